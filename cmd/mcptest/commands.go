@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Commands that exercise resources and prompts rather than call a tool. In a
+// test file or interactively they are written like tool calls:
+//
+//	resources/list
+//	resources/read <uri>
+//	prompts/list
+//	prompts/get <name> arg1=value1 arg2=value2
+var commands = map[string]func(m *MCPTester, call ToolCall) (*ToolResult, error){
+	"resources/list": (*MCPTester).ListResources,
+	"resources/read": (*MCPTester).ReadResource,
+	"prompts/list":   (*MCPTester).ListPrompts,
+	"prompts/get":    (*MCPTester).GetPrompt,
+}
+
+func isCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// Run carries out a line of a test file: a command or a tool call.
+func (m *MCPTester) Run(call ToolCall) (*ToolResult, error) {
+	if command, ok := commands[call.Tool]; ok {
+		return command(m, call)
+	}
+	return m.CallTool(call)
+}
+
+func (m *MCPTester) ListResources(ToolCall) (*ToolResult, error) {
+	raw, failed, err := m.request("resources/list", nil)
+	if err != nil || failed != nil {
+		return failed, err
+	}
+
+	resources, _ := raw["resources"].([]interface{})
+	fmt.Printf("📚 Resources (%d):\n", len(resources))
+	var lines []string
+	for i, resource := range resources {
+		if r, ok := resource.(map[string]interface{}); ok {
+			line := fmt.Sprintf("%v - %v", r["uri"], r["name"])
+			if mimeType, ok := r["mimeType"].(string); ok {
+				line += fmt.Sprintf(" (%s)", mimeType)
+			}
+			fmt.Printf("  %d. %s\n", i+1, line)
+			lines = append(lines, line)
+		}
+	}
+	fmt.Println()
+	return &ToolResult{Text: strings.Join(lines, "\n")}, nil
+}
+
+func (m *MCPTester) ReadResource(call ToolCall) (*ToolResult, error) {
+	if len(call.Positional) != 1 {
+		return nil, fmt.Errorf("usage: resources/read <uri>")
+	}
+	uri := call.Positional[0]
+
+	fmt.Printf("📄 Reading resource: %s\n", uri)
+	raw, failed, err := m.request("resources/read", map[string]interface{}{"uri": uri})
+	if err != nil || failed != nil {
+		return failed, err
+	}
+
+	contents, _ := raw["contents"].([]interface{})
+	var texts []string
+	for _, item := range contents {
+		c, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if text, ok := c["text"].(string); ok {
+			texts = append(texts, text)
+			printIndented(text)
+		} else if blob, ok := c["blob"].(string); ok {
+			fmt.Printf("   [%v blob, %d bytes of base64]\n", c["mimeType"], len(blob))
+		}
+	}
+	fmt.Println()
+	return &ToolResult{Text: strings.Join(texts, "\n")}, nil
+}
+
+func (m *MCPTester) ListPrompts(ToolCall) (*ToolResult, error) {
+	raw, failed, err := m.request("prompts/list", nil)
+	if err != nil || failed != nil {
+		return failed, err
+	}
+
+	prompts, _ := raw["prompts"].([]interface{})
+	fmt.Printf("💡 Prompts (%d):\n", len(prompts))
+	var lines []string
+	for i, prompt := range prompts {
+		p, ok := prompt.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		line := fmt.Sprintf("%v - %v", p["name"], p["description"])
+		if arguments, ok := p["arguments"].([]interface{}); ok && len(arguments) > 0 {
+			var names []string
+			for _, argument := range arguments {
+				if a, ok := argument.(map[string]interface{}); ok {
+					name := fmt.Sprint(a["name"])
+					if required, _ := a["required"].(bool); required {
+						name += "*"
+					}
+					names = append(names, name)
+				}
+			}
+			line += fmt.Sprintf(" (arguments: %s)", strings.Join(names, ", "))
+		}
+		fmt.Printf("  %d. %s\n", i+1, line)
+		lines = append(lines, line)
+	}
+	fmt.Println()
+	return &ToolResult{Text: strings.Join(lines, "\n")}, nil
+}
+
+func (m *MCPTester) GetPrompt(call ToolCall) (*ToolResult, error) {
+	if len(call.Positional) != 1 {
+		return nil, fmt.Errorf("usage: prompts/get <name> arg1=value1 arg2=value2")
+	}
+	name := call.Positional[0]
+
+	// Prompt arguments are always strings
+	arguments := make(map[string]string, len(call.Args))
+	keys := make([]string, 0, len(call.Args))
+	for key, value := range call.Args {
+		switch value := value.(type) {
+		case []string:
+			arguments[key] = "[" + strings.Join(value, ",") + "]"
+		default:
+			arguments[key] = fmt.Sprint(value)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Printf("💬 Getting prompt: %s\n", name)
+	for _, key := range keys {
+		fmt.Printf("     %s: %s\n", key, arguments[key])
+	}
+	raw, failed, err := m.request("prompts/get", map[string]interface{}{"name": name, "arguments": arguments})
+	if err != nil || failed != nil {
+		return failed, err
+	}
+
+	if description, ok := raw["description"].(string); ok && description != "" {
+		fmt.Printf("   %s\n", description)
+	}
+	messages, _ := raw["messages"].([]interface{})
+	var lines []string
+	for _, message := range messages {
+		msg, ok := message.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		text := "[non-text content]"
+		if content, ok := msg["content"].(map[string]interface{}); ok {
+			if t, ok := content["text"].(string); ok {
+				text = t
+			}
+		}
+		line := fmt.Sprintf("%v: %s", msg["role"], text)
+		printIndented(line)
+		lines = append(lines, line)
+	}
+	fmt.Println()
+	return &ToolResult{Text: strings.Join(lines, "\n")}, nil
+}
+
+// request sends a command's request and returns its result. If the server
+// returns an error, it is printed and returned as a failed result instead.
+func (m *MCPTester) request(method string, params interface{}) (map[string]interface{}, *ToolResult, error) {
+	resp, err := m.sendRequest(method, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s failed: %w", method, err)
+	}
+	if resp.Error != nil {
+		fmt.Printf("❌ %s error: %v\n\n", method, resp.Error)
+		return nil, &ToolResult{Text: fmt.Sprint(resp.Error), IsError: true}, nil
+	}
+	raw, _ := resp.Result.(map[string]interface{})
+	return raw, nil, nil
+}
+
+// printIndented prints text indented under a heading.
+func printIndented(text string) {
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Printf("   %s\n", line)
+	}
+}
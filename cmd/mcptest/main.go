@@ -3,6 +3,8 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
@@ -31,6 +33,40 @@ type MCPResponse struct {
 type ToolCall struct {
 	Tool string
 	Args map[string]interface{}
+	// Positional holds the words without an =, which commands such as
+	// resources/read take as their operands
+	Positional []string
+	// Assertions made about the result by the directives following the call
+	Assertions []Assertion
+}
+
+// Assertion is an expect: or assert_contains: directive in a test file.
+type Assertion struct {
+	Directive string
+	Text      string
+	Line      int
+}
+
+// ToolResult is the outcome of a tool call.
+type ToolResult struct {
+	Text    string // text content, one item per line
+	IsError bool
+}
+
+// Check reports why the result does not satisfy the assertion, if it does not.
+// expect: compares the whole text, ignoring surrounding whitespace.
+func (a Assertion) Check(result *ToolResult) error {
+	switch a.Directive {
+	case "expect":
+		if strings.TrimSpace(result.Text) != a.Text {
+			return fmt.Errorf("line %d: expected %q, got %q", a.Line, a.Text, strings.TrimSpace(result.Text))
+		}
+	case "assert_contains":
+		if !strings.Contains(result.Text, a.Text) {
+			return fmt.Errorf("line %d: expected result to contain %q, got %q", a.Line, a.Text, result.Text)
+		}
+	}
+	return nil
 }
 
 type MCPTester struct {
@@ -40,9 +76,18 @@ type MCPTester struct {
 	stderr io.ReadCloser
 	mu     sync.Mutex
 	nextID int
+	// Timeout is how long to wait for each response; zero waits forever.
+	Timeout time.Duration
+	lines   chan []byte // lines read from stdout, closed when it is
+	// schemas holds each tool's input schema once ListTools has run
+	schemas map[string]map[string]interface{}
+	readErr error // why reading stopped, once lines is closed
 }
 
-func NewMCPTester(serverCommand string, serverArgs ...string) (*MCPTester, error) {
+// errNoResponse is returned by sendRequest when the server does not respond in time.
+var errNoResponse = errors.New("no response")
+
+func NewMCPTester(timeout time.Duration, serverCommand string, serverArgs ...string) (*MCPTester, error) {
 	cmd := exec.Command(serverCommand, serverArgs...)
 
 	stdin, err := cmd.StdinPipe()
@@ -65,19 +110,39 @@ func NewMCPTester(serverCommand string, serverArgs ...string) (*MCPTester, error
 	}
 
 	tester := &MCPTester{
-		cmd:    cmd,
-		stdin:  stdin,
-		stdout: stdout,
-		stderr: stderr,
-		nextID: 1,
+		cmd:     cmd,
+		stdin:   stdin,
+		stdout:  stdout,
+		stderr:  stderr,
+		nextID:  1,
+		Timeout: timeout,
+		lines:   make(chan []byte),
 	}
 
-	// Start stderr reader
+	// Start stdout and stderr readers
+	go tester.readStdout()
 	go tester.readStderr()
 
 	return tester, nil
 }
 
+// readStdout passes each line the server writes to sendRequest, so a server
+// that stops responding cannot block it past its timeout.
+func (m *MCPTester) readStdout() {
+	reader := bufio.NewReader(m.stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			m.lines <- line
+		}
+		if err != nil {
+			m.readErr = err
+			close(m.lines)
+			return
+		}
+	}
+}
+
 func (m *MCPTester) readStderr() {
 	scanner := bufio.NewScanner(m.stderr)
 	for scanner.Scan() {
@@ -94,11 +159,18 @@ func (m *MCPTester) getNextID() int {
 }
 
 func (m *MCPTester) sendRequest(method string, params interface{}) (*MCPResponse, error) {
+	return m.sendRequestWithin(m.Timeout, method, params)
+}
+
+// sendRequestWithin sends a request and waits up to timeout for its response.
+// Notifications and responses to earlier requests are printed and skipped.
+func (m *MCPTester) sendRequestWithin(timeout time.Duration, method string, params interface{}) (*MCPResponse, error) {
+	id := m.getNextID()
 	req := MCPRequest{
 		JSONRPC: "2.0",
 		Method:  method,
 		Params:  params,
-		ID:      m.getNextID(),
+		ID:      id,
 	}
 
 	reqBytes, err := json.Marshal(req)
@@ -112,24 +184,44 @@ func (m *MCPTester) sendRequest(method string, params interface{}) (*MCPResponse
 		return nil, fmt.Errorf("failed to write request: %w", err)
 	}
 
-	// Read response
-	reader := bufio.NewReader(m.stdout)
-	line, err := reader.ReadBytes('\n')
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
 	}
 
-	fmt.Printf("← %s\n", string(line))
+	// Read response
+	for {
+		var line []byte
+		select {
+		case l, ok := <-m.lines:
+			if !ok {
+				return nil, fmt.Errorf("failed to read response: %w", m.readErr)
+			}
+			line = l
+		case <-deadline:
+			return nil, fmt.Errorf("%w to %s within %s", errNoResponse, method, timeout)
+		}
+
+		fmt.Printf("← %s\n", string(line))
 
-	var resp MCPResponse
-	if err := json.Unmarshal(line, &resp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		var resp MCPResponse
+		if err := json.Unmarshal(line, &resp); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+		if number, ok := resp.ID.(float64); ok && int(number) == id {
+			return &resp, nil
+		}
 	}
-
-	return &resp, nil
 }
 
-func (m *MCPTester) Initialize() error {
+// initializeRetryInterval is how long Initialize waits for each attempt.
+const initializeRetryInterval = 500 * time.Millisecond
+
+// Initialize sends initialize until the server responds or startupTimeout
+// passes, so servers that are slow to start are not mistaken for broken ones.
+func (m *MCPTester) Initialize(startupTimeout time.Duration) error {
 	params := map[string]interface{}{
 		"capabilities": map[string]interface{}{},
 		"clientInfo": map[string]interface{}{
@@ -138,9 +230,19 @@ func (m *MCPTester) Initialize() error {
 		},
 	}
 
-	resp, err := m.sendRequest("initialize", params)
-	if err != nil {
-		return fmt.Errorf("initialization failed: %w", err)
+	deadline := time.Now().Add(startupTimeout)
+	var resp *MCPResponse
+	for {
+		var err error
+		wait := min(initializeRetryInterval, max(time.Until(deadline), 0))
+		resp, err = m.sendRequestWithin(wait, "initialize", params)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errNoResponse) || time.Now().After(deadline) {
+			return fmt.Errorf("initialization failed: %w", err)
+		}
+		fmt.Println("⏳ Waiting for server to start...")
 	}
 
 	if resp.Error != nil {
@@ -165,11 +267,16 @@ func (m *MCPTester) ListTools() error {
 	if result, ok := resp.Result.(map[string]interface{}); ok {
 		if tools, ok := result["tools"].([]interface{}); ok {
 			fmt.Printf("\n📋 Available Tools (%d):\n", len(tools))
+			m.schemas = make(map[string]map[string]interface{})
 			for i, tool := range tools {
 				if t, ok := tool.(map[string]interface{}); ok {
 					name := t["name"]
 					desc := t["description"]
 					fmt.Printf("  %d. %s - %s\n", i+1, name, desc)
+					if name, ok := name.(string); ok {
+						schema, _ := t["inputSchema"].(map[string]interface{})
+						m.schemas[name] = schema
+					}
 				}
 			}
 			fmt.Println()
@@ -179,7 +286,7 @@ func (m *MCPTester) ListTools() error {
 	return nil
 }
 
-func (m *MCPTester) CallTool(toolCall ToolCall) error {
+func (m *MCPTester) CallTool(toolCall ToolCall) (*ToolResult, error) {
 	params := map[string]interface{}{
 		"name":      toolCall.Tool,
 		"arguments": toolCall.Args,
@@ -195,20 +302,23 @@ func (m *MCPTester) CallTool(toolCall ToolCall) error {
 
 	resp, err := m.sendRequest("tools/call", params)
 	if err != nil {
-		return fmt.Errorf("failed to call tool %s: %w", toolCall.Tool, err)
+		return nil, fmt.Errorf("failed to call tool %s: %w", toolCall.Tool, err)
 	}
 
 	if resp.Error != nil {
 		fmt.Printf("❌ Tool error: %v\n\n", resp.Error)
-		return nil
+		return &ToolResult{Text: fmt.Sprint(resp.Error), IsError: true}, nil
 	}
 
 	// Pretty print result
+	toolResult := &ToolResult{}
+	var texts []string
 	if result, ok := resp.Result.(map[string]interface{}); ok {
 		isError := false
 		if errFlag, ok := result["isError"].(bool); ok {
 			isError = errFlag
 		}
+		toolResult.IsError = isError
 
 		if isError {
 			fmt.Printf("❌ Tool returned error:\n")
@@ -220,11 +330,8 @@ func (m *MCPTester) CallTool(toolCall ToolCall) error {
 			for _, item := range content {
 				if c, ok := item.(map[string]interface{}); ok {
 					if text, ok := c["text"].(string); ok {
-						// Indent the output
-						lines := strings.Split(text, "\n")
-						for _, line := range lines {
-							fmt.Printf("   %s\n", line)
-						}
+						texts = append(texts, text)
+						printIndented(text)
 					}
 				}
 			}
@@ -232,7 +339,8 @@ func (m *MCPTester) CallTool(toolCall ToolCall) error {
 	}
 
 	fmt.Println()
-	return nil
+	toolResult.Text = strings.Join(texts, "\n")
+	return toolResult, nil
 }
 
 func (m *MCPTester) Close() error {
@@ -242,7 +350,8 @@ func (m *MCPTester) Close() error {
 	return m.cmd.Wait()
 }
 
-// Parse tool calls from simple text format
+// Parse tool calls from simple text format. A call may be followed by
+// expect: and assert_contains: directives about its result.
 func parseToolCalls(filename string) ([]ToolCall, error) {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -252,65 +361,124 @@ func parseToolCalls(filename string) ([]ToolCall, error) {
 
 	var calls []ToolCall
 	scanner := bufio.NewScanner(file)
+	lineNumber := 0
 
 	for scanner.Scan() {
+		lineNumber++
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 
-		// Parse format: tool_name arg1=value1 arg2=value2
-		parts := strings.Fields(line)
-		if len(parts) == 0 {
+		if directive, text, ok := parseDirective(line); ok {
+			if len(calls) == 0 {
+				return nil, fmt.Errorf("line %d: %s: must follow a tool call", lineNumber, directive)
+			}
+			last := &calls[len(calls)-1]
+			last.Assertions = append(last.Assertions, Assertion{Directive: directive, Text: text, Line: lineNumber})
 			continue
 		}
 
-		call := ToolCall{
-			Tool: parts[0],
-			Args: make(map[string]interface{}),
+		if call, ok := parseCallLine(line); ok {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls, scanner.Err()
+}
+
+// parseDirective splits an expect: or assert_contains: line.
+func parseDirective(line string) (directive, text string, ok bool) {
+	for _, directive := range []string{"expect", "assert_contains"} {
+		if text, ok := strings.CutPrefix(line, directive+":"); ok {
+			return directive, strings.TrimSpace(text), true
 		}
+	}
+	return "", "", false
+}
+
+// parseCallLine parses a call in the format: tool_name arg1=value1 arg2=value2
+func parseCallLine(line string) (ToolCall, bool) {
+	parts := strings.Fields(line)
+	if len(parts) == 0 {
+		return ToolCall{}, false
+	}
+
+	call := ToolCall{
+		Tool: parts[0],
+		Args: make(map[string]interface{}),
+	}
 
-		for _, part := range parts[1:] {
-			if strings.Contains(part, "=") {
-				kv := strings.SplitN(part, "=", 2)
-				key := kv[0]
-				value := kv[1]
-
-				// Try to parse as different types
-				if value == "true" {
-					call.Args[key] = true
-				} else if value == "false" {
-					call.Args[key] = false
-				} else if num, err := strconv.ParseFloat(value, 64); err == nil {
-					call.Args[key] = num
-				} else if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
-					// Simple array parsing
-					value = strings.Trim(value, "[]")
-					if value != "" {
-						items := strings.Split(value, ",")
-						var array []string
-						for _, item := range items {
-							array = append(array, strings.TrimSpace(item))
-						}
-						call.Args[key] = array
-					} else {
-						call.Args[key] = []string{}
+	for _, part := range parts[1:] {
+		if !strings.Contains(part, "=") {
+			call.Positional = append(call.Positional, part)
+		} else {
+			kv := strings.SplitN(part, "=", 2)
+			key := kv[0]
+			value := kv[1]
+
+			// Try to parse as different types
+			if value == "true" {
+				call.Args[key] = true
+			} else if value == "false" {
+				call.Args[key] = false
+			} else if num, err := strconv.ParseFloat(value, 64); err == nil {
+				call.Args[key] = num
+			} else if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+				// Simple array parsing
+				value = strings.Trim(value, "[]")
+				if value != "" {
+					items := strings.Split(value, ",")
+					var array []string
+					for _, item := range items {
+						array = append(array, strings.TrimSpace(item))
 					}
+					call.Args[key] = array
 				} else {
-					call.Args[key] = value
+					call.Args[key] = []string{}
 				}
+			} else {
+				call.Args[key] = value
 			}
 		}
-
-		calls = append(calls, call)
 	}
 
-	return calls, scanner.Err()
+	return call, true
+}
+
+// checkCall prints how a call does not match the server's schema and reports
+// whether to make it anyway: mismatches are warnings unless strict is set.
+func checkCall(tester *MCPTester, call ToolCall, strict bool) bool {
+	if isCommand(call.Tool) {
+		return true
+	}
+	problems := tester.CheckCall(call)
+	if len(problems) == 0 {
+		return true
+	}
+	symbol := "⚠️ "
+	if strict {
+		symbol = "🚫"
+	}
+	for _, problem := range problems {
+		fmt.Printf("%s %s: %s\n", symbol, call.Tool, problem)
+	}
+	if strict {
+		fmt.Println()
+	}
+	return !strict
 }
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <server-command> [test-file]\n", os.Args[0])
+	timeout := flag.Duration("timeout", 30*time.Second, "")
+	startupTimeout := flag.Duration("startup-timeout", 10*time.Second, "")
+	strict := flag.Bool("strict", false, "")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] <server-command> [test-file]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\nOptions:\n")
+		fmt.Fprintf(os.Stderr, "  --timeout <duration>          How long to wait for each response, 0 for no limit (default 30s)\n")
+		fmt.Fprintf(os.Stderr, "  --startup-timeout <duration>  How long to wait for the server to answer initialize (default 10s)\n")
+		fmt.Fprintf(os.Stderr, "  --strict                      Do not make calls that do not match the tool's schema\n")
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  %s ./tmux-mcp\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s ./tmux-mcp test-calls.txt\n", os.Args[0])
@@ -318,28 +486,38 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  tool_name arg1=value1 arg2=value2\n")
 		fmt.Fprintf(os.Stderr, "  tmux_list\n")
 		fmt.Fprintf(os.Stderr, "  tmux_new_session command=[echo,hello] prefix=test\n")
+		fmt.Fprintf(os.Stderr, "\nResources and prompts are exercised with these commands:\n")
+		fmt.Fprintf(os.Stderr, "  resources/list\n")
+		fmt.Fprintf(os.Stderr, "  resources/read <uri>\n")
+		fmt.Fprintf(os.Stderr, "  prompts/list\n")
+		fmt.Fprintf(os.Stderr, "  prompts/get <name> arg1=value1 arg2=value2\n")
+		fmt.Fprintf(os.Stderr, "\nA call may be followed by assertions about its result; any failure makes\n")
+		fmt.Fprintf(os.Stderr, "the exit status non-zero:\n")
+		fmt.Fprintf(os.Stderr, "  expect: <the whole result text>\n")
+		fmt.Fprintf(os.Stderr, "  assert_contains: <text somewhere in the result>\n")
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	serverCommand := os.Args[1]
+	serverCommand := flag.Arg(0)
 	var testFile string
-	if len(os.Args) > 2 {
-		testFile = os.Args[2]
+	if flag.NArg() > 1 {
+		testFile = flag.Arg(1)
 	}
 
 	fmt.Printf("🚀 Starting MCP server: %s\n", serverCommand)
 
-	tester, err := NewMCPTester(serverCommand)
+	tester, err := NewMCPTester(*timeout, serverCommand)
 	if err != nil {
 		log.Fatalf("Failed to start MCP tester: %v", err)
 	}
 	defer tester.Close()
 
-	// Give server time to start
-	time.Sleep(100 * time.Millisecond)
-
 	// Initialize
-	if err := tester.Initialize(); err != nil {
+	if err := tester.Initialize(*startupTimeout); err != nil {
 		log.Fatalf("Failed to initialize: %v", err)
 	}
 
@@ -356,18 +534,50 @@ func main() {
 			log.Fatalf("Failed to parse test file: %v", err)
 		}
 
+		passed, failed, invalid, errored := 0, 0, 0, 0
 		for i, call := range calls {
 			fmt.Printf("--- Test %d ---\n", i+1)
-			if err := tester.CallTool(call); err != nil {
+			if !checkCall(tester, call, *strict) {
+				invalid++
+				failed += len(call.Assertions)
+				continue
+			}
+			result, err := tester.Run(call)
+			if err != nil {
 				log.Printf("Test %d failed: %v", i+1, err)
+				errored++
+				failed += len(call.Assertions)
+				continue
+			}
+			for _, assertion := range call.Assertions {
+				if err := assertion.Check(result); err != nil {
+					fmt.Printf("❌ %s failed: %v\n\n", assertion.Directive, err)
+					failed++
+				} else {
+					passed++
+				}
 			}
 		}
 
 		fmt.Printf("✅ Completed %d test calls\n", len(calls))
+		if passed+failed > 0 {
+			fmt.Printf("📊 Assertions: %d passed, %d failed\n", passed, failed)
+		}
+		if invalid > 0 {
+			fmt.Printf("🚫 %d calls were not made because they do not match the server's schema\n", invalid)
+		}
+		if errored > 0 {
+			fmt.Printf("💥 %d calls failed without a result\n", errored)
+		}
+		if failed > 0 || invalid > 0 || errored > 0 {
+			tester.Close()
+			os.Exit(1)
+		}
 	} else {
 		// Interactive mode
 		fmt.Println("💬 Interactive mode - enter tool calls (Ctrl+C to exit)")
 		fmt.Println("Format: tool_name arg1=value1 arg2=value2")
+		fmt.Println("Also: resources/list, resources/read <uri>, prompts/list, prompts/get <name> arg=value")
 		fmt.Println()
 
 		scanner := bufio.NewScanner(os.Stdin)
@@ -386,50 +596,12 @@ func main() {
 				break
 			}
 
-			// Parse the line directly
-			parts := strings.Fields(line)
-			if len(parts) == 0 {
+			call, ok := parseCallLine(line)
+			if !ok || !checkCall(tester, call, *strict) {
 				continue
 			}
 
-			call := ToolCall{
-				Tool: parts[0],
-				Args: make(map[string]interface{}),
-			}
-
-			for _, part := range parts[1:] {
-				if strings.Contains(part, "=") {
-					kv := strings.SplitN(part, "=", 2)
-					key := kv[0]
-					value := kv[1]
-
-					// Try to parse as different types
-					if value == "true" {
-						call.Args[key] = true
-					} else if value == "false" {
-						call.Args[key] = false
-					} else if num, err := strconv.ParseFloat(value, 64); err == nil {
-						call.Args[key] = num
-					} else if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
-						// Simple array parsing
-						value = strings.Trim(value, "[]")
-						if value != "" {
-							items := strings.Split(value, ",")
-							var array []string
-							for _, item := range items {
-								array = append(array, strings.TrimSpace(item))
-							}
-							call.Args[key] = array
-						} else {
-							call.Args[key] = []string{}
-						}
-					} else {
-						call.Args[key] = value
-					}
-				}
-			}
-
-			if err := tester.CallTool(call); err != nil {
+			if _, err := tester.Run(call); err != nil {
 				fmt.Printf("Error: %v\n", err)
 			}
 		}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// testServerEnv makes the test binary act as the MCP server under test. Its
+// value is how long the server takes to start.
+const testServerEnv = "MCPTEST_TEST_SERVER"
+
+func TestMain(m *testing.M) {
+	if delay := os.Getenv(testServerEnv); delay != "" {
+		serveTestServer(delay)
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// serveTestServer runs a stdio MCP server with an echo tool, a tool that never
+// returns, a readme resource and a greet prompt.
+func serveTestServer(delay string) {
+	startup, _ := time.ParseDuration(delay)
+	time.Sleep(startup)
+
+	s := server.NewMCPServer("test-server", "1.0.0",
+		server.WithResourceCapabilities(false, false),
+		server.WithPromptCapabilities(false),
+	)
+	s.AddResource(mcp.NewResource("test://readme", "readme", mcp.WithMIMEType("text/plain")),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			return []mcp.ResourceContents{mcp.TextResourceContents{URI: request.Params.URI, MIMEType: "text/plain", Text: "read me"}}, nil
+		})
+	s.AddPrompt(mcp.NewPrompt("greet",
+		mcp.WithPromptDescription("Greet someone"),
+		mcp.WithArgument("name", mcp.RequiredArgument()),
+	), func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		return mcp.NewGetPromptResult("A greeting", []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent("Say hello to "+request.Params.Arguments["name"])),
+		}), nil
+	})
+	s.AddTool(mcp.NewTool("echo",
+		mcp.WithString("message", mcp.Required()),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("echo: " + request.GetString("message", "")), nil
+	})
+	s.AddTool(mcp.NewTool("hang"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err := server.ServeStdio(s); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newTestTester(t *testing.T, startup time.Duration, timeout time.Duration) *MCPTester {
+	t.Helper()
+	t.Setenv(testServerEnv, startup.String())
+	tester, err := NewMCPTester(timeout, os.Args[0])
+	if err != nil {
+		t.Fatalf("Failed to start tester: %v", err)
+	}
+	t.Cleanup(func() { _ = tester.Close() })
+	return tester
+}
+
+func TestInitializeWaitsForSlowServer(t *testing.T) {
+	tester := newTestTester(t, 1200*time.Millisecond, time.Second)
+
+	if err := tester.Initialize(5 * time.Second); err != nil {
+		t.Fatalf("Expected initialize to succeed once the server started: %v", err)
+	}
+
+	// Responses to the earlier attempts do not get mixed up with later calls
+	result, err := tester.CallTool(ToolCall{Tool: "echo", Args: map[string]interface{}{"message": "hi"}})
+	if err != nil || result.Text != "echo: hi" {
+		t.Fatalf("Expected the echo result, got %+v (err %v)", result, err)
+	}
+}
+
+func TestInitializeGivesUpAfterStartupTimeout(t *testing.T) {
+	tester := newTestTester(t, 5*time.Second, time.Second)
+	// Do not wait for the server to finish starting when closing
+	t.Cleanup(func() { _ = tester.cmd.Process.Kill() })
+
+	start := time.Now()
+	err := tester.Initialize(time.Second)
+	if !errors.Is(err, errNoResponse) {
+		t.Fatalf("Expected initialize to time out, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected initialize to give up after about a second, took %s", elapsed)
+	}
+}
+
+func TestCallToolTimesOut(t *testing.T) {
+	tester := newTestTester(t, 0, 300*time.Millisecond)
+	if err := tester.Initialize(5 * time.Second); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	_, err := tester.CallTool(ToolCall{Tool: "hang", Args: map[string]interface{}{}})
+	if !errors.Is(err, errNoResponse) {
+		t.Fatalf("Expected the call to time out, got %v", err)
+	}
+}
+
+func writeTestFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "calls.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	return path
+}
+
+func TestParseToolCallsWithAssertions(t *testing.T) {
+	path := writeTestFile(t, `# a comment
+echo message=hello
+expect: echo: hello
+assert_contains:  hel
+
+tmux_list
+`)
+
+	calls, err := parseToolCalls(path)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 calls, got %d", len(calls))
+	}
+	want := []Assertion{
+		{Directive: "expect", Text: "echo: hello", Line: 3},
+		{Directive: "assert_contains", Text: "hel", Line: 4},
+	}
+	if !reflect.DeepEqual(calls[0].Assertions, want) {
+		t.Errorf("Expected assertions %+v, got %+v", want, calls[0].Assertions)
+	}
+	if calls[0].Args["message"] != "hello" {
+		t.Errorf("Expected the call's arguments to be parsed, got %v", calls[0].Args)
+	}
+	if len(calls[1].Assertions) != 0 {
+		t.Errorf("Expected no assertions on the second call, got %+v", calls[1].Assertions)
+	}
+}
+
+func TestParseToolCallsRejectsLeadingDirective(t *testing.T) {
+	path := writeTestFile(t, "expect: nothing\necho message=hi\n")
+
+	_, err := parseToolCalls(path)
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected an error pointing at line 1, got %v", err)
+	}
+}
+
+func TestAssertionCheck(t *testing.T) {
+	result := &ToolResult{Text: "Session created: test-1\nHash: abc\n"}
+
+	tests := []struct {
+		assertion Assertion
+		pass      bool
+	}{
+		{Assertion{Directive: "expect", Text: "Session created: test-1\nHash: abc"}, true},
+		{Assertion{Directive: "expect", Text: "Session created: test-1"}, false},
+		{Assertion{Directive: "assert_contains", Text: "Hash: abc"}, true},
+		{Assertion{Directive: "assert_contains", Text: "Hash: xyz"}, false},
+	}
+
+	for _, tt := range tests {
+		err := tt.assertion.Check(result)
+		if (err == nil) != tt.pass {
+			t.Errorf("%s %q: expected pass=%v, got %v", tt.assertion.Directive, tt.assertion.Text, tt.pass, err)
+		}
+	}
+}
+
+func TestRunResourceAndPromptCommands(t *testing.T) {
+	tester := newTestTester(t, 0, 5*time.Second)
+	if err := tester.Initialize(5 * time.Second); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"resources/list", "test://readme - readme (text/plain)"},
+		{"resources/read test://readme", "read me"},
+		{"prompts/list", "greet - Greet someone (arguments: name*)"},
+		{"prompts/get greet name=Ada", "user: Say hello to Ada"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			call, _ := parseCallLine(tt.line)
+			result, err := tester.Run(call)
+			if err != nil {
+				t.Fatalf("Failed to run: %v", err)
+			}
+			if result.IsError || result.Text != tt.want {
+				t.Errorf("Expected %q, got %+v", tt.want, result)
+			}
+		})
+	}
+
+	// Errors from the server fail the command rather than the tester
+	call, _ := parseCallLine("resources/read test://missing")
+	result, err := tester.Run(call)
+	if err != nil || !result.IsError {
+		t.Errorf("Expected an error result for a missing resource, got %+v (err %v)", result, err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// CheckCall cross-checks a call against the input schema the server advertised
+// for the tool and describes each mismatch: an unknown tool, a missing required
+// argument, an argument the schema does not declare, or a value of the wrong type.
+func (m *MCPTester) CheckCall(call ToolCall) []string {
+	if m.schemas == nil {
+		return nil
+	}
+	schema, ok := m.schemas[call.Tool]
+	if !ok {
+		return []string{fmt.Sprintf("the server has no tool named %s", call.Tool)}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	var problems []string
+
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, present := call.Args[name]; !present {
+					problems = append(problems, fmt.Sprintf("missing required argument %s", name))
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(call.Args))
+	for name := range call.Args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, ok := properties[name].(map[string]interface{})
+		if !ok {
+			if additional, _ := schema["additionalProperties"].(bool); !additional {
+				problems = append(problems, fmt.Sprintf("unknown argument %s (expected one of %s)", name, strings.Join(sortedKeys(properties), ", ")))
+			}
+			continue
+		}
+		if schemaType, ok := property["type"].(string); ok && !valueFitsType(call.Args[name], schemaType) {
+			problems = append(problems, fmt.Sprintf("argument %s is %v but should be %s", name, call.Args[name], article(schemaType)))
+		}
+	}
+
+	return problems
+}
+
+// valueFitsType reports whether a value parsed from a call line is plausible
+// for a JSON schema type.
+func valueFitsType(value interface{}, schemaType string) bool {
+	switch value := value.(type) {
+	case bool:
+		return schemaType == "boolean"
+	case float64:
+		if schemaType == "integer" {
+			return value == float64(int64(value))
+		}
+		return schemaType == "number"
+	case []string:
+		return schemaType == "array"
+	default:
+		return slices.Contains([]string{"string", "object"}, schemaType)
+	}
+}
+
+func article(schemaType string) string {
+	switch schemaType {
+	case "array", "integer", "object":
+		return "an " + schemaType
+	default:
+		return "a " + schemaType
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
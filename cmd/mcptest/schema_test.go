@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCheckCall(t *testing.T) {
+	tester := &MCPTester{schemas: map[string]map[string]interface{}{
+		"tmux_send_keys": {
+			"type": "object",
+			"properties": map[string]interface{}{
+				"session": map[string]interface{}{"type": "string"},
+				"keys":    map[string]interface{}{"type": "string"},
+				"enter":   map[string]interface{}{"type": "boolean"},
+				"lines":   map[string]interface{}{"type": "integer"},
+				"command": map[string]interface{}{"type": "array"},
+			},
+			"required": []interface{}{"session"},
+		},
+	}}
+
+	tests := []struct {
+		name string
+		call ToolCall
+		want []string
+	}{
+		{
+			name: "valid",
+			call: ToolCall{Tool: "tmux_send_keys", Args: map[string]interface{}{"session": "s", "enter": true, "lines": 3.0, "command": []string{"ls"}}},
+		},
+		{
+			name: "unknown tool",
+			call: ToolCall{Tool: "tmux_sned_keys", Args: map[string]interface{}{}},
+			want: []string{"the server has no tool named tmux_sned_keys"},
+		},
+		{
+			name: "typo",
+			call: ToolCall{Tool: "tmux_send_keys", Args: map[string]interface{}{"sesion": "s"}},
+			want: []string{
+				"missing required argument session",
+				"unknown argument sesion (expected one of command, enter, keys, lines, session)",
+			},
+		},
+		{
+			name: "wrong types",
+			call: ToolCall{Tool: "tmux_send_keys", Args: map[string]interface{}{"session": "s", "enter": "yes", "lines": 1.5}},
+			want: []string{
+				"argument enter is yes but should be a boolean",
+				"argument lines is 1.5 but should be an integer",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tester.CheckCall(tt.call); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestListToolsRecordsSchemas(t *testing.T) {
+	tester := newTestTester(t, 0, 5*time.Second)
+	if err := tester.Initialize(5 * time.Second); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	if err := tester.ListTools(); err != nil {
+		t.Fatalf("Failed to list tools: %v", err)
+	}
+
+	problems := tester.CheckCall(ToolCall{Tool: "echo", Args: map[string]interface{}{"mesage": "hi"}})
+	want := []string{"missing required argument message", "unknown argument mesage (expected one of message)"}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("Expected %q, got %q", want, problems)
+	}
+}
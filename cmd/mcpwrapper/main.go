@@ -1,17 +1,19 @@
 package main
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -24,14 +26,33 @@ type MCPWrapper struct {
 	binaryPath     string
 	serverArgs     []string
 	currentProcess *exec.Cmd
-	currentStdin   io.WriteCloser
-	currentStdout  io.ReadCloser
+	processExited  chan struct{} // closed once currentProcess has been waited for
+	startedAt      time.Time
+	currentConn    *serverConn
 	watcher        *fsnotify.Watcher
+	watch          watchTarget
+	debounce       time.Duration
 	mu             sync.RWMutex
 	isRestarting   bool
-	currentTools   map[string]*mcp.Tool
-	requestID      int
-	logFile        *os.File
+	restartDone    chan struct{} // closed when the restart in progress finishes
+	inFlight       atomic.Int64  // calls sent on currentConn that have not finished
+	drainTimeout   time.Duration
+	// callTimeout bounds each proxied request; zero means no limit
+	callTimeout      time.Duration
+	restartOnTimeout bool
+	restartMu        sync.Mutex // serializes restarts
+	// Crash recovery; see superviseCrashes
+	crashed       chan struct{}
+	crashRestarts int
+	maxRestarts   int
+	crashBackoff  time.Duration
+	closed        chan struct{}
+	currentTools  map[string]*mcp.Tool
+	// Names of the prompts and URIs of the resources mirrored from the server
+	currentPrompts   []string
+	currentResources []string
+	requestID        atomic.Int64
+	logFile          *os.File
 }
 
 type MCPMessage struct {
@@ -43,16 +64,63 @@ type MCPMessage struct {
 	ID      interface{} `json:"id,omitempty"`
 }
 
-func NewMCPWrapper(binaryPath string, serverArgs ...string) (*MCPWrapper, error) {
+// NewMCPWrapper wraps the server binary at binaryPath. The server is restarted
+// when the paths described by watch change; an empty watch means the binary.
+func NewMCPWrapper(binaryPath, watch string, serverArgs ...string) (*MCPWrapper, error) {
 	absPath, err := filepath.Abs(binaryPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get absolute path: %w", err)
 	}
+	if watch == "" {
+		watch = absPath
+	}
+	target, err := parseWatchTarget(watch)
+	if err != nil {
+		return nil, err
+	}
 
 	wrapper := &MCPWrapper{
 		binaryPath:   absPath,
+		watch:        target,
 		serverArgs:   serverArgs,
 		currentTools: make(map[string]*mcp.Tool),
+		debounce:     defaultDebounce,
+		drainTimeout: defaultDrainTimeout,
+		crashed:      make(chan struct{}, 1),
+		maxRestarts:  defaultMaxRestarts,
+		crashBackoff: defaultCrashBackoff,
+		closed:       make(chan struct{}),
+	}
+
+	if value := os.Getenv("MCPWRAPPER_DEBOUNCE"); value != "" {
+		debounce, err := time.ParseDuration(value)
+		if err != nil || debounce < 0 {
+			return nil, fmt.Errorf("invalid MCPWRAPPER_DEBOUNCE %q, expected a duration such as 500ms", value)
+		}
+		wrapper.debounce = debounce
+	}
+
+	if value := os.Getenv("MCPWRAPPER_CALL_TIMEOUT"); value != "" {
+		callTimeout, err := time.ParseDuration(value)
+		if err != nil || callTimeout < 0 {
+			return nil, fmt.Errorf("invalid MCPWRAPPER_CALL_TIMEOUT %q, expected a duration such as 2m", value)
+		}
+		wrapper.callTimeout = callTimeout
+	}
+	if value := os.Getenv("MCPWRAPPER_RESTART_ON_TIMEOUT"); value != "" {
+		restart, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MCPWRAPPER_RESTART_ON_TIMEOUT %q, expected true or false", value)
+		}
+		wrapper.restartOnTimeout = restart
+	}
+
+	if value := os.Getenv("MCPWRAPPER_MAX_RESTARTS"); value != "" {
+		maxRestarts, err := strconv.Atoi(value)
+		if err != nil || maxRestarts < 0 {
+			return nil, fmt.Errorf("invalid MCPWRAPPER_MAX_RESTARTS %q, expected a count such as 5", value)
+		}
+		wrapper.maxRestarts = maxRestarts
 	}
 
 	// Set up logging if MCPWRAPPER_LOG_FILE is set
@@ -69,7 +137,10 @@ func NewMCPWrapper(binaryPath string, serverArgs ...string) (*MCPWrapper, error)
 	}
 
 	// Create the wrapper MCP server
-	wrapper.server = server.NewMCPServer("mcpwrapper", "1.0.0")
+	wrapper.server = server.NewMCPServer("mcpwrapper", "1.0.0",
+		server.WithPromptCapabilities(true),
+		server.WithResourceCapabilities(false, true),
+	)
 
 	// Set up file watcher
 	watcher, err := fsnotify.NewWatcher()
@@ -78,17 +149,26 @@ func NewMCPWrapper(binaryPath string, serverArgs ...string) (*MCPWrapper, error)
 	}
 	wrapper.watcher = watcher
 
-	// Add the binary file to watcher
-	if err := watcher.Add(absPath); err != nil {
-		return nil, fmt.Errorf("failed to watch binary: %w", err)
+	// Watch the directories holding the watched paths, which keeps working
+	// when a file is replaced by renaming another over it
+	dirs, err := target.dirs()
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
 	}
+	log.Printf("Watching %s", target)
 
 	return wrapper, nil
 }
 
 func (w *MCPWrapper) Start() error {
-	// Start watching for file changes
+	// Start watching for file changes and crashes
 	go w.watchFileChanges()
+	go w.superviseCrashes()
 
 	// Start the underlying server initially
 	if err := w.startUnderlyingServer(); err != nil {
@@ -101,47 +181,38 @@ func (w *MCPWrapper) Start() error {
 	}
 
 	// Start the wrapper MCP server
-	return server.ServeStdio(w.server)
+	return w.serve()
 }
 
-func (w *MCPWrapper) watchFileChanges() {
-	for {
-		select {
-		case event, ok := <-w.watcher.Events:
-			if !ok {
-				return
-			}
-
-			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) {
-				log.Printf("Binary changed: %s", event.Name)
-				w.logEvent("BINARY_CHANGED", "Detected binary file change", map[string]interface{}{
-					"file_path": event.Name,
-					"operation": event.Op.String(),
-				})
-
-				// Small delay to ensure write is complete
-				time.Sleep(100 * time.Millisecond)
-
-				if err := w.restartServer(); err != nil {
-					log.Printf("Failed to restart server: %v", err)
-					w.logEvent("RESTART_FAILED", "Server restart failed", map[string]interface{}{
-						"error": err.Error(),
-					})
-				}
-			}
-
-		case err, ok := <-w.watcher.Errors:
-			if !ok {
-				return
-			}
-			log.Printf("Watcher error: %v", err)
-			w.logEvent("WATCHER_ERROR", "File watcher error", map[string]interface{}{
-				"error": err.Error(),
-			})
+// defaultSSEAddr is where the wrapper listens when MCPWRAPPER_TRANSPORT=sse and
+// MCPWRAPPER_ADDR is not set.
+const defaultSSEAddr = "localhost:8080"
+
+// serve exposes the wrapper's tools on the transport chosen by MCPWRAPPER_TRANSPORT.
+// The underlying server is always driven over stdio.
+func (w *MCPWrapper) serve() error {
+	switch transport := os.Getenv("MCPWRAPPER_TRANSPORT"); transport {
+	case "", "stdio":
+		return server.ServeStdio(w.server)
+	case "sse":
+		addr := os.Getenv("MCPWRAPPER_ADDR")
+		if addr == "" {
+			addr = defaultSSEAddr
 		}
+		log.Printf("Serving SSE on %s", addr)
+		w.logEvent("SSE_START", "Serving over SSE", map[string]interface{}{
+			"addr": addr,
+		})
+		return w.newSSEServer().Start(addr)
+	default:
+		return fmt.Errorf("unknown MCPWRAPPER_TRANSPORT %q, expected stdio or sse", transport)
 	}
 }
 
+func (w *MCPWrapper) newSSEServer() *server.SSEServer {
+	return server.NewSSEServer(w.server, server.WithKeepAlive(true))
+}
+
 func (w *MCPWrapper) startUnderlyingServer() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -162,9 +233,12 @@ func (w *MCPWrapper) startUnderlyingServer() error {
 		return fmt.Errorf("failed to start server: %w", err)
 	}
 
+	exited := make(chan struct{})
 	w.currentProcess = cmd
-	w.currentStdin = stdin
-	w.currentStdout = stdout
+	w.processExited = exited
+	w.startedAt = time.Now()
+	w.currentConn = newServerConn(stdin, stdout, w.handleServerNotification)
+	go w.waitForExit(cmd, exited)
 
 	log.Printf("Started underlying server: PID %d", cmd.Process.Pid)
 	return nil
@@ -179,44 +253,49 @@ func (w *MCPWrapper) stopUnderlyingServer() error {
 	}
 
 	// Close pipes
-	if w.currentStdin != nil {
-		w.currentStdin.Close()
-	}
-	if w.currentStdout != nil {
-		w.currentStdout.Close()
-	}
+	w.currentConn.close()
 
 	// Kill process
-	if err := w.currentProcess.Process.Kill(); err != nil {
+	if err := w.currentProcess.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
 		log.Printf("Warning: failed to kill process: %v", err)
 	}
 
 	// Wait for it to exit
-	_ = w.currentProcess.Wait()
+	<-w.processExited
 	w.currentProcess = nil
-	w.currentStdin = nil
-	w.currentStdout = nil
+	w.currentConn = nil
 
 	log.Printf("Stopped underlying server")
 	return nil
 }
 
-func (w *MCPWrapper) restartServer() error {
+// defaultDrainTimeout bounds how long a restart waits for in-flight calls to the
+// old server to finish before killing it.
+const defaultDrainTimeout = 30 * time.Second
+
+// restartServer replaces the underlying server with a fresh run of the binary.
+// Calls made while it restarts wait and are sent to the new server; calls
+// already in flight are given drainTimeout to finish first.
+func (w *MCPWrapper) restartServer(reason string) error {
+	w.restartMu.Lock()
+	defer w.restartMu.Unlock()
+
 	w.mu.Lock()
 	w.isRestarting = true
+	w.restartDone = make(chan struct{})
 	w.mu.Unlock()
 
 	defer func() {
 		w.mu.Lock()
 		w.isRestarting = false
+		close(w.restartDone)
 		w.mu.Unlock()
 	}()
 
-	log.Printf("Restarting server due to binary change...")
-	w.logEvent("SERVER_RESTART_START", "Server restart initiated due to binary change", nil)
+	log.Printf("Restarting server due to %s...", reason)
+	w.logEvent("SERVER_RESTART_START", "Server restart initiated due to "+reason, nil)
 
-	// Remove all current tools
-	w.removeAllTools()
+	w.drainCalls()
 
 	// Stop current server
 	if err := w.stopUnderlyingServer(); err != nil {
@@ -234,7 +313,11 @@ func (w *MCPWrapper) restartServer() error {
 		return fmt.Errorf("failed to start new server: %w", err)
 	}
 
-	// Load new tools
+	// Replace the old tools, prompts and resources with the new server's. They
+	// stay registered until now so calls made during the restart wait for it
+	w.removeAllTools()
+	w.removeAllPrompts()
+	w.removeAllResources()
 	if err := w.loadToolsFromServer(); err != nil {
 		w.logEvent("SERVER_RESTART_ERROR", "Failed to load tools during restart", map[string]interface{}{
 			"error": err.Error(),
@@ -247,6 +330,46 @@ func (w *MCPWrapper) restartServer() error {
 	return nil
 }
 
+// drainCalls waits up to drainTimeout for calls in flight to the current server
+// to finish. New calls wait in acquireConn until the restart is over.
+func (w *MCPWrapper) drainCalls() {
+	deadline := time.Now().Add(w.drainTimeout)
+	for w.inFlight.Load() > 0 {
+		if time.Now().After(deadline) {
+			log.Printf("%d in-flight calls did not finish within %s, restarting anyway", w.inFlight.Load(), w.drainTimeout)
+			w.logEvent("DRAIN_TIMEOUT", "In-flight calls did not finish before the restart", map[string]interface{}{
+				"calls":   w.inFlight.Load(),
+				"timeout": w.drainTimeout.String(),
+			})
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// acquireConn returns the connection to send a call on, waiting for a restart
+// in progress to finish first. The caller must call w.inFlight.Add(-1) once
+// the call is over.
+func (w *MCPWrapper) acquireConn(ctx context.Context) (*serverConn, error) {
+	for {
+		w.mu.RLock()
+		if !w.isRestarting {
+			w.inFlight.Add(1)
+			conn := w.currentConn
+			w.mu.RUnlock()
+			return conn, nil
+		}
+		done := w.restartDone
+		w.mu.RUnlock()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("gave up waiting for the server to restart: %w", ctx.Err())
+		}
+	}
+}
+
 func (w *MCPWrapper) removeAllTools() {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -269,9 +392,6 @@ func (w *MCPWrapper) removeAllTools() {
 }
 
 func (w *MCPWrapper) loadToolsFromServer() error {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-
 	// Initialize the underlying server
 	initReq := MCPMessage{
 		JSONRPC: "2.0",
@@ -286,74 +406,104 @@ func (w *MCPWrapper) loadToolsFromServer() error {
 		ID: w.getNextRequestID(),
 	}
 
-	if err := w.sendToServer(initReq); err != nil {
-		return fmt.Errorf("failed to send initialize: %w", err)
-	}
-
-	// Read initialize response
-	if _, err := w.readFromServer(); err != nil {
-		return fmt.Errorf("failed to read initialize response: %w", err)
+	initResp, err := w.requestFromServer(initReq)
+	if err != nil {
+		return fmt.Errorf("initialize failed: %w", err)
 	}
 
-	// List tools
-	listReq := MCPMessage{
-		JSONRPC: "2.0",
-		Method:  "tools/list",
-		ID:      w.getNextRequestID(),
+	if err := w.loadToolList(); err != nil {
+		return err
 	}
 
-	if err := w.sendToServer(listReq); err != nil {
-		return fmt.Errorf("failed to send tools/list: %w", err)
+	// Mirror prompts and resources if the server has them
+	var initResult mcp.InitializeResult
+	raw, err := rawResult(initResp)
+	if err == nil {
+		err = json.Unmarshal(*raw, &initResult)
 	}
-
-	// Read tools/list response
-	resp, err := w.readFromServer()
 	if err != nil {
-		return fmt.Errorf("failed to read tools/list response: %w", err)
+		return fmt.Errorf("invalid initialize response: %w", err)
 	}
-
-	// Parse tools from response
-	if err := w.parseAndAddTools(resp); err != nil {
-		return fmt.Errorf("failed to parse tools: %w", err)
+	if initResult.Capabilities.Prompts != nil {
+		if err := w.loadPromptsFromServer(); err != nil {
+			return err
+		}
+	}
+	if initResult.Capabilities.Resources != nil {
+		if err := w.loadResourcesFromServer(); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (w *MCPWrapper) sendToServer(msg MCPMessage) error {
-	if w.currentStdin == nil {
-		return fmt.Errorf("server not running")
+// loadToolList registers a proxy for every tool of the underlying server.
+func (w *MCPWrapper) loadToolList() error {
+	listReq := MCPMessage{
+		JSONRPC: "2.0",
+		Method:  "tools/list",
+		ID:      w.getNextRequestID(),
 	}
 
-	data, err := json.Marshal(msg)
+	resp, err := w.requestFromServer(listReq)
 	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
+		return fmt.Errorf("tools/list failed: %w", err)
 	}
 
-	if _, err := w.currentStdin.Write(append(data, '\n')); err != nil {
-		return fmt.Errorf("failed to write to server: %w", err)
+	// Parse tools from response
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.parseAndAddTools(resp); err != nil {
+		return fmt.Errorf("failed to parse tools: %w", err)
 	}
 
 	return nil
 }
 
-func (w *MCPWrapper) readFromServer() (*MCPMessage, error) {
-	if w.currentStdout == nil {
-		return nil, fmt.Errorf("server not running")
-	}
+// startupTimeout bounds the requests the wrapper makes on its own behalf
+// (initialize and the list requests) when no callTimeout is set, so a server
+// that hangs on startup cannot hold up restarts forever.
+const startupTimeout = 30 * time.Second
+
+// requestFromServer sends a request the wrapper makes on its own behalf to the
+// underlying server and waits for the response with the same id. It must not
+// be called with w.mu held: the lock is only taken to read the connection.
+func (w *MCPWrapper) requestFromServer(msg MCPMessage) (*MCPMessage, error) {
+	w.mu.RLock()
+	conn := w.currentConn
+	w.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), startupTimeout)
+	defer cancel()
+	return w.requestWithTimeout(ctx, conn, msg, nil)
+}
 
-	reader := bufio.NewReader(w.currentStdout)
-	line, err := reader.ReadBytes('\n')
-	if err != nil {
-		return nil, fmt.Errorf("failed to read from server: %w", err)
+// handleServerNotification forwards progress the underlying server reports for
+// a proxied call to the client that made it and mirrors changes to the server's
+// tools, prompts and resources. Other notifications are logged.
+func (w *MCPWrapper) handleServerNotification(msg *MCPMessage, call *pendingRequest) {
+	switch msg.Method {
+	case mcp.MethodNotificationToolsListChanged, mcp.MethodNotificationPromptsListChanged, mcp.MethodNotificationResourcesListChanged:
+		// Reloading waits for responses from the reader this is called on
+		go w.reloadList(msg.Method)
+		return
 	}
-
-	var msg MCPMessage
-	if err := json.Unmarshal(line, &msg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	if msg.Method == "notifications/progress" && call != nil && call.progressToken != nil {
+		params, _ := msg.Params.(map[string]interface{})
+		forwarded := make(map[string]any, len(params))
+		for k, v := range params {
+			forwarded[k] = v
+		}
+		forwarded["progressToken"] = call.progressToken
+		if err := w.server.SendNotificationToClient(call.ctx, msg.Method, forwarded); err != nil {
+			log.Printf("Failed to forward progress: %v", err)
+		}
+		return
 	}
-
-	return &msg, nil
+	w.logEvent("SERVER_NOTIFICATION", fmt.Sprintf("Server sent %s", msg.Method), map[string]interface{}{
+		"method": msg.Method,
+	})
 }
 
 func (w *MCPWrapper) parseAndAddTools(resp *MCPMessage) error {
@@ -391,60 +541,17 @@ func (w *MCPWrapper) parseAndAddTools(resp *MCPMessage) error {
 			continue
 		}
 
-		// Create tool for wrapper with full schema
-		toolOptions := []mcp.ToolOption{mcp.WithDescription(description)}
-
-		// Preserve the original input schema if available
-		if inputSchema, ok := toolMap["inputSchema"].(map[string]interface{}); ok {
-			// Add parameters from the original schema
-			if properties, ok := inputSchema["properties"].(map[string]interface{}); ok {
-				if required, ok := inputSchema["required"].([]interface{}); ok {
-					requiredSet := make(map[string]bool)
-					for _, r := range required {
-						if reqStr, ok := r.(string); ok {
-							requiredSet[reqStr] = true
-						}
-					}
-
-					for paramName, paramDef := range properties {
-						if paramMap, ok := paramDef.(map[string]interface{}); ok {
-							paramType, _ := paramMap["type"].(string)
-							paramDesc, _ := paramMap["description"].(string)
-							isRequired := requiredSet[paramName]
-
-							switch paramType {
-							case "string":
-								opts := []mcp.PropertyOption{mcp.Description(paramDesc)}
-								if isRequired {
-									opts = append(opts, mcp.Required())
-								}
-								toolOptions = append(toolOptions, mcp.WithString(paramName, opts...))
-							case "boolean":
-								opts := []mcp.PropertyOption{mcp.Description(paramDesc)}
-								if isRequired {
-									opts = append(opts, mcp.Required())
-								}
-								toolOptions = append(toolOptions, mcp.WithBoolean(paramName, opts...))
-							case "number":
-								opts := []mcp.PropertyOption{mcp.Description(paramDesc)}
-								if isRequired {
-									opts = append(opts, mcp.Required())
-								}
-								toolOptions = append(toolOptions, mcp.WithNumber(paramName, opts...))
-							case "array":
-								opts := []mcp.PropertyOption{mcp.Description(paramDesc)}
-								if isRequired {
-									opts = append(opts, mcp.Required())
-								}
-								toolOptions = append(toolOptions, mcp.WithArray(paramName, opts...))
-							}
-						}
-					}
-				}
+		// Pass the original input schema through verbatim so nothing the
+		// server declared is lost
+		schema := json.RawMessage(`{"type":"object"}`)
+		if inputSchema, ok := toolMap["inputSchema"]; ok {
+			data, err := json.Marshal(inputSchema)
+			if err != nil {
+				return fmt.Errorf("invalid input schema for tool %s: %w", name, err)
 			}
+			schema = data
 		}
-
-		tool := mcp.NewTool(name, toolOptions...)
+		tool := mcp.NewToolWithRawSchema(name, description, schema)
 
 		// Create handler that proxies to underlying server
 		handler := w.createProxyHandler(name)
@@ -476,72 +583,54 @@ func (w *MCPWrapper) createProxyHandler(toolName string) func(context.Context, m
 			"arguments": args,
 		})
 
-		w.mu.RLock()
-		isRestarting := w.isRestarting
-		w.mu.RUnlock()
-
-		if isRestarting {
-			result := &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{
-						Type: "text",
-						Text: "Server is restarting, please try again in a moment",
-					},
-				},
-				IsError: true,
-			}
-			w.logEvent("TOOL_RESULT", fmt.Sprintf("Tool '%s' failed (server restarting)", toolName), map[string]interface{}{
-				"tool_name": toolName,
-				"error":     true,
-			})
-			return result, nil
-		}
-
 		// Forward request to underlying server
+		id := w.getNextRequestID()
+		params := map[string]interface{}{
+			"name":      toolName,
+			"arguments": req.GetArguments(),
+		}
+		var progressToken mcp.ProgressToken
+		if req.Params.Meta != nil && req.Params.Meta.ProgressToken != nil {
+			// The server reports progress under our request id, which is
+			// mapped back to the client's token when forwarding it
+			progressToken = req.Params.Meta.ProgressToken
+			params["_meta"] = map[string]interface{}{"progressToken": id}
+		}
 		forwardReq := MCPMessage{
 			JSONRPC: "2.0",
 			Method:  "tools/call",
-			Params: map[string]interface{}{
-				"name":      toolName,
-				"arguments": req.GetArguments(),
-			},
-			ID: w.getNextRequestID(),
+			Params:  params,
+			ID:      id,
 		}
 
-		w.mu.RLock()
-		defer w.mu.RUnlock()
-
-		if err := w.sendToServer(forwardReq); err != nil {
-			result := &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{
-						Type: "text",
-						Text: fmt.Sprintf("Failed to forward request: %v", err),
-					},
-				},
-				IsError: true,
-			}
-			w.logEvent("TOOL_RESULT", fmt.Sprintf("Tool '%s' failed (forward error)", toolName), map[string]interface{}{
+		// Only the connection is read under the lock, so calls run concurrently.
+		// A call made during a restart waits for it and goes to the new server
+		conn, err := w.acquireConn(ctx)
+		var resp *MCPMessage
+		if err == nil {
+			resp, err = w.requestWithTimeout(ctx, conn, forwardReq, progressToken)
+			w.inFlight.Add(-1)
+		}
+		if errors.Is(err, errCallTimeout) {
+			w.logEvent("TOOL_RESULT", fmt.Sprintf("Tool '%s' timed out", toolName), map[string]interface{}{
 				"tool_name": toolName,
 				"error":     true,
-				"reason":    err.Error(),
+				"timeout":   w.callTimeout.String(),
 			})
-			return result, nil
+			w.restartAfterTimeout()
+			return mcp.NewToolResultError(fmt.Sprintf("Tool '%s' did not respond within %s", toolName, w.callTimeout)), nil
 		}
-
-		// Read response
-		resp, err := w.readFromServer()
 		if err != nil {
 			result := &mcp.CallToolResult{
 				Content: []mcp.Content{
 					mcp.TextContent{
 						Type: "text",
-						Text: fmt.Sprintf("Failed to read response: %v", err),
+						Text: fmt.Sprintf("Failed to forward request: %v", err),
 					},
 				},
 				IsError: true,
 			}
-			w.logEvent("TOOL_RESULT", fmt.Sprintf("Tool '%s' failed (read error)", toolName), map[string]interface{}{
+			w.logEvent("TOOL_RESULT", fmt.Sprintf("Tool '%s' failed (forward error)", toolName), map[string]interface{}{
 				"tool_name": toolName,
 				"error":     true,
 				"reason":    err.Error(),
@@ -632,8 +721,7 @@ func (w *MCPWrapper) convertToCallToolResult(resp *MCPMessage) (*mcp.CallToolRes
 }
 
 func (w *MCPWrapper) getNextRequestID() int {
-	w.requestID++
-	return w.requestID
+	return int(w.requestID.Add(1))
 }
 
 func (w *MCPWrapper) logEvent(eventType, message string, details map[string]interface{}) {
@@ -660,6 +748,7 @@ func (w *MCPWrapper) logEvent(eventType, message string, details map[string]inte
 }
 
 func (w *MCPWrapper) Close() error {
+	close(w.closed)
 	if w.logFile != nil {
 		w.logEvent("WRAPPER_STOP", "MCP Wrapper stopping", nil)
 		w.logFile.Close()
@@ -671,22 +760,40 @@ func (w *MCPWrapper) Close() error {
 }
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <mcp-server-binary> [args...]\n", os.Args[0])
+	watch := flag.String("watch", os.Getenv("MCPWRAPPER_WATCH"), "")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [--watch <path>] <mcp-server-binary> [args...]\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "\nThis wrapper monitors the MCP server binary for changes and automatically\n")
 		fmt.Fprintf(os.Stderr, "restarts it, updating the tool list dynamically.\n")
+		fmt.Fprintf(os.Stderr, "\nOptions:\n")
+		fmt.Fprintf(os.Stderr, "  --watch <path>                 Restart when this changes instead of the binary: a file, a\n")
+		fmt.Fprintf(os.Stderr, "                                 directory, a directory ending in /... to include subdirectories,\n")
+		fmt.Fprintf(os.Stderr, "                                 or a glob such as 'bin/*-mcp'\n")
 		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
-		fmt.Fprintf(os.Stderr, "  MCPWRAPPER_LOG_FILE    Path to log file for detailed human-readable logging\n")
+		fmt.Fprintf(os.Stderr, "  MCPWRAPPER_LOG_FILE            Path to log file for detailed human-readable logging\n")
+		fmt.Fprintf(os.Stderr, "  MCPWRAPPER_TRANSPORT           Transport to serve clients on: stdio (default) or sse\n")
+		fmt.Fprintf(os.Stderr, "  MCPWRAPPER_ADDR                Address to listen on with sse (default %s)\n", defaultSSEAddr)
+		fmt.Fprintf(os.Stderr, "  MCPWRAPPER_DEBOUNCE            How long the binary must stay unchanged before a restart (default %s)\n", defaultDebounce)
+		fmt.Fprintf(os.Stderr, "  MCPWRAPPER_WATCH               Default for --watch\n")
+		fmt.Fprintf(os.Stderr, "  MCPWRAPPER_CALL_TIMEOUT        How long a proxied call may take before it fails (default no limit)\n")
+		fmt.Fprintf(os.Stderr, "  MCPWRAPPER_RESTART_ON_TIMEOUT  Restart the server when a call times out (default false)\n")
+		fmt.Fprintf(os.Stderr, "  MCPWRAPPER_MAX_RESTARTS        How many crashes in a row to restart the server after (default %d)\n", defaultMaxRestarts)
 		fmt.Fprintf(os.Stderr, "\nExample:\n")
 		fmt.Fprintf(os.Stderr, "  %s ./tmux-mcp\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --watch './bin/...' ./bin/tmux-mcp\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  MCPWRAPPER_LOG_FILE=/tmp/wrapper.log %s ./tmux-mcp\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  MCPWRAPPER_TRANSPORT=sse MCPWRAPPER_ADDR=:9000 %s ./tmux-mcp\n", os.Args[0])
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	binaryPath := os.Args[1]
-	serverArgs := os.Args[2:]
+	binaryPath := flag.Arg(0)
+	serverArgs := flag.Args()[1:]
 
-	wrapper, err := NewMCPWrapper(binaryPath, serverArgs...)
+	wrapper, err := NewMCPWrapper(binaryPath, *watch, serverArgs...)
 	if err != nil {
 		log.Fatalf("Failed to create wrapper: %v", err)
 	}
@@ -0,0 +1,517 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// testChildEnv makes the test binary act as the wrapped MCP server.
+const testChildEnv = "MCPWRAPPER_TEST_CHILD"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testChildEnv) != "" {
+		serveTestChild()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// serveTestChild runs a minimal stdio MCP server with an echo tool, a greet
+// prompt and a readme resource.
+func serveTestChild() {
+	s := server.NewMCPServer("test-child", "1.0.0",
+		server.WithPromptCapabilities(true),
+		server.WithResourceCapabilities(false, true),
+	)
+	s.AddPrompt(mcp.NewPrompt("greet",
+		mcp.WithPromptDescription("Greet someone"),
+		mcp.WithArgument("name", mcp.RequiredArgument()),
+	), func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		return mcp.NewGetPromptResult("A greeting", []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent("Say hello to "+request.Params.Arguments["name"])),
+		}), nil
+	})
+	s.AddResource(mcp.NewResource("test://readme", "readme", mcp.WithMIMEType("text/plain")),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			return []mcp.ResourceContents{mcp.TextResourceContents{URI: request.Params.URI, MIMEType: "text/plain", Text: "read me"}}, nil
+		})
+	s.AddTool(mcp.NewTool("add_prompt",
+		mcp.WithDescription("Add a prompt, announcing the change to the client"),
+		mcp.WithString("name", mcp.Required(), mcp.Description("Prompt name")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name := request.GetString("name", "")
+		s.AddPrompt(mcp.NewPrompt(name), func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			return mcp.NewGetPromptResult(name, nil), nil
+		})
+		return mcp.NewToolResultText("added " + name), nil
+	})
+	s.AddTool(mcp.NewTool("echo",
+		mcp.WithDescription("Echo the message back"),
+		mcp.WithString("message", mcp.Required(), mcp.Description("Message to echo")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("echo: " + request.GetString("message", "")), nil
+	})
+	s.AddTool(mcp.NewTool("progress_echo",
+		mcp.WithDescription("Report progress, then echo the message back"),
+		mcp.WithString("message", mcp.Required(), mcp.Description("Message to echo")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		_ = s.SendNotificationToClient(ctx, "notifications/message", map[string]any{"level": "info", "data": "working"})
+		if request.Params.Meta != nil && request.Params.Meta.ProgressToken != nil {
+			for step := 1; step <= 2; step++ {
+				_ = s.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+					"progressToken": request.Params.Meta.ProgressToken,
+					"progress":      step,
+					"total":         2,
+				})
+			}
+		}
+		// Let the notifications reach stdout ahead of the result
+		time.Sleep(200 * time.Millisecond)
+		return mcp.NewToolResultText("echo: " + request.GetString("message", "")), nil
+	})
+	s.AddTool(mcp.NewTool("configure",
+		mcp.WithDescription("Accept settings with a non-trivial schema"),
+		mcp.WithString("mode", mcp.Enum("fast", "slow"), mcp.DefaultString("fast")),
+		mcp.WithObject("options", mcp.Properties(map[string]any{
+			"retries": map[string]any{"type": "integer", "minimum": 0},
+		})),
+		mcp.WithArray("tags", mcp.Items(map[string]any{"type": "string"})),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(request.GetString("mode", "")), nil
+	})
+	s.AddTool(mcp.NewTool("crash",
+		mcp.WithDescription("Exit without responding"),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		os.Exit(2)
+		return nil, nil
+	})
+	s.AddTool(mcp.NewTool("sleep",
+		mcp.WithDescription("Sleep, then report how long"),
+		mcp.WithNumber("ms", mcp.Required(), mcp.Description("Milliseconds to sleep")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ms := request.GetInt("ms", 0)
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+		return mcp.NewToolResultText(fmt.Sprintf("slept %dms", ms)), nil
+	})
+	if err := server.ServeStdio(s); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newTestWrapper(t *testing.T) *MCPWrapper {
+	t.Helper()
+	t.Setenv(testChildEnv, "1")
+
+	wrapper, err := NewMCPWrapper(os.Args[0], "")
+	if err != nil {
+		t.Fatalf("Failed to create wrapper: %v", err)
+	}
+	t.Cleanup(func() { _ = wrapper.Close() })
+
+	if err := wrapper.startUnderlyingServer(); err != nil {
+		t.Fatalf("Failed to start underlying server: %v", err)
+	}
+	if err := wrapper.loadToolsFromServer(); err != nil {
+		t.Fatalf("Failed to load tools: %v", err)
+	}
+	return wrapper
+}
+
+// newTestSSEClient serves the wrapper over SSE and returns an initialized client.
+func newTestSSEClient(t *testing.T, ctx context.Context, wrapper *MCPWrapper) *client.Client {
+	t.Helper()
+	httpServer := httptest.NewServer(wrapper.newSSEServer())
+	t.Cleanup(httpServer.Close)
+
+	sseClient, err := client.NewSSEMCPClient(httpServer.URL + "/sse")
+	if err != nil {
+		t.Fatalf("Failed to create SSE client: %v", err)
+	}
+	t.Cleanup(func() { _ = sseClient.Close() })
+	if err := sseClient.Start(ctx); err != nil {
+		t.Fatalf("Failed to start SSE client: %v", err)
+	}
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "test-client", Version: "1.0.0"}
+	if _, err := sseClient.Initialize(ctx, initRequest); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	return sseClient
+}
+
+func TestSSEClientListsAndCallsProxiedTool(t *testing.T) {
+	wrapper := newTestWrapper(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sseClient := newTestSSEClient(t, ctx, wrapper)
+
+	tools, err := sseClient.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatalf("Failed to list tools: %v", err)
+	}
+	var names []string
+	for _, tool := range tools.Tools {
+		names = append(names, tool.Name)
+	}
+	if !slices.Contains(names, "echo") {
+		t.Fatalf("Expected the proxied echo tool, got %v", names)
+	}
+
+	callRequest := mcp.CallToolRequest{}
+	callRequest.Params.Name = "echo"
+	callRequest.Params.Arguments = map[string]interface{}{"message": "hello"}
+	result, err := sseClient.CallTool(ctx, callRequest)
+	if err != nil {
+		t.Fatalf("Failed to call tool: %v", err)
+	}
+	if result.IsError || len(result.Content) != 1 {
+		t.Fatalf("Expected a single successful content item, got %+v", result)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; text != "echo: hello" {
+		t.Errorf("Expected proxied result %q, got %q", "echo: hello", text)
+	}
+}
+
+func TestSSEClientSeesFullInputSchema(t *testing.T) {
+	wrapper := newTestWrapper(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sseClient := newTestSSEClient(t, ctx, wrapper)
+
+	tools, err := sseClient.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatalf("Failed to list tools: %v", err)
+	}
+	var schema mcp.ToolInputSchema
+	for _, tool := range tools.Tools {
+		if tool.Name == "configure" {
+			schema = tool.InputSchema
+		}
+	}
+
+	want := map[string]any{
+		"mode": map[string]any{
+			"type":    "string",
+			"enum":    []any{"fast", "slow"},
+			"default": "fast",
+		},
+		"options": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"retries": map[string]any{"type": "integer", "minimum": float64(0)},
+			},
+		},
+		"tags": map[string]any{
+			"type":  "array",
+			"items": map[string]any{"type": "string"},
+		},
+	}
+	if !reflect.DeepEqual(schema.Properties, want) {
+		t.Errorf("Expected the schema to be passed through unchanged\n got: %v\nwant: %v", schema.Properties, want)
+	}
+}
+
+func TestProxyIgnoresInterleavedNotifications(t *testing.T) {
+	wrapper := newTestWrapper(t)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"message": "hello"}
+	result, err := wrapper.createProxyHandler("progress_echo")(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Failed to call tool: %v", err)
+	}
+	if result.IsError || len(result.Content) != 1 || result.Content[0].(mcp.TextContent).Text != "echo: hello" {
+		t.Fatalf("Expected the tool result rather than a notification, got %+v", result)
+	}
+
+	// The connection is still in step for the next call
+	request.Params.Arguments = map[string]interface{}{"message": "again"}
+	result, err = wrapper.createProxyHandler("echo")(context.Background(), request)
+	if err != nil || result.Content[0].(mcp.TextContent).Text != "echo: again" {
+		t.Fatalf("Expected the second result, got %+v (err %v)", result, err)
+	}
+}
+
+func TestProxyRunsCallsConcurrently(t *testing.T) {
+	wrapper := newTestWrapper(t)
+	handler := wrapper.createProxyHandler("sleep")
+
+	start := time.Now()
+	results := make([]string, 4)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = map[string]interface{}{"ms": 500 + 100*i}
+			result, err := handler(context.Background(), request)
+			if err != nil {
+				t.Errorf("Call %d failed: %v", i, err)
+				return
+			}
+			results[i] = result.Content[0].(mcp.TextContent).Text
+		}()
+	}
+	wg.Wait()
+
+	// Each call gets its own response even though they finish out of order
+	for i, text := range results {
+		if want := fmt.Sprintf("slept %dms", 500+100*i); text != want {
+			t.Errorf("Call %d: expected %q, got %q", i, want, text)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Errorf("Expected the calls to overlap, took %s", elapsed)
+	}
+}
+
+func TestRestartDrainsAndQueuesCalls(t *testing.T) {
+	wrapper := newTestWrapper(t)
+
+	call := func(tool string, args map[string]interface{}) (string, bool) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := wrapper.createProxyHandler(tool)(context.Background(), request)
+		if err != nil {
+			return err.Error(), true
+		}
+		return result.Content[0].(mcp.TextContent).Text, result.IsError
+	}
+
+	slow := make(chan string, 1)
+	go func() {
+		text, _ := call("sleep", map[string]interface{}{"ms": 600})
+		slow <- text
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	restarted := make(chan error, 1)
+	go func() { restarted <- wrapper.restartServer("test") }()
+	time.Sleep(100 * time.Millisecond)
+
+	// A call made during the restart waits for it rather than failing
+	text, isError := call("echo", map[string]interface{}{"message": "queued"})
+	if isError || text != "echo: queued" {
+		t.Errorf("Expected the queued call to reach the new server, got %q", text)
+	}
+
+	if text := <-slow; text != "slept 600ms" {
+		t.Errorf("Expected the in-flight call to finish before the restart, got %q", text)
+	}
+	if err := <-restarted; err != nil {
+		t.Fatalf("Restart failed: %v", err)
+	}
+}
+
+func TestRestartGivesUpDrainingAfterTimeout(t *testing.T) {
+	wrapper := newTestWrapper(t)
+	wrapper.drainTimeout = 200 * time.Millisecond
+
+	slow := make(chan *mcp.CallToolResult, 1)
+	go func() {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"ms": 5000}
+		result, _ := wrapper.createProxyHandler("sleep")(context.Background(), request)
+		slow <- result
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	if err := wrapper.restartServer("test"); err != nil {
+		t.Fatalf("Restart failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Expected the restart not to wait for the slow call, took %s", elapsed)
+	}
+	if result := <-slow; !result.IsError {
+		t.Errorf("Expected the call cut off by the restart to fail, got %+v", result)
+	}
+}
+
+func TestSSEClientReceivesForwardedProgress(t *testing.T) {
+	wrapper := newTestWrapper(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sseClient := newTestSSEClient(t, ctx, wrapper)
+
+	var mu sync.Mutex
+	var progress []map[string]any
+	sseClient.OnNotification(func(notification mcp.JSONRPCNotification) {
+		if notification.Method == "notifications/progress" {
+			mu.Lock()
+			progress = append(progress, notification.Params.AdditionalFields)
+			mu.Unlock()
+		}
+	})
+
+	callRequest := mcp.CallToolRequest{}
+	callRequest.Params.Name = "progress_echo"
+	callRequest.Params.Arguments = map[string]interface{}{"message": "hello"}
+	callRequest.Params.Meta = &mcp.Meta{ProgressToken: "client-token"}
+	result, err := sseClient.CallTool(ctx, callRequest)
+	if err != nil {
+		t.Fatalf("Failed to call tool: %v", err)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; text != "echo: hello" {
+		t.Errorf("Expected proxied result %q, got %q", "echo: hello", text)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(progress) != 2 {
+		t.Fatalf("Expected 2 progress notifications, got %v", progress)
+	}
+	for i, params := range progress {
+		if params["progressToken"] != "client-token" || params["progress"] != float64(i+1) {
+			t.Errorf("Expected progress %d under the client's token, got %v", i+1, params)
+		}
+	}
+}
+
+func TestSSEClientUsesProxiedPromptsAndResources(t *testing.T) {
+	wrapper := newTestWrapper(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sseClient := newTestSSEClient(t, ctx, wrapper)
+
+	prompts, err := sseClient.ListPrompts(ctx, mcp.ListPromptsRequest{})
+	if err != nil {
+		t.Fatalf("Failed to list prompts: %v", err)
+	}
+	if len(prompts.Prompts) != 1 || prompts.Prompts[0].Name != "greet" || len(prompts.Prompts[0].Arguments) != 1 {
+		t.Fatalf("Expected the proxied greet prompt, got %+v", prompts.Prompts)
+	}
+
+	promptRequest := mcp.GetPromptRequest{}
+	promptRequest.Params.Name = "greet"
+	promptRequest.Params.Arguments = map[string]string{"name": "Ada"}
+	prompt, err := sseClient.GetPrompt(ctx, promptRequest)
+	if err != nil {
+		t.Fatalf("Failed to get prompt: %v", err)
+	}
+	if len(prompt.Messages) != 1 || prompt.Messages[0].Content.(mcp.TextContent).Text != "Say hello to Ada" {
+		t.Errorf("Expected the proxied greeting, got %+v", prompt.Messages)
+	}
+
+	resources, err := sseClient.ListResources(ctx, mcp.ListResourcesRequest{})
+	if err != nil {
+		t.Fatalf("Failed to list resources: %v", err)
+	}
+	if len(resources.Resources) != 1 || resources.Resources[0].URI != "test://readme" {
+		t.Fatalf("Expected the proxied readme resource, got %+v", resources.Resources)
+	}
+
+	readRequest := mcp.ReadResourceRequest{}
+	readRequest.Params.URI = "test://readme"
+	contents, err := sseClient.ReadResource(ctx, readRequest)
+	if err != nil {
+		t.Fatalf("Failed to read resource: %v", err)
+	}
+	if len(contents.Contents) != 1 || contents.Contents[0].(mcp.TextResourceContents).Text != "read me" {
+		t.Errorf("Expected the proxied readme contents, got %+v", contents.Contents)
+	}
+}
+
+func TestSSEClientSeesServerPromptListChanges(t *testing.T) {
+	wrapper := newTestWrapper(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sseClient := newTestSSEClient(t, ctx, wrapper)
+
+	changed := make(chan struct{}, 1)
+	sseClient.OnNotification(func(notification mcp.JSONRPCNotification) {
+		if notification.Method == mcp.MethodNotificationPromptsListChanged {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		}
+	})
+
+	callRequest := mcp.CallToolRequest{}
+	callRequest.Params.Name = "add_prompt"
+	callRequest.Params.Arguments = map[string]interface{}{"name": "farewell"}
+	if _, err := sseClient.CallTool(ctx, callRequest); err != nil {
+		t.Fatalf("Failed to call tool: %v", err)
+	}
+
+	// The wrapper reloads its prompts asynchronously, so poll until it has
+	for {
+		prompts, err := sseClient.ListPrompts(ctx, mcp.ListPromptsRequest{})
+		if err != nil {
+			t.Fatalf("Failed to list prompts: %v", err)
+		}
+		if len(prompts.Prompts) == 2 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Expected the added prompt to appear, got %+v", prompts.Prompts)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	select {
+	case <-changed:
+	case <-ctx.Done():
+		t.Fatal("Expected the client to be told the prompt list changed")
+	}
+}
+
+func TestServeRejectsUnknownTransport(t *testing.T) {
+	t.Setenv("MCPWRAPPER_TRANSPORT", "carrier-pigeon")
+
+	wrapper := &MCPWrapper{server: server.NewMCPServer("mcpwrapper", "1.0.0")}
+	if err := wrapper.serve(); err == nil {
+		t.Error("Expected an error for an unknown transport")
+	}
+}
+
+func TestServerRequestsAreAnswered(t *testing.T) {
+	stdout, serverWrites := io.Pipe()
+	serverReads, stdin := io.Pipe()
+	conn := newServerConn(stdin, stdout, func(*MCPMessage, *pendingRequest) {
+		t.Error("A request must not be treated as a notification")
+	})
+	defer conn.close()
+
+	replies := bufio.NewReader(serverReads)
+	for _, tc := range []struct {
+		request string
+		want    string
+	}{
+		{`{"jsonrpc":"2.0","id":7,"method":"ping"}`, `{"jsonrpc":"2.0","result":{},"id":7}`},
+		{`{"jsonrpc":"2.0","id":"r","method":"roots/list"}`, `"code":-32601`},
+	} {
+		go func() { _, _ = serverWrites.Write([]byte(tc.request + "\n")) }()
+		line, err := replies.ReadString('\n')
+		if err != nil {
+			t.Fatalf("No reply to %s: %v", tc.request, err)
+		}
+		if !strings.Contains(line, tc.want) {
+			t.Errorf("Reply to %s\n got: %s\nwant: %s", tc.request, line, tc.want)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// loadPromptsFromServer registers a proxy for every prompt of the underlying
+// server.
+func (w *MCPWrapper) loadPromptsFromServer() error {
+	var result mcp.ListPromptsResult
+	if err := w.listFromServer("prompts/list", &result); err != nil {
+		return err
+	}
+
+	var prompts []server.ServerPrompt
+	var names []string
+	for _, prompt := range result.Prompts {
+		prompts = append(prompts, server.ServerPrompt{Prompt: prompt, Handler: w.proxyGetPrompt})
+		names = append(names, prompt.Name)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(prompts) > 0 {
+		w.server.AddPrompts(prompts...)
+		w.currentPrompts = names
+		log.Printf("Added %d prompts: %v", len(names), names)
+		w.logEvent("PROMPTS_ADDED", fmt.Sprintf("Added %d prompts", len(names)), map[string]interface{}{
+			"count":        len(names),
+			"prompt_names": names,
+		})
+	}
+	return nil
+}
+
+// loadResourcesFromServer registers a proxy for every resource of the
+// underlying server.
+func (w *MCPWrapper) loadResourcesFromServer() error {
+	var result mcp.ListResourcesResult
+	if err := w.listFromServer("resources/list", &result); err != nil {
+		return err
+	}
+
+	var resources []server.ServerResource
+	var uris []string
+	for _, resource := range result.Resources {
+		resources = append(resources, server.ServerResource{Resource: resource, Handler: w.proxyReadResource})
+		uris = append(uris, resource.URI)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(resources) > 0 {
+		w.server.AddResources(resources...)
+		w.currentResources = uris
+		log.Printf("Added %d resources: %v", len(uris), uris)
+		w.logEvent("RESOURCES_ADDED", fmt.Sprintf("Added %d resources", len(uris)), map[string]interface{}{
+			"count": len(uris),
+			"uris":  uris,
+		})
+	}
+	return nil
+}
+
+func (w *MCPWrapper) removeAllPrompts() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.currentPrompts) > 0 {
+		w.logEvent("PROMPTS_REMOVED", fmt.Sprintf("Removed %d prompts", len(w.currentPrompts)), map[string]interface{}{
+			"count":        len(w.currentPrompts),
+			"prompt_names": w.currentPrompts,
+		})
+		w.server.DeletePrompts(w.currentPrompts...)
+		w.currentPrompts = nil
+	}
+}
+
+func (w *MCPWrapper) removeAllResources() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.currentResources) > 0 {
+		w.logEvent("RESOURCES_REMOVED", fmt.Sprintf("Removed %d resources", len(w.currentResources)), map[string]interface{}{
+			"count": len(w.currentResources),
+			"uris":  w.currentResources,
+		})
+		for _, uri := range w.currentResources {
+			w.server.RemoveResource(uri)
+		}
+		w.currentResources = nil
+	}
+}
+
+// reloadList mirrors the list the underlying server announced a change of
+// with method. Clients are notified of the change by the wrapper's server.
+func (w *MCPWrapper) reloadList(method string) {
+	var err error
+	switch method {
+	case mcp.MethodNotificationToolsListChanged:
+		w.removeAllTools()
+		err = w.loadToolList()
+	case mcp.MethodNotificationPromptsListChanged:
+		w.removeAllPrompts()
+		err = w.loadPromptsFromServer()
+	case mcp.MethodNotificationResourcesListChanged:
+		w.removeAllResources()
+		err = w.loadResourcesFromServer()
+	}
+	if err != nil {
+		log.Printf("Failed to reload after %s: %v", method, err)
+		w.logEvent("RELOAD_FAILED", fmt.Sprintf("Failed to reload after %s", method), map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
+
+func (w *MCPWrapper) proxyGetPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	raw, err := w.forwardToServer(ctx, "prompts/get", map[string]interface{}{
+		"name":      request.Params.Name,
+		"arguments": request.Params.Arguments,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mcp.ParseGetPromptResult(raw)
+}
+
+func (w *MCPWrapper) proxyReadResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	raw, err := w.forwardToServer(ctx, "resources/read", map[string]interface{}{
+		"uri": request.Params.URI,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result, err := mcp.ParseReadResourceResult(raw)
+	if err != nil {
+		return nil, err
+	}
+	return result.Contents, nil
+}
+
+// listFromServer decodes the result of a list request into result.
+func (w *MCPWrapper) listFromServer(method string, result any) error {
+	resp, err := w.requestFromServer(MCPMessage{
+		JSONRPC: "2.0",
+		Method:  method,
+		ID:      w.getNextRequestID(),
+	})
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", method, err)
+	}
+	raw, err := rawResult(resp)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", method, err)
+	}
+	return json.Unmarshal(*raw, result)
+}
+
+// forwardToServer proxies a client request to the underlying server and
+// returns the raw result.
+func (w *MCPWrapper) forwardToServer(ctx context.Context, method string, params map[string]interface{}) (*json.RawMessage, error) {
+	conn, err := w.acquireConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer w.inFlight.Add(-1)
+
+	resp, err := w.requestWithTimeout(ctx, conn, MCPMessage{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      w.getNextRequestID(),
+	}, nil)
+	if errors.Is(err, errCallTimeout) {
+		w.restartAfterTimeout()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to forward %s: %w", method, err)
+	}
+	return rawResult(resp)
+}
+
+func rawResult(resp *MCPMessage) (*json.RawMessage, error) {
+	if resp.Error != nil {
+		return nil, fmt.Errorf("server error: %v", resp.Error)
+	}
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		return nil, err
+	}
+	raw := json.RawMessage(data)
+	return &raw, nil
+}
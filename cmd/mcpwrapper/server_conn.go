@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// serverConn is the stdio connection to one run of the underlying server. A
+// reader goroutine matches each response to its request by id, so notifications
+// the server writes in between are never mistaken for a result.
+type serverConn struct {
+	stdin          io.WriteCloser
+	stdout         io.ReadCloser
+	onNotification func(msg *MCPMessage, call *pendingRequest)
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	pending map[int]*pendingRequest
+	readErr error // why the reader stopped, once it has
+}
+
+// pendingRequest is a request waiting for its response.
+type pendingRequest struct {
+	response chan *MCPMessage
+	// ctx and progressToken identify the client call the request proxies, if any.
+	ctx           context.Context
+	progressToken mcp.ProgressToken
+}
+
+// newServerConn starts reading from stdout. onNotification is called from the
+// reader for every message that is not a response, with the pending request it
+// reports progress for, if any.
+func newServerConn(stdin io.WriteCloser, stdout io.ReadCloser, onNotification func(*MCPMessage, *pendingRequest)) *serverConn {
+	c := &serverConn{
+		stdin:          stdin,
+		stdout:         stdout,
+		onNotification: onNotification,
+		pending:        make(map[int]*pendingRequest),
+	}
+	go c.readLoop()
+	return c
+}
+
+// request sends msg, whose ID must be an int, and waits for its response.
+// Requests may be made concurrently. A nil connection means the server is not running.
+func (c *serverConn) request(ctx context.Context, msg MCPMessage, progressToken mcp.ProgressToken) (*MCPMessage, error) {
+	if c == nil {
+		return nil, fmt.Errorf("server not running")
+	}
+	id, ok := msg.ID.(int)
+	if !ok {
+		return nil, fmt.Errorf("request id %v is not an int", msg.ID)
+	}
+	call := &pendingRequest{response: make(chan *MCPMessage, 1), ctx: ctx, progressToken: progressToken}
+
+	c.mu.Lock()
+	if c.readErr != nil {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("server connection closed: %w", c.readErr)
+	}
+	c.pending[id] = call
+	c.mu.Unlock()
+
+	if err := c.send(msg); err != nil {
+		c.forget(id)
+		return nil, err
+	}
+
+	select {
+	case resp, ok := <-call.response:
+		if !ok {
+			return nil, fmt.Errorf("server connection closed before responding: %w", c.readErr)
+		}
+		return resp, nil
+	case <-ctx.Done():
+		c.forget(id)
+		// Let the server stop working on it
+		_ = c.send(MCPMessage{
+			JSONRPC: "2.0",
+			Method:  "notifications/cancelled",
+			Params:  map[string]interface{}{"requestId": id, "reason": ctx.Err().Error()},
+		})
+		return nil, ctx.Err()
+	}
+}
+
+func (c *serverConn) send(msg MCPMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := c.stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write to server: %w", err)
+	}
+	return nil
+}
+
+func (c *serverConn) forget(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, id)
+}
+
+// readLoop dispatches messages from the server until stdout is closed, then
+// fails every request still waiting.
+func (c *serverConn) readLoop() {
+	reader := bufio.NewReader(c.stdout)
+	var err error
+	for {
+		var line []byte
+		line, err = reader.ReadBytes('\n')
+		if len(line) > 1 {
+			c.dispatch(line)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readErr = err
+	for id, call := range c.pending {
+		close(call.response)
+		delete(c.pending, id)
+	}
+}
+
+func (c *serverConn) dispatch(line []byte) {
+	var msg MCPMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		log.Printf("Ignoring malformed message from server: %v", err)
+		return
+	}
+
+	if msg.Method != "" && msg.ID != nil {
+		c.answer(&msg)
+		return
+	}
+	if msg.Method != "" {
+		c.onNotification(&msg, c.progressCall(&msg))
+		return
+	}
+
+	id, ok := messageID(msg.ID)
+	c.mu.Lock()
+	call := c.pending[id]
+	delete(c.pending, id)
+	c.mu.Unlock()
+	if !ok || call == nil {
+		log.Printf("Ignoring response with unknown id %v", msg.ID)
+		return
+	}
+	call.response <- &msg
+}
+
+// answer replies to a request the server sent us. The wrapper only answers
+// pings; anything else (roots, sampling, elicitation) is refused so the server
+// is not left waiting for a response that never comes.
+func (c *serverConn) answer(msg *MCPMessage) {
+	reply := MCPMessage{JSONRPC: "2.0", ID: msg.ID}
+	if msg.Method == string(mcp.MethodPing) {
+		reply.Result = map[string]interface{}{}
+	} else {
+		reply.Error = map[string]interface{}{
+			"code":    mcp.METHOD_NOT_FOUND,
+			"message": fmt.Sprintf("method %q not supported by the wrapper", msg.Method),
+		}
+	}
+	if err := c.send(reply); err != nil {
+		log.Printf("Failed to answer server request %s: %v", msg.Method, err)
+	}
+}
+
+// progressCall returns the pending request a progress notification is about.
+// Proxied calls use their request id as progress token.
+func (c *serverConn) progressCall(msg *MCPMessage) *pendingRequest {
+	params, ok := msg.Params.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	id, ok := messageID(params["progressToken"])
+	if !ok {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pending[id]
+}
+
+func (c *serverConn) close() {
+	c.stdin.Close()
+	c.stdout.Close()
+}
+
+// messageID converts an id decoded from JSON back to the int it was sent as.
+func messageID(id interface{}) (int, bool) {
+	number, ok := id.(float64)
+	if !ok || number != float64(int(number)) {
+		return 0, false
+	}
+	return int(number), true
+}
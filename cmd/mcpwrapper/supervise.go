@@ -0,0 +1,100 @@
+package main
+
+import (
+	"log"
+	"os/exec"
+	"time"
+)
+
+const (
+	// defaultMaxRestarts is how many times in a row the wrapper restarts a
+	// crashed server, unless MCPWRAPPER_MAX_RESTARTS says otherwise.
+	defaultMaxRestarts = 5
+	// defaultCrashBackoff is the delay before the first restart after a crash;
+	// it doubles with every further crash up to maxCrashBackoff.
+	defaultCrashBackoff = 500 * time.Millisecond
+	maxCrashBackoff     = 30 * time.Second
+	// crashResetAfter is how long a server has to stay up for its crash
+	// restarts to stop counting against the limit.
+	crashResetAfter = time.Minute
+)
+
+// waitForExit reaps the server process and, if it exited without being
+// stopped, reports a crash to superviseCrashes.
+func (w *MCPWrapper) waitForExit(cmd *exec.Cmd, exited chan struct{}) {
+	err := cmd.Wait()
+	close(exited)
+
+	// stopUnderlyingServer clears currentProcess before releasing the lock
+	w.mu.RLock()
+	unexpected := w.currentProcess == cmd
+	uptime := time.Since(w.startedAt)
+	w.mu.RUnlock()
+	if !unexpected {
+		return
+	}
+
+	log.Printf("Underlying server exited unexpectedly after %s: %v", uptime.Round(time.Millisecond), err)
+	details := map[string]interface{}{
+		"pid":    cmd.Process.Pid,
+		"uptime": uptime.String(),
+	}
+	if err != nil {
+		details["error"] = err.Error()
+	}
+	w.logEvent("SERVER_CRASHED", "Underlying server exited unexpectedly", details)
+	w.reportCrash()
+}
+
+// reportCrash asks superviseCrashes for a restart. Crashes reported while one
+// is pending are folded into it.
+func (w *MCPWrapper) reportCrash() {
+	select {
+	case w.crashed <- struct{}{}:
+	default:
+	}
+}
+
+// superviseCrashes restarts the server after each crash, backing off
+// exponentially, until maxRestarts crashes in a row have been restarted.
+func (w *MCPWrapper) superviseCrashes() {
+	for {
+		select {
+		case <-w.crashed:
+		case <-w.closed:
+			return
+		}
+
+		w.mu.Lock()
+		if time.Since(w.startedAt) > crashResetAfter {
+			w.crashRestarts = 0
+		}
+		if w.crashRestarts >= w.maxRestarts {
+			w.mu.Unlock()
+			log.Printf("Not restarting the crashed server: it was already restarted %d times in a row", w.maxRestarts)
+			w.logEvent("CRASH_RESTARTS_EXHAUSTED", "Server keeps crashing, waiting for a binary change", map[string]interface{}{
+				"max_restarts": w.maxRestarts,
+			})
+			continue
+		}
+		w.crashRestarts++
+		attempt := w.crashRestarts
+		w.mu.Unlock()
+
+		delay := min(w.crashBackoff<<(attempt-1), maxCrashBackoff)
+		log.Printf("Restarting crashed server in %s (attempt %d of %d)", delay, attempt, w.maxRestarts)
+		select {
+		case <-time.After(delay):
+		case <-w.closed:
+			return
+		}
+
+		if err := w.restartServer("crash"); err != nil {
+			log.Printf("Failed to restart crashed server: %v", err)
+			w.logEvent("RESTART_FAILED", "Server restart after crash failed", map[string]interface{}{
+				"error": err.Error(),
+			})
+			w.reportCrash()
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// callEcho calls the proxied echo tool and reports whether it succeeded.
+func callEcho(wrapper *MCPWrapper) bool {
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"message": "alive"}
+	result, err := wrapper.createProxyHandler("echo")(context.Background(), request)
+	return err == nil && !result.IsError
+}
+
+// crash makes the underlying server exit and waits for the wrapper to notice.
+func crash(t *testing.T, wrapper *MCPWrapper) {
+	t.Helper()
+	wrapper.mu.RLock()
+	exited := wrapper.processExited
+	wrapper.mu.RUnlock()
+
+	result, err := wrapper.createProxyHandler("crash")(context.Background(), mcp.CallToolRequest{})
+	if err != nil || !result.IsError {
+		t.Fatalf("Expected the crash to fail the call, got %+v (err %v)", result, err)
+	}
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the server to exit")
+	}
+}
+
+// waitForEcho polls echo until it succeeds or the timeout passes.
+func waitForEcho(wrapper *MCPWrapper, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if callEcho(wrapper) {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return false
+}
+
+func TestSupervisorRestartsCrashedServer(t *testing.T) {
+	wrapper := newTestWrapper(t)
+	wrapper.crashBackoff = 10 * time.Millisecond
+	go wrapper.superviseCrashes()
+
+	for i := 1; i <= 2; i++ {
+		crash(t, wrapper)
+		if !waitForEcho(wrapper, 5*time.Second) {
+			t.Fatalf("Expected the server to be restarted after crash %d", i)
+		}
+	}
+
+	wrapper.mu.RLock()
+	defer wrapper.mu.RUnlock()
+	if wrapper.crashRestarts != 2 {
+		t.Errorf("Expected 2 crash restarts to be counted, got %d", wrapper.crashRestarts)
+	}
+}
+
+func TestSupervisorStopsAfterMaxRestarts(t *testing.T) {
+	wrapper := newTestWrapper(t)
+	wrapper.crashBackoff = 10 * time.Millisecond
+	wrapper.maxRestarts = 1
+	go wrapper.superviseCrashes()
+
+	crash(t, wrapper)
+	if !waitForEcho(wrapper, 5*time.Second) {
+		t.Fatal("Expected the server to be restarted after the first crash")
+	}
+
+	crash(t, wrapper)
+	if waitForEcho(wrapper, time.Second) {
+		t.Error("Expected the server not to be restarted once the limit was reached")
+	}
+}
+
+func TestSupervisorIgnoresDeliberateStops(t *testing.T) {
+	wrapper := newTestWrapper(t)
+	wrapper.crashBackoff = 10 * time.Millisecond
+	go wrapper.superviseCrashes()
+
+	if err := wrapper.restartServer("test"); err != nil {
+		t.Fatalf("Restart failed: %v", err)
+	}
+	if err := wrapper.stopUnderlyingServer(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	wrapper.mu.RLock()
+	defer wrapper.mu.RUnlock()
+	if wrapper.crashRestarts != 0 || wrapper.currentProcess != nil {
+		t.Errorf("Expected no crash restarts, got %d (process %v)", wrapper.crashRestarts, wrapper.currentProcess)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// errCallTimeout is returned by requestWithTimeout when the server did not
+// respond within callTimeout.
+var errCallTimeout = errors.New("server did not respond in time")
+
+// requestWithTimeout sends msg on conn, giving up after callTimeout if one is
+// set. Giving up on a request cancels it on the server.
+func (w *MCPWrapper) requestWithTimeout(ctx context.Context, conn *serverConn, msg MCPMessage, progressToken mcp.ProgressToken) (*MCPMessage, error) {
+	if w.callTimeout <= 0 {
+		return conn.request(ctx, msg, progressToken)
+	}
+
+	callCtx, cancel := context.WithTimeoutCause(ctx, w.callTimeout, errCallTimeout)
+	defer cancel()
+	resp, err := conn.request(callCtx, msg, progressToken)
+	if err != nil && errors.Is(context.Cause(callCtx), errCallTimeout) {
+		return nil, fmt.Errorf("%s: %w", msg.Method, errCallTimeout)
+	}
+	return resp, err
+}
+
+// restartAfterTimeout restarts the server in the background if
+// MCPWRAPPER_RESTART_ON_TIMEOUT is set, on the assumption that it is stuck.
+func (w *MCPWrapper) restartAfterTimeout() {
+	if !w.restartOnTimeout {
+		return
+	}
+	go func() {
+		if err := w.restartServer("call timeout"); err != nil {
+			log.Printf("Failed to restart server after timeout: %v", err)
+			w.logEvent("RESTART_FAILED", "Server restart after call timeout failed", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// callSleep calls the proxied sleep tool and returns its result text.
+func callSleep(t *testing.T, wrapper *MCPWrapper, ms int) (string, bool) {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"ms": ms}
+	result, err := wrapper.createProxyHandler("sleep")(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	return result.Content[0].(mcp.TextContent).Text, result.IsError
+}
+
+func TestProxyTimesOutHungCalls(t *testing.T) {
+	wrapper := newTestWrapper(t)
+	wrapper.callTimeout = 200 * time.Millisecond
+
+	start := time.Now()
+	text, isError := callSleep(t, wrapper, 2000)
+	if !isError || !strings.Contains(text, "did not respond within 200ms") {
+		t.Errorf("Expected a timeout error, got %q", text)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the call to give up after the timeout, took %s", elapsed)
+	}
+
+	// Calls within the timeout still work on the same server
+	if text, isError := callSleep(t, wrapper, 10); isError || text != "slept 10ms" {
+		t.Errorf("Expected a later call to succeed, got %q", text)
+	}
+}
+
+func TestProxyRestartsServerAfterTimeout(t *testing.T) {
+	wrapper := newTestWrapper(t)
+	wrapper.callTimeout = 200 * time.Millisecond
+	wrapper.restartOnTimeout = true
+
+	wrapper.mu.RLock()
+	before := wrapper.currentProcess.Process.Pid
+	wrapper.mu.RUnlock()
+
+	if _, isError := callSleep(t, wrapper, 5000); !isError {
+		t.Fatal("Expected the call to time out")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		wrapper.mu.RLock()
+		restarted := wrapper.currentProcess != nil && wrapper.currentProcess.Process.Pid != before && !wrapper.isRestarting
+		wrapper.mu.RUnlock()
+		if restarted {
+			if text, isError := callSleep(t, wrapper, 10); isError || text != "slept 10ms" {
+				t.Errorf("Expected the restarted server to answer, got %q", text)
+			}
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("Expected the server to be restarted after the timeout")
+}
+
+func TestLoadingFromHungServerTimesOutWithoutBlockingCalls(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+	// A server that never answers, like a rebuilt binary that hangs on startup
+	wrapper, err := NewMCPWrapper(sleep, "", "60")
+	if err != nil {
+		t.Fatalf("Failed to create wrapper: %v", err)
+	}
+	t.Cleanup(func() { _ = wrapper.Close() })
+	wrapper.callTimeout = time.Second
+	if err := wrapper.startUnderlyingServer(); err != nil {
+		t.Fatalf("Failed to start underlying server: %v", err)
+	}
+
+	loaded := make(chan error, 1)
+	go func() { loaded <- wrapper.loadToolsFromServer() }()
+
+	// Calls can still get hold of the connection while the load is waiting
+	time.Sleep(100 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if _, err := wrapper.acquireConn(ctx); err != nil {
+		t.Errorf("Expected calls not to wait for the load, got %v", err)
+	} else {
+		wrapper.inFlight.Add(-1)
+	}
+
+	select {
+	case err := <-loaded:
+		if !errors.Is(err, errCallTimeout) {
+			t.Errorf("Expected the load to time out, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the load to give up on the hung server")
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// defaultDebounce is how long the binary must go without changes before the
+// wrapper restarts the server, unless MCPWRAPPER_DEBOUNCE says otherwise.
+const defaultDebounce = 500 * time.Millisecond
+
+// watchTarget is what the wrapper watches for changes: a single file, a
+// directory, optionally with its subdirectories, or a glob.
+type watchTarget struct {
+	path      string // absolute path or pattern
+	glob      bool
+	dir       bool
+	recursive bool
+}
+
+// parseWatchTarget parses a --watch value. A path ending in /... is a directory
+// watched with its subdirectories; one containing *, ? or [ is a glob.
+func parseWatchTarget(value string) (watchTarget, error) {
+	var target watchTarget
+	if trimmed, ok := strings.CutSuffix(value, "/..."); ok {
+		value = trimmed
+		target.dir, target.recursive = true, true
+	}
+	path, err := filepath.Abs(value)
+	if err != nil {
+		return watchTarget{}, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	target.path = path
+
+	switch {
+	case target.recursive:
+	case strings.ContainsAny(filepath.Base(path), "*?["):
+		if _, err := filepath.Match(path, path); err != nil {
+			return watchTarget{}, fmt.Errorf("invalid watch pattern %q: %w", value, err)
+		}
+		target.glob = true
+	default:
+		info, err := os.Stat(path)
+		if err != nil {
+			return watchTarget{}, fmt.Errorf("failed to watch %s: %w", value, err)
+		}
+		target.dir = info.IsDir()
+	}
+	return target, nil
+}
+
+func (t watchTarget) String() string {
+	if t.recursive {
+		return t.path + string(filepath.Separator) + "..."
+	}
+	return t.path
+}
+
+// root is the directory holding everything the target matches.
+func (t watchTarget) root() string {
+	if t.dir {
+		return t.path
+	}
+	return filepath.Dir(t.path)
+}
+
+// dirs returns the directories to add to the watcher.
+func (t watchTarget) dirs() ([]string, error) {
+	if !t.recursive {
+		return []string{t.root()}, nil
+	}
+	var dirs []string
+	err := filepath.WalkDir(t.path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch %s: %w", t, err)
+	}
+	return dirs, nil
+}
+
+// matches reports whether a change to path should restart the server.
+func (t watchTarget) matches(path string) bool {
+	switch {
+	case t.glob:
+		matched, _ := filepath.Match(t.path, path)
+		return matched
+	case t.recursive:
+		return strings.HasPrefix(path, t.path+string(filepath.Separator))
+	case t.dir:
+		return filepath.Dir(path) == t.path
+	default:
+		return path == t.path
+	}
+}
+
+// watchFileChanges restarts the server once the watched files have stopped
+// changing for the debounce window. A compiler writing the binary fires many
+// events in quick succession; they are coalesced into a single restart.
+func (w *MCPWrapper) watchFileChanges() {
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	var lastChanged string
+	var lastSeen os.FileInfo
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				timer.Stop()
+				return
+			}
+
+			// The watch goes with the directory when it is removed or renamed,
+			// as build tools that replace their output directory do
+			if event.Name == w.watch.root() && (event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename)) {
+				if !w.rewatch() {
+					return
+				}
+				// Whatever was put there while it was not watched
+				lastChanged = w.binaryPath
+				lastSeen, _ = os.Stat(lastChanged)
+				timer.Reset(w.debounce)
+				continue
+			}
+
+			// New subdirectories of a recursive watch are watched too
+			if w.watch.recursive && event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := w.watcher.Add(event.Name); err != nil {
+						log.Printf("Failed to watch %s: %v", event.Name, err)
+					}
+					continue
+				}
+			}
+
+			if !w.watch.matches(event.Name) {
+				continue
+			}
+
+			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) {
+				log.Printf("Binary changed: %s", event.Name)
+				w.logEvent("BINARY_CHANGED", "Detected binary file change", map[string]interface{}{
+					"file_path": event.Name,
+					"operation": event.Op.String(),
+				})
+				lastChanged = event.Name
+				lastSeen, _ = os.Stat(lastChanged)
+				timer.Reset(w.debounce)
+			}
+
+		case <-timer.C:
+			// Catch writes whose events have not been delivered yet
+			info, err := os.Stat(lastChanged)
+			if err == nil && lastSeen != nil && (info.Size() != lastSeen.Size() || !info.ModTime().Equal(lastSeen.ModTime())) {
+				lastSeen = info
+				timer.Reset(w.debounce)
+				continue
+			}
+
+			if err := checkExecutable(w.binaryPath); err != nil {
+				log.Printf("Not restarting: %v", err)
+				w.logEvent("BINARY_NOT_READY", "Binary is not a complete executable, waiting for further changes", map[string]interface{}{
+					"error": err.Error(),
+				})
+				continue
+			}
+
+			// A new binary gets a fresh allowance of crash restarts
+			w.mu.Lock()
+			w.crashRestarts = 0
+			w.mu.Unlock()
+
+			if err := w.restartServer("binary change"); err != nil {
+				log.Printf("Failed to restart server: %v", err)
+				w.logEvent("RESTART_FAILED", "Server restart failed", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				timer.Stop()
+				return
+			}
+			log.Printf("Watcher error: %v", err)
+			w.logEvent("WATCHER_ERROR", "File watcher error", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+}
+
+// rewatchAttempts and rewatchInterval bound how long rewatch waits for a
+// removed directory to come back.
+const (
+	rewatchAttempts = 50
+	rewatchInterval = 100 * time.Millisecond
+)
+
+// rewatch adds the watched directories again once they exist. It returns false
+// if the watcher has been closed.
+func (w *MCPWrapper) rewatch() bool {
+	root := w.watch.root()
+	log.Printf("Watched directory %s went away, waiting for it to return", root)
+	w.logEvent("WATCH_LOST", "Watched directory was removed or renamed", map[string]interface{}{
+		"dir": root,
+	})
+
+	// A renamed directory is still watched under its old name
+	_ = w.watcher.Remove(root)
+
+	for attempt := 0; attempt < rewatchAttempts; attempt++ {
+		time.Sleep(rewatchInterval)
+		dirs, err := w.watch.dirs()
+		if err != nil {
+			continue
+		}
+		for _, dir := range dirs {
+			if err = w.watcher.Add(dir); err != nil {
+				break
+			}
+		}
+		if errors.Is(err, fsnotify.ErrClosed) {
+			return false
+		}
+		if err == nil {
+			log.Printf("Watching %s again", w.watch)
+			w.logEvent("WATCH_RESTORED", "Watched directory is being watched again", map[string]interface{}{
+				"dir": root,
+			})
+			return true
+		}
+	}
+
+	log.Printf("Gave up waiting for %s to return; changes to it will not be noticed", root)
+	w.logEvent("WATCH_FAILED", "Watched directory did not return", map[string]interface{}{
+		"dir": root,
+	})
+	return true
+}
+
+// checkExecutable reports why path cannot be run yet: it is missing, not
+// executable, or a truncated ELF, Mach-O or PE binary. Scripts starting with
+// #! are accepted as they are.
+func checkExecutable(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", path)
+	}
+	if info.Mode().Perm()&0111 == 0 {
+		return fmt.Errorf("%s is not executable", path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return fmt.Errorf("%s is too short to be an executable", path)
+	}
+
+	// A partial write leaves the header pointing past the end of the file
+	var end uint64
+	switch {
+	case bytes.HasPrefix(header, []byte("#!")):
+		return nil
+	case bytes.Equal(header, []byte(elf.ELFMAG)):
+		binary, err := elf.NewFile(file)
+		if err != nil {
+			return fmt.Errorf("%s is an incomplete ELF binary: %w", path, err)
+		}
+		for _, section := range binary.Sections {
+			if section.Type != elf.SHT_NOBITS {
+				end = max(end, section.Offset+section.FileSize)
+			}
+		}
+		for _, prog := range binary.Progs {
+			end = max(end, prog.Off+prog.Filesz)
+		}
+	case bytes.HasPrefix(header, []byte("MZ")):
+		binary, err := pe.NewFile(file)
+		if err != nil {
+			return fmt.Errorf("%s is an incomplete PE binary: %w", path, err)
+		}
+		for _, section := range binary.Sections {
+			end = max(end, uint64(section.Offset)+uint64(section.Size))
+		}
+	default:
+		binary, err := macho.NewFile(file)
+		if err != nil {
+			return fmt.Errorf("%s is not a recognized executable: %w", path, err)
+		}
+		for _, load := range binary.Loads {
+			if segment, ok := load.(*macho.Segment); ok {
+				end = max(end, segment.Offset+segment.Filesz)
+			}
+		}
+	}
+	if end > uint64(info.Size()) {
+		return fmt.Errorf("%s is incomplete: %d of %d bytes written", path, info.Size(), end)
+	}
+	return nil
+}
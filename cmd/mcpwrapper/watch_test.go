@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckExecutable(t *testing.T) {
+	dir := t.TempDir()
+	binary, err := os.ReadFile(os.Args[0])
+	if err != nil {
+		t.Fatalf("Failed to read test binary: %v", err)
+	}
+
+	write := func(name string, data []byte, mode os.FileMode) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, mode); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{"complete binary", write("complete", binary, 0755), ""},
+		{"truncated binary", write("truncated", binary[:len(binary)/2], 0755), "incomplete"},
+		{"not executable", write("plain", binary, 0644), "not executable"},
+		{"script", write("script", []byte("#!/bin/sh\nexit 0\n"), 0755), ""},
+		{"empty", write("empty", nil, 0755), "too short"},
+		{"missing", filepath.Join(dir, "missing"), "no such file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkExecutable(tt.path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected %s to be accepted, got %v", tt.path, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWatchTargetMatches(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "bin"), 0755); err != nil {
+		t.Fatalf("Failed to create bin: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bin", "server"), nil, 0755); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		watch   string
+		path    string
+		matches bool
+	}{
+		{"file", "bin/server", "bin/server", true},
+		{"file sibling", "bin/server", "bin/other", false},
+		{"directory", "bin", "bin/other", true},
+		{"directory subdirectory", "bin", "bin/sub/other", false},
+		{"recursive", "bin/...", "bin/sub/other", true},
+		{"recursive outside", "bin/...", "other", false},
+		{"glob", "bin/*-mcp", "bin/tmux-mcp", true},
+		{"glob mismatch", "bin/*-mcp", "bin/server", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := parseWatchTarget(filepath.Join(dir, tt.watch))
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", tt.watch, err)
+			}
+			if got := target.matches(filepath.Join(dir, tt.path)); got != tt.matches {
+				t.Errorf("Expected %s watching %s to match %s: %v, got %v", tt.watch, target, tt.path, tt.matches, got)
+			}
+		})
+	}
+}
+
+// newScriptWrapper wraps a script that runs the test child, watching watch,
+// and returns the script's path and a count of completed restarts. A script can
+// be rewritten while the server it started is running.
+func newScriptWrapper(t *testing.T, dir, watch string) (*MCPWrapper, string, func() int) {
+	t.Helper()
+	logPath := filepath.Join(t.TempDir(), "wrapper.log")
+	t.Setenv("MCPWRAPPER_LOG_FILE", logPath)
+	t.Setenv("MCPWRAPPER_DEBOUNCE", "200ms")
+	t.Setenv(testChildEnv, "1")
+
+	scriptPath := filepath.Join(dir, "server.sh")
+	if err := os.WriteFile(scriptPath, []byte(testScript()), 0755); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+
+	wrapper, err := NewMCPWrapper(scriptPath, watch)
+	if err != nil {
+		t.Fatalf("Failed to create wrapper: %v", err)
+	}
+	t.Cleanup(func() { _ = wrapper.Close() })
+	if err := wrapper.startUnderlyingServer(); err != nil {
+		t.Fatalf("Failed to start underlying server: %v", err)
+	}
+	go wrapper.watchFileChanges()
+
+	restarts := func() int {
+		data, _ := os.ReadFile(logPath)
+		return strings.Count(string(data), "SERVER_RESTART_COMPLETE")
+	}
+	return wrapper, scriptPath, restarts
+}
+
+func testScript() string {
+	return fmt.Sprintf("#!/bin/sh\nexec %q \"$@\"\n", os.Args[0])
+}
+
+// waitForRestarts waits for a restart, then long enough for another to show up,
+// and returns how many there were.
+func waitForRestarts(restarts func() int) int {
+	deadline := time.Now().Add(5 * time.Second)
+	for restarts() == 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	time.Sleep(500 * time.Millisecond)
+	return restarts()
+}
+
+func TestWatcherCoalescesRapidChanges(t *testing.T) {
+	wrapper, scriptPath, restarts := newScriptWrapper(t, t.TempDir(), "")
+	script := testScript()
+
+	// Rewrite the script in pieces, each well within the debounce window
+	file, err := os.OpenFile(scriptPath, os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		t.Fatalf("Failed to open script: %v", err)
+	}
+	for _, line := range strings.SplitAfter(script, "\n") {
+		if _, err := file.WriteString(line); err != nil {
+			t.Fatalf("Failed to write script: %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	file.Close()
+
+	if n := waitForRestarts(restarts); n != 1 {
+		t.Errorf("Expected the changes to cause exactly one restart, got %d", n)
+	}
+	wrapper.mu.RLock()
+	defer wrapper.mu.RUnlock()
+	if _, ok := wrapper.currentTools["echo"]; !ok {
+		t.Errorf("Expected the restarted server's tools to be loaded")
+	}
+}
+
+func TestWatcherRestartsOnlyForMatchingFiles(t *testing.T) {
+	dir := t.TempDir()
+	_, _, restarts := newScriptWrapper(t, dir, filepath.Join(dir, "*.bin"))
+
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("unrelated"), 0644); err != nil {
+		t.Fatalf("Failed to write notes: %v", err)
+	}
+	time.Sleep(500 * time.Millisecond)
+	if n := restarts(); n != 0 {
+		t.Fatalf("Expected an unmatched file not to cause a restart, got %d", n)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "server.bin"), []byte("rebuilt"), 0755); err != nil {
+		t.Fatalf("Failed to write artifact: %v", err)
+	}
+	if n := waitForRestarts(restarts); n != 1 {
+		t.Errorf("Expected the matching artifact to cause one restart, got %d", n)
+	}
+}
+
+func TestWatcherRestartsWhenFileIsRenamedIntoPlace(t *testing.T) {
+	dir := t.TempDir()
+	_, scriptPath, restarts := newScriptWrapper(t, dir, "")
+
+	tmpPath := scriptPath + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(testScript()), 0755); err != nil {
+		t.Fatalf("Failed to write replacement: %v", err)
+	}
+	if err := os.Rename(tmpPath, scriptPath); err != nil {
+		t.Fatalf("Failed to rename replacement into place: %v", err)
+	}
+	if n := waitForRestarts(restarts); n != 1 {
+		t.Fatalf("Expected the rename to cause one restart, got %d", n)
+	}
+
+	// Later changes are still noticed
+	if err := os.WriteFile(scriptPath, []byte(testScript()), 0755); err != nil {
+		t.Fatalf("Failed to rewrite script: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for restarts() < 2 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if n := restarts(); n != 2 {
+		t.Errorf("Expected a second restart after rewriting the script, got %d", n)
+	}
+}
+
+func TestWatcherFollowsReplacedDirectory(t *testing.T) {
+	binDir := filepath.Join(t.TempDir(), "bin")
+	if err := os.Mkdir(binDir, 0755); err != nil {
+		t.Fatalf("Failed to create bin: %v", err)
+	}
+	_, scriptPath, restarts := newScriptWrapper(t, binDir, binDir)
+
+	// Replace the whole directory, as some build tools do with their output
+	if err := os.Rename(binDir, binDir+".old"); err != nil {
+		t.Fatalf("Failed to move bin away: %v", err)
+	}
+	if err := os.Mkdir(binDir, 0755); err != nil {
+		t.Fatalf("Failed to recreate bin: %v", err)
+	}
+	if err := os.WriteFile(scriptPath, []byte(testScript()), 0755); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	if n := waitForRestarts(restarts); n != 1 {
+		t.Fatalf("Expected the new directory's script to cause one restart, got %d", n)
+	}
+
+	// The new directory is watched
+	if err := os.WriteFile(scriptPath, []byte(testScript()), 0755); err != nil {
+		t.Fatalf("Failed to rewrite script: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for restarts() < 2 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if n := restarts(); n != 2 {
+		t.Errorf("Expected a second restart after rewriting the script, got %d", n)
+	}
+}
@@ -0,0 +1,422 @@
+package mcpcommon
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// redactedValue replaces the value of sensitive:"true" fields in logs.
+const redactedValue = "[REDACTED]"
+
+// forEachArgumentField calls fn for every exported, json-tagged field of the
+// struct v points to, descending into embedded structs.
+func forEachArgumentField(v reflect.Value, fn func(name string, field reflect.StructField, value reflect.Value)) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type == reflect.TypeOf(ToolInfo{}) || !field.IsExported() {
+			continue
+		}
+		if field.Anonymous {
+			forEachArgumentField(v.Field(i), fn)
+			continue
+		}
+
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "" || jsonTag == "-" {
+			continue
+		}
+		fn(strings.Split(jsonTag, ",")[0], field, v.Field(i))
+	}
+}
+
+// applyDefaultTags sets every field the constructor left at its zero value to
+// the value of its default tag, before arguments are unmarshaled over them.
+func applyDefaultTags(tool interface{}) error {
+	var err error
+	forEachArgumentField(reflect.ValueOf(tool), func(name string, field reflect.StructField, value reflect.Value) {
+		defaultValue, ok := field.Tag.Lookup("default")
+		if !ok || err != nil || !value.IsZero() {
+			return
+		}
+
+		if value.Kind() == reflect.String {
+			value.SetString(defaultValue)
+			return
+		}
+		if jsonErr := json.Unmarshal([]byte(defaultValue), value.Addr().Interface()); jsonErr != nil {
+			err = fmt.Errorf("parameter %s: invalid default %q: %w", name, defaultValue, jsonErr)
+		}
+	})
+	return err
+}
+
+// applyEnvArguments fills fields tagged fromenv:"VAR" from the environment
+// when the caller did not pass them, so secrets never travel as arguments.
+func applyEnvArguments(tool interface{}, arguments map[string]interface{}) error {
+	var err error
+	forEachArgumentField(reflect.ValueOf(tool), func(name string, field reflect.StructField, value reflect.Value) {
+		envVar := field.Tag.Get("fromenv")
+		if envVar == "" || err != nil {
+			return
+		}
+		if _, provided := arguments[name]; provided {
+			return
+		}
+		envValue, ok := os.LookupEnv(envVar)
+		if !ok {
+			return
+		}
+
+		if value.Kind() == reflect.String {
+			value.SetString(envValue)
+			return
+		}
+		if jsonErr := json.Unmarshal([]byte(envValue), value.Addr().Interface()); jsonErr != nil {
+			err = fmt.Errorf("parameter %s: invalid value in $%s: %w", name, envVar, jsonErr)
+		}
+	})
+	return err
+}
+
+// loggableArguments returns the tool's populated parameters keyed by their
+// json names, with sensitive:"true" fields redacted.
+func loggableArguments(tool interface{}) map[string]any {
+	args := map[string]any{}
+	forEachArgumentField(reflect.ValueOf(tool), func(name string, field reflect.StructField, value reflect.Value) {
+		if field.Tag.Get("sensitive") == "true" {
+			args[name] = redactedValue
+			return
+		}
+		args[name] = value.Interface()
+	})
+	return args
+}
+
+// renameDeprecatedArguments maps arguments passed under a field's
+// deprecated_names to its current name, warning so callers can migrate.
+// The caller's map is left untouched.
+func renameDeprecatedArguments(tool interface{}, arguments map[string]interface{}) map[string]interface{} {
+	renamed := arguments
+	copied := false
+	forEachArgumentField(reflect.ValueOf(tool), func(name string, field reflect.StructField, value reflect.Value) {
+		deprecated := field.Tag.Get("deprecated_names")
+		if deprecated == "" {
+			return
+		}
+		for _, oldName := range strings.Split(deprecated, ",") {
+			oldName = strings.TrimSpace(oldName)
+			oldValue, ok := renamed[oldName]
+			if !ok {
+				continue
+			}
+			if !copied {
+				renamed = make(map[string]interface{}, len(arguments))
+				for k, v := range arguments {
+					renamed[k] = v
+				}
+				copied = true
+			}
+			delete(renamed, oldName)
+			if _, hasCurrent := renamed[name]; hasCurrent {
+				slog.Warn("ignoring deprecated argument, current name also given", "argument", oldName, "use", name)
+				continue
+			}
+			slog.Warn("argument is deprecated", "argument", oldName, "use", name)
+			renamed[name] = oldValue
+		}
+	})
+	return renamed
+}
+
+// isRequired reports whether field is tagged mcp:"required" or json:",required".
+func isRequired(field reflect.StructField) bool {
+	_, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return field.Tag.Get("mcp") == "required" || slices.Contains(strings.Split(options, ","), "required")
+}
+
+// argumentGiven reports whether the caller passed the parameter name, or its
+// fromenv variable is set, as opposed to it keeping its default.
+func argumentGiven(arguments map[string]interface{}, name string, field reflect.StructField) bool {
+	if arguments[name] != nil {
+		return true
+	}
+	if envVar := field.Tag.Get("fromenv"); envVar != "" {
+		_, ok := os.LookupEnv(envVar)
+		return ok
+	}
+	return false
+}
+
+// checkRequiredArguments returns an InvalidArgument error naming the first
+// required field the caller did not pass, unless its fromenv variable is set.
+func checkRequiredArguments(tool interface{}, arguments map[string]interface{}) error {
+	var err error
+	forEachArgumentField(reflect.ValueOf(tool), func(name string, field reflect.StructField, value reflect.Value) {
+		if err != nil || !isRequired(field) || argumentGiven(arguments, name, field) {
+			return
+		}
+		err = Errorf(InvalidArgument, "missing required parameter %s", name)
+	})
+	return err
+}
+
+// requiredIfCondition parses a required_if:"field=value" tag. Without "=value"
+// the condition is that field is set to anything but its zero value.
+func requiredIfCondition(tag string) (field, value string, hasValue bool) {
+	field, value, hasValue = strings.Cut(tag, "=")
+	return strings.TrimSpace(field), strings.TrimSpace(value), hasValue
+}
+
+// describeRequiredIf explains a required_if tag, e.g. "required when hex is true".
+func describeRequiredIf(tag string) string {
+	field, value, hasValue := requiredIfCondition(tag)
+	if !hasValue {
+		return fmt.Sprintf("required when %s is set", field)
+	}
+	return fmt.Sprintf("required when %s is %s", field, value)
+}
+
+// checkRequiredIfTags panics if a required_if tag of the tool type refers to a
+// parameter the tool does not have.
+func checkRequiredIfTags(toolType reflect.Type) {
+	names := map[string]bool{}
+	var conditions []string
+	forEachArgumentField(reflect.New(toolType), func(name string, field reflect.StructField, value reflect.Value) {
+		names[name] = true
+		if tag := field.Tag.Get("required_if"); tag != "" {
+			conditions = append(conditions, tag)
+		}
+	})
+	for _, tag := range conditions {
+		if field, _, _ := requiredIfCondition(tag); !names[field] {
+			panic(fmt.Sprintf("Tool %s: required_if %q refers to unknown parameter %s", toolType.Name(), tag, field))
+		}
+	}
+}
+
+// checkRequiredIf returns an InvalidArgument error naming the first field whose
+// required_if condition holds but which was left unset.
+func checkRequiredIf(tool interface{}) error {
+	values := map[string]reflect.Value{}
+	forEachArgumentField(reflect.ValueOf(tool), func(name string, field reflect.StructField, value reflect.Value) {
+		values[name] = value
+	})
+
+	var err error
+	forEachArgumentField(reflect.ValueOf(tool), func(name string, field reflect.StructField, value reflect.Value) {
+		tag := field.Tag.Get("required_if")
+		if tag == "" || err != nil || !value.IsZero() {
+			return
+		}
+		other, expected, hasValue := requiredIfCondition(tag)
+		otherValue, ok := values[other]
+		if !ok {
+			return
+		}
+		if hasValue && fmt.Sprint(otherValue.Interface()) != expected || !hasValue && otherValue.IsZero() {
+			return
+		}
+		err = Errorf(InvalidArgument, "parameter %s is %s", name, describeRequiredIf(tag))
+	})
+	return err
+}
+
+// enumValues splits an enum:"a,b,c" tag into its allowed values.
+func enumValues(tag string) []string {
+	values := strings.Split(tag, ",")
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	return values
+}
+
+// enumNumbers parses the values of an enum tag on a number parameter.
+func enumNumbers(tag string) ([]float64, error) {
+	var numbers []float64
+	for _, value := range enumValues(tag) {
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("enum value %q is not a number", value)
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, nil
+}
+
+// enumContains reports whether the string or number in value is one of the
+// values of the enum tag.
+func enumContains(tag string, value reflect.Value) bool {
+	var number float64
+	switch value.Kind() {
+	case reflect.String:
+		return slices.Contains(enumValues(tag), value.String())
+	case reflect.Int, reflect.Int64:
+		number = float64(value.Int())
+	case reflect.Float64:
+		number = value.Float()
+	default:
+		return true
+	}
+	numbers, err := enumNumbers(tag)
+	return err == nil && slices.Contains(numbers, number)
+}
+
+// checkEnum returns an InvalidArgument error naming the first field tagged
+// enum:"a,b,c" whose value is not one of the allowed values. Only arguments
+// the caller gave are checked, including zero values; defaults are not.
+func checkEnum(tool interface{}, arguments map[string]interface{}) error {
+	var err error
+	forEachArgumentField(reflect.ValueOf(tool), func(name string, field reflect.StructField, value reflect.Value) {
+		tag := field.Tag.Get("enum")
+		if tag == "" || err != nil || !argumentGiven(arguments, name, field) || enumContains(tag, value) {
+			return
+		}
+		err = Errorf(InvalidArgument, "parameter %s must be one of %s, got %v", name, strings.Join(enumValues(tag), ", "), value.Interface())
+	})
+	return err
+}
+
+// parameterBounds holds the min and max tags of a number parameter and the
+// minLength and maxLength tags of a string parameter.
+type parameterBounds struct {
+	min, max             *float64
+	minLength, maxLength *int
+}
+
+// parseBounds parses the bound tags of field, rejecting bounds that do not
+// apply to its type.
+func parseBounds(field reflect.StructField) (bounds parameterBounds, err error) {
+	isNumber := false
+	switch field.Type.Kind() {
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		isNumber = true
+	}
+	for _, tag := range []struct {
+		name  string
+		value **float64
+	}{{"min", &bounds.min}, {"max", &bounds.max}} {
+		text, ok := field.Tag.Lookup(tag.name)
+		if !ok {
+			continue
+		}
+		if !isNumber {
+			return bounds, fmt.Errorf("%s is only supported on number parameters", tag.name)
+		}
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return bounds, fmt.Errorf("%s %q is not a number", tag.name, text)
+		}
+		*tag.value = &number
+	}
+	for _, tag := range []struct {
+		name  string
+		value **int
+	}{{"minLength", &bounds.minLength}, {"maxLength", &bounds.maxLength}} {
+		text, ok := field.Tag.Lookup(tag.name)
+		if !ok {
+			continue
+		}
+		if field.Type.Kind() != reflect.String {
+			return bounds, fmt.Errorf("%s is only supported on string parameters", tag.name)
+		}
+		length, err := strconv.Atoi(text)
+		if err != nil || length < 0 {
+			return bounds, fmt.Errorf("%s %q is not a non-negative integer", tag.name, text)
+		}
+		*tag.value = &length
+	}
+	return bounds, nil
+}
+
+// check returns an InvalidArgument error if value, the parameter name, is out of bounds.
+func (b parameterBounds) check(name string, value reflect.Value) error {
+	var number float64
+	switch value.Kind() {
+	case reflect.String:
+		length := utf8.RuneCountInString(value.String())
+		if b.minLength != nil && length < *b.minLength {
+			return Errorf(InvalidArgument, "parameter %s must be at least %d characters long, got %d", name, *b.minLength, length)
+		}
+		if b.maxLength != nil && length > *b.maxLength {
+			return Errorf(InvalidArgument, "parameter %s must be at most %d characters long, got %d", name, *b.maxLength, length)
+		}
+		return nil
+	case reflect.Int, reflect.Int64:
+		number = float64(value.Int())
+	case reflect.Float64:
+		number = value.Float()
+	default:
+		return nil
+	}
+	if b.min != nil && number < *b.min {
+		return Errorf(InvalidArgument, "parameter %s must be at least %v, got %v", name, *b.min, value.Interface())
+	}
+	if b.max != nil && number > *b.max {
+		return Errorf(InvalidArgument, "parameter %s must be at most %v, got %v", name, *b.max, value.Interface())
+	}
+	return nil
+}
+
+// checkBounds returns an InvalidArgument error naming the first field outside
+// its min, max, minLength or maxLength tags. Only arguments the caller gave are
+// checked, including zero values; defaults are not.
+func checkBounds(tool interface{}, arguments map[string]interface{}) error {
+	var err error
+	forEachArgumentField(reflect.ValueOf(tool), func(name string, field reflect.StructField, value reflect.Value) {
+		if err != nil || !argumentGiven(arguments, name, field) {
+			return
+		}
+		bounds, parseErr := parseBounds(field)
+		if parseErr != nil {
+			return
+		}
+		err = bounds.check(name, value)
+	})
+	return err
+}
+
+// checkIntegerArguments returns an InvalidArgument error naming the first
+// integer parameter, or integer array item, passed a number with a fractional
+// part, which json.Unmarshal would otherwise reject with a decoding error.
+func checkIntegerArguments(tool interface{}, arguments map[string]interface{}) error {
+	var err error
+	forEachArgumentField(reflect.ValueOf(tool), func(name string, field reflect.StructField, value reflect.Value) {
+		if err != nil {
+			return
+		}
+		kind, items := field.Type.Kind(), []interface{}{arguments[name]}
+		if kind == reflect.Slice {
+			kind = field.Type.Elem().Kind()
+			items, _ = arguments[name].([]interface{})
+		}
+		if kind != reflect.Int && kind != reflect.Int64 {
+			return
+		}
+		for _, item := range items {
+			if number, ok := item.(float64); ok && number != math.Trunc(number) {
+				err = Errorf(InvalidArgument, "parameter %s must be an integer, got %v", name, number)
+				return
+			}
+		}
+	})
+	return err
+}
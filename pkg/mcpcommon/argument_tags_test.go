@@ -0,0 +1,417 @@
+package mcpcommon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type TestEnvSecretTool struct {
+	ToolInfo `name:"env_secret_tool" description:"A tool reading a secret from the environment"`
+
+	Token string `json:"token" description:"API token" fromenv:"MCPCOMMON_TEST_TOKEN" sensitive:"true"`
+	Label string `json:"label" description:"Label to echo"`
+}
+
+func (t *TestEnvSecretTool) Handle(ctx context.Context) (interface{}, error) {
+	return t.Label + ":" + t.Token, nil
+}
+
+func captureDebugLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func TestReflectToolFromEnv(t *testing.T) {
+	t.Setenv("MCPCOMMON_TEST_TOKEN", "s3cret-from-env")
+	logs := captureDebugLogs(t)
+
+	serverTool := ReflectTool(func() *TestEnvSecretTool { return &TestEnvSecretTool{} })
+	text := callText(t, serverTool.Handler, map[string]interface{}{"label": "a"})
+	if text != "a:s3cret-from-env" {
+		t.Errorf("Expected token to be filled from environment, got %q", text)
+	}
+
+	if strings.Contains(logs.String(), "s3cret-from-env") {
+		t.Errorf("Expected sensitive value to be redacted from logs, got:\n%s", logs.String())
+	}
+	if !strings.Contains(logs.String(), redactedValue) {
+		t.Errorf("Expected redaction marker in logs, got:\n%s", logs.String())
+	}
+}
+
+func TestReflectToolFromEnvArgumentWins(t *testing.T) {
+	t.Setenv("MCPCOMMON_TEST_TOKEN", "s3cret-from-env")
+
+	serverTool := ReflectTool(func() *TestEnvSecretTool { return &TestEnvSecretTool{} })
+	text := callText(t, serverTool.Handler, map[string]interface{}{"label": "b", "token": "explicit"})
+	if text != "b:explicit" {
+		t.Errorf("Expected explicit argument to take precedence, got %q", text)
+	}
+}
+
+type TestEnvNumberTool struct {
+	ToolInfo `name:"env_number_tool" description:"A tool reading a number from the environment"`
+
+	Limit int `json:"limit" description:"Limit" fromenv:"MCPCOMMON_TEST_LIMIT"`
+}
+
+func (t *TestEnvNumberTool) Handle(ctx context.Context) (interface{}, error) {
+	return t.Limit, nil
+}
+
+func TestReflectToolFromEnvInvalidValue(t *testing.T) {
+	t.Setenv("MCPCOMMON_TEST_LIMIT", "lots")
+
+	serverTool := ReflectTool(func() *TestEnvNumberTool { return &TestEnvNumberTool{} })
+	_, err := serverTool.Handler(context.Background(), mcp.CallToolRequest{})
+	if err == nil || !strings.Contains(err.Error(), "MCPCOMMON_TEST_LIMIT") {
+		t.Errorf("Expected error naming the environment variable, got %v", err)
+	}
+}
+
+type TestRenamedParamTool struct {
+	ToolInfo `name:"renamed_param_tool" description:"A tool whose parameter was renamed"`
+
+	Filter string `json:"filter" description:"Filter pattern" deprecated_names:"grep,match"`
+}
+
+func (t *TestRenamedParamTool) Handle(ctx context.Context) (interface{}, error) {
+	return "filter=" + t.Filter, nil
+}
+
+func TestReflectToolDeprecatedArgumentName(t *testing.T) {
+	logs := captureDebugLogs(t)
+	serverTool := ReflectTool(func() *TestRenamedParamTool { return &TestRenamedParamTool{} })
+
+	arguments := map[string]interface{}{"grep": "error"}
+	text := callText(t, serverTool.Handler, arguments)
+	if text != "filter=error" {
+		t.Errorf("Expected deprecated name to populate the renamed field, got %q", text)
+	}
+	if !strings.Contains(logs.String(), "argument is deprecated") || !strings.Contains(logs.String(), "argument=grep") {
+		t.Errorf("Expected deprecation warning naming the old argument, got:\n%s", logs.String())
+	}
+	if _, ok := arguments["filter"]; ok {
+		t.Error("Expected caller's arguments to be left untouched")
+	}
+
+	text = callText(t, serverTool.Handler, map[string]interface{}{"grep": "old", "filter": "new"})
+	if text != "filter=new" {
+		t.Errorf("Expected current name to win over deprecated name, got %q", text)
+	}
+}
+
+type TestRequiredIfTool struct {
+	ToolInfo `name:"required_if_tool" description:"A tool with dependent parameters"`
+
+	Mode    string `json:"mode" description:"Match mode, literal or regex"`
+	Pattern string `json:"pattern" description:"Pattern to match" required_if:"mode=regex"`
+	Hex     bool   `json:"hex" description:"Send hex codes"`
+	Codes   string `json:"codes" description:"Hex codes to send" required_if:"hex"`
+}
+
+func (t *TestRequiredIfTool) Handle(ctx context.Context) (interface{}, error) {
+	return "ok", nil
+}
+
+func TestReflectToolRequiredIf(t *testing.T) {
+	serverTool := ReflectTool(func() *TestRequiredIfTool {
+		return &TestRequiredIfTool{}
+	})
+
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		errMsg    string
+	}{
+		{name: "condition not met", arguments: map[string]interface{}{"mode": "literal"}},
+		{name: "condition met and provided", arguments: map[string]interface{}{"mode": "regex", "pattern": "a+"}},
+		{name: "condition met and missing", arguments: map[string]interface{}{"mode": "regex"}, errMsg: "parameter pattern is required when mode is regex"},
+		{name: "set condition met and missing", arguments: map[string]interface{}{"hex": true}, errMsg: "parameter codes is required when hex is set"},
+		{name: "set condition not met", arguments: map[string]interface{}{"hex": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := serverTool.Handler(context.Background(), mcp.CallToolRequest{
+				Params: mcp.CallToolParams{Arguments: tt.arguments},
+			})
+			if err != nil {
+				t.Fatalf("Handler execution failed: %v", err)
+			}
+			text := result.Content[0].(mcp.TextContent).Text
+			if tt.errMsg == "" {
+				if result.IsError || text != "ok" {
+					t.Errorf("Expected the tool to run, got %q", text)
+				}
+				return
+			}
+			if category := errorMeta(t, result)["category"]; category != string(InvalidArgument) {
+				t.Errorf("Expected InvalidArgument category, got %v", category)
+			}
+			if !strings.Contains(text, tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %q", tt.errMsg, text)
+			}
+		})
+	}
+
+	// The dependency is documented in the schema
+	description := serverTool.Tool.InputSchema.Properties["pattern"].(map[string]any)["description"]
+	if description != "Pattern to match (required when mode is regex)" {
+		t.Errorf("Expected the dependency in the description, got %q", description)
+	}
+}
+
+type TestBadRequiredIfTool struct {
+	ToolInfo `name:"bad_required_if_tool" description:"A tool with a broken required_if tag"`
+
+	Pattern string `json:"pattern" description:"Pattern to match" required_if:"mood=regex"`
+}
+
+func (t *TestBadRequiredIfTool) Handle(ctx context.Context) (interface{}, error) {
+	return "ok", nil
+}
+
+func TestReflectToolRequiredIfUnknownParameter(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "unknown parameter mood") {
+			t.Errorf("Expected a panic naming the unknown parameter, got %v", r)
+		}
+	}()
+	ReflectTool(func() *TestBadRequiredIfTool { return &TestBadRequiredIfTool{} })
+}
+
+type TestEnumTool struct {
+	ToolInfo `name:"enum_tool" description:"A tool with constrained parameters"`
+
+	Mode  string  `json:"mode" description:"Access mode" enum:"read,write"`
+	Level int     `json:"level" description:"Compression level" enum:"1, 5, 9"`
+	Ratio float64 `json:"ratio" description:"Sampling ratio" enum:"0.5,1"`
+}
+
+func (t *TestEnumTool) Handle(ctx context.Context) (interface{}, error) {
+	return "ok", nil
+}
+
+func TestReflectToolEnum(t *testing.T) {
+	serverTool := ReflectTool(func() *TestEnumTool {
+		return &TestEnumTool{}
+	})
+
+	properties := serverTool.Tool.InputSchema.Properties
+	if enum := properties["mode"].(map[string]any)["enum"]; !reflect.DeepEqual(enum, []string{"read", "write"}) {
+		t.Errorf("Expected string enum in schema, got %#v", enum)
+	}
+	if enum := properties["level"].(map[string]any)["enum"]; !reflect.DeepEqual(enum, []float64{1, 5, 9}) {
+		t.Errorf("Expected number enum in schema, got %#v", enum)
+	}
+
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		errMsg    string
+	}{
+		{name: "unset", arguments: map[string]interface{}{}},
+		{name: "allowed values", arguments: map[string]interface{}{"mode": "write", "level": 5, "ratio": 0.5}},
+		{name: "string out of range", arguments: map[string]interface{}{"mode": "append"}, errMsg: "parameter mode must be one of read, write, got append"},
+		{name: "int out of range", arguments: map[string]interface{}{"level": 3}, errMsg: "parameter level must be one of 1, 5, 9, got 3"},
+		{name: "float out of range", arguments: map[string]interface{}{"ratio": 0.25}, errMsg: "parameter ratio must be one of 0.5, 1, got 0.25"},
+		{name: "zero int", arguments: map[string]interface{}{"level": 0}, errMsg: "parameter level must be one of 1, 5, 9, got 0"},
+		{name: "empty string", arguments: map[string]interface{}{"mode": ""}, errMsg: "parameter mode must be one of read, write, got "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := serverTool.Handler(context.Background(), mcp.CallToolRequest{
+				Params: mcp.CallToolParams{Arguments: tt.arguments},
+			})
+			if err != nil {
+				t.Fatalf("Handler execution failed: %v", err)
+			}
+			text := result.Content[0].(mcp.TextContent).Text
+			if tt.errMsg == "" {
+				if result.IsError || text != "ok" {
+					t.Errorf("Expected the tool to run, got %q", text)
+				}
+				return
+			}
+			if category := errorMeta(t, result)["category"]; category != string(InvalidArgument) {
+				t.Errorf("Expected InvalidArgument category, got %v", category)
+			}
+			if !strings.Contains(text, tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %q", tt.errMsg, text)
+			}
+		})
+	}
+}
+
+type TestBadEnumTool struct {
+	ToolInfo `name:"bad_enum_tool" description:"A tool with a non-numeric enum on a number"`
+
+	Level int `json:"level" description:"Compression level" enum:"low,high"`
+}
+
+func (t *TestBadEnumTool) Handle(ctx context.Context) (interface{}, error) {
+	return "ok", nil
+}
+
+func TestReflectToolEnumNotANumber(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), `enum value "low" is not a number`) {
+			t.Errorf("Expected a panic naming the bad enum value, got %v", r)
+		}
+	}()
+	ReflectTool(func() *TestBadEnumTool { return &TestBadEnumTool{} })
+}
+
+type TestBoundsTool struct {
+	ToolInfo `name:"bounds_tool" description:"A tool with bounded parameters"`
+
+	MaxTokens   int     `json:"max_tokens" description:"Maximum tokens to generate" min:"1" max:"4096"`
+	Temperature float64 `json:"temperature" description:"Sampling temperature" min:"0.1" max:"1"`
+	Prompt      string  `json:"prompt" description:"Prompt to send" minLength:"3" maxLength:"5"`
+}
+
+func (t *TestBoundsTool) Handle(ctx context.Context) (interface{}, error) {
+	return "ok", nil
+}
+
+func TestReflectToolBounds(t *testing.T) {
+	serverTool := ReflectTool(func() *TestBoundsTool {
+		return &TestBoundsTool{}
+	})
+
+	properties := serverTool.Tool.InputSchema.Properties
+	maxTokens := properties["max_tokens"].(map[string]any)
+	if maxTokens["minimum"] != 1.0 || maxTokens["maximum"] != 4096.0 {
+		t.Errorf("Expected number bounds in schema, got %v", maxTokens)
+	}
+	prompt := properties["prompt"].(map[string]any)
+	if prompt["minLength"] != 3 || prompt["maxLength"] != 5 {
+		t.Errorf("Expected length bounds in schema, got %v", prompt)
+	}
+
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		errMsg    string
+	}{
+		{name: "unset", arguments: map[string]interface{}{}},
+		{name: "within bounds", arguments: map[string]interface{}{"max_tokens": 4096, "temperature": 0.1, "prompt": "héllo"}},
+		{name: "int too large", arguments: map[string]interface{}{"max_tokens": 5000}, errMsg: "parameter max_tokens must be at most 4096, got 5000"},
+		{name: "float too small", arguments: map[string]interface{}{"temperature": 0.05}, errMsg: "parameter temperature must be at least 0.1, got 0.05"},
+		{name: "negative int", arguments: map[string]interface{}{"max_tokens": -1}, errMsg: "parameter max_tokens must be at least 1, got -1"},
+		{name: "zero int", arguments: map[string]interface{}{"max_tokens": 0}, errMsg: "parameter max_tokens must be at least 1, got 0"},
+		{name: "empty string", arguments: map[string]interface{}{"prompt": ""}, errMsg: "parameter prompt must be at least 3 characters long, got 0"},
+		{name: "string too short", arguments: map[string]interface{}{"prompt": "hi"}, errMsg: "parameter prompt must be at least 3 characters long, got 2"},
+		{name: "string too long", arguments: map[string]interface{}{"prompt": "hello!"}, errMsg: "parameter prompt must be at most 5 characters long, got 6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := serverTool.Handler(context.Background(), mcp.CallToolRequest{
+				Params: mcp.CallToolParams{Arguments: tt.arguments},
+			})
+			if err != nil {
+				t.Fatalf("Handler execution failed: %v", err)
+			}
+			text := result.Content[0].(mcp.TextContent).Text
+			if tt.errMsg == "" {
+				if result.IsError || text != "ok" {
+					t.Errorf("Expected the tool to run, got %q", text)
+				}
+				return
+			}
+			if category := errorMeta(t, result)["category"]; category != string(InvalidArgument) {
+				t.Errorf("Expected InvalidArgument category, got %v", category)
+			}
+			if !strings.Contains(text, tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %q", tt.errMsg, text)
+			}
+		})
+	}
+}
+
+type TestBadBoundsTool struct {
+	ToolInfo `name:"bad_bounds_tool" description:"A tool with a length bound on a number"`
+
+	Count int `json:"count" description:"How many" maxLength:"3"`
+}
+
+func (t *TestBadBoundsTool) Handle(ctx context.Context) (interface{}, error) {
+	return "ok", nil
+}
+
+func TestReflectToolBoundsWrongType(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "maxLength is only supported on string parameters") {
+			t.Errorf("Expected a panic naming the misplaced bound, got %v", r)
+		}
+	}()
+	ReflectTool(func() *TestBadBoundsTool { return &TestBadBoundsTool{} })
+}
+
+type TestDefaultsTool struct {
+	ToolInfo `name:"defaults_tool" description:"A tool declaring its defaults in tags"`
+
+	Timeout float64 `json:"timeout" description:"Seconds to wait" default:"10"`
+	Lines   int     `json:"lines" description:"Lines to show" default:"100"`
+	Shell   string  `json:"shell" description:"Shell to use" default:"bash"`
+	Follow  bool    `json:"follow" description:"Keep following" default:"true"`
+}
+
+func (t *TestDefaultsTool) Handle(ctx context.Context) (interface{}, error) {
+	return fmt.Sprintf("%v %v %v %v", t.Timeout, t.Lines, t.Shell, t.Follow), nil
+}
+
+func TestReflectToolDefaultTags(t *testing.T) {
+	// A constructor that used to repeat the defaults can return the bare struct
+	migrated := ReflectTool(func() *TestDefaultsTool { return &TestDefaultsTool{} })
+	legacy := ReflectTool(func() *TestDefaultsTool {
+		return &TestDefaultsTool{Timeout: 10, Lines: 100, Shell: "bash", Follow: true}
+	})
+	for _, arguments := range []map[string]interface{}{
+		{},
+		{"timeout": 2.5, "shell": "zsh"},
+		{"lines": 0, "follow": false},
+	} {
+		if got, want := callText(t, migrated.Handler, arguments), callText(t, legacy.Handler, arguments); got != want {
+			t.Errorf("With %v expected %q like the legacy constructor, got %q", arguments, want, got)
+		}
+	}
+	if text := callText(t, migrated.Handler, map[string]interface{}{"lines": 0, "follow": false}); text != "10 0 bash false" {
+		t.Errorf("Expected explicit zero values to override defaults, got %q", text)
+	}
+
+	// Values set by the constructor win over the tag
+	custom := ReflectTool(func() *TestDefaultsTool { return &TestDefaultsTool{Shell: "fish"} })
+	if text := callText(t, custom.Handler, map[string]interface{}{}); text != "10 100 fish true" {
+		t.Errorf("Expected the constructor's value to be kept, got %q", text)
+	}
+}
+
+type TestBadDefaultTool struct {
+	ToolInfo `name:"bad_default_tool" description:"A tool with a default that does not fit its type"`
+
+	Lines int `json:"lines" description:"Lines to show" default:"many"`
+}
+
+func (t *TestBadDefaultTool) Handle(ctx context.Context) (interface{}, error) {
+	return "ok", nil
+}
+
+func TestReflectToolInvalidDefaultTag(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), `parameter lines: invalid default "many"`) {
+			t.Errorf("Expected a panic naming the invalid default, got %v", r)
+		}
+	}()
+	ReflectTool(func() *TestBadDefaultTool { return &TestBadDefaultTool{} })
+}
@@ -0,0 +1,39 @@
+package mcpcommon
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Blob is binary data a tool can return from Handle. Images and audio become
+// the matching MCP content type; anything else is embedded as a blob resource.
+type Blob struct {
+	Data     []byte
+	MimeType string
+	// URI identifies non-image, non-audio blobs in the embedded resource (defaults to "blob:").
+	URI string
+}
+
+// Content converts the blob to the content block it is returned as, for tools
+// that return it alongside other blocks in a []mcp.Content.
+func (b Blob) Content() mcp.Content {
+	data := base64.StdEncoding.EncodeToString(b.Data)
+	switch {
+	case strings.HasPrefix(b.MimeType, "image/"):
+		return mcp.NewImageContent(data, b.MimeType)
+	case strings.HasPrefix(b.MimeType, "audio/"):
+		return mcp.NewAudioContent(data, b.MimeType)
+	default:
+		uri := b.URI
+		if uri == "" {
+			uri = "blob:"
+		}
+		return mcp.NewEmbeddedResource(mcp.BlobResourceContents{
+			URI:      uri,
+			MIMEType: b.MimeType,
+			Blob:     data,
+		})
+	}
+}
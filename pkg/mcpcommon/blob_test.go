@@ -0,0 +1,69 @@
+package mcpcommon
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestConvertResultImageContent(t *testing.T) {
+	image := mcp.NewImageContent("aGVsbG8=", "image/png")
+
+	result := convertResult("image_tool", image)
+
+	if len(result.Content) != 1 {
+		t.Fatalf("Expected 1 content block, got %d", len(result.Content))
+	}
+	got, ok := result.Content[0].(mcp.ImageContent)
+	if !ok {
+		t.Fatalf("Expected ImageContent, got %T", result.Content[0])
+	}
+	if got.MIMEType != "image/png" {
+		t.Errorf("Expected mime type image/png, got %s", got.MIMEType)
+	}
+	if got.Data != "aGVsbG8=" {
+		t.Errorf("Expected data to be preserved, got %s", got.Data)
+	}
+}
+
+func TestConvertResultBlob(t *testing.T) {
+	data := []byte{0x89, 'P', 'N', 'G'}
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	image := convertResult("blob_tool", Blob{Data: data, MimeType: "image/png"})
+	if got, ok := image.Content[0].(mcp.ImageContent); !ok || got.MIMEType != "image/png" || got.Data != encoded {
+		t.Errorf("Expected image content with image/png, got %#v", image.Content[0])
+	}
+
+	audio := convertResult("blob_tool", &Blob{Data: data, MimeType: "audio/wav"})
+	if got, ok := audio.Content[0].(mcp.AudioContent); !ok || got.MIMEType != "audio/wav" {
+		t.Errorf("Expected audio content with audio/wav, got %#v", audio.Content[0])
+	}
+
+	other := convertResult("blob_tool", Blob{Data: data, MimeType: "application/pdf"})
+	embedded, ok := other.Content[0].(mcp.EmbeddedResource)
+	if !ok {
+		t.Fatalf("Expected embedded resource, got %T", other.Content[0])
+	}
+	blob, ok := embedded.Resource.(mcp.BlobResourceContents)
+	if !ok || blob.MIMEType != "application/pdf" || blob.Blob != encoded {
+		t.Errorf("Expected blob resource with application/pdf, got %#v", embedded.Resource)
+	}
+}
+
+func TestConvertResultContentList(t *testing.T) {
+	chart := Blob{Data: []byte{0x89, 'P', 'N', 'G'}, MimeType: "image/png"}
+
+	result := convertResult("chart_tool", []mcp.Content{mcp.NewTextContent("Requests per second"), chart.Content()})
+
+	if len(result.Content) != 2 {
+		t.Fatalf("Expected 2 content blocks, got %d", len(result.Content))
+	}
+	if got, ok := result.Content[0].(mcp.TextContent); !ok || got.Text != "Requests per second" {
+		t.Errorf("Expected the caption first, got %#v", result.Content[0])
+	}
+	if got, ok := result.Content[1].(mcp.ImageContent); !ok || got.MIMEType != "image/png" {
+		t.Errorf("Expected the chart second, got %#v", result.Content[1])
+	}
+}
@@ -0,0 +1,46 @@
+package mcpcommon
+
+import (
+	"errors"
+	"fmt"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ToolSet is a named group of tools, usually the Tools slice of one server package.
+type ToolSet struct {
+	Name  string
+	Tools []server.ServerTool
+}
+
+// BuildServer returns an MCPServer serving the tools of all toolSets. Every server
+// built this way advertises tool list changes and recovers from handler panics.
+// It fails if two tools share a name, reporting every conflict and where each side came from.
+func BuildServer(name, version string, toolSets ...ToolSet) (*server.MCPServer, error) {
+	var tools []server.ServerTool
+	var conflicts []error
+	owners := make(map[string]string)
+	for _, toolSet := range toolSets {
+		for _, tool := range toolSet.Tools {
+			if owner, ok := owners[tool.Tool.Name]; ok {
+				if owner == toolSet.Name {
+					conflicts = append(conflicts, fmt.Errorf("tool %s is defined more than once by %s", tool.Tool.Name, owner))
+				} else {
+					conflicts = append(conflicts, fmt.Errorf("tool %s is defined by both %s and %s", tool.Tool.Name, owner, toolSet.Name))
+				}
+				continue
+			}
+			owners[tool.Tool.Name] = toolSet.Name
+			tools = append(tools, tool)
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, errors.Join(conflicts...)
+	}
+
+	s := server.NewMCPServer(name, version,
+		server.WithToolCapabilities(true),
+		server.WithRecovery(),
+	)
+	s.AddTools(tools...)
+	return s, nil
+}
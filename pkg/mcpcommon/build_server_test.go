@@ -0,0 +1,83 @@
+package mcpcommon
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+type TestPingTool struct {
+	ToolInfo `name:"ping" description:"Reply with pong"`
+}
+
+func (t *TestPingTool) Handle(ctx context.Context) (interface{}, error) {
+	return "pong", nil
+}
+
+type TestEchoTool struct {
+	ToolInfo `name:"echo" description:"Echo the message"`
+
+	Message string `json:"message" description:"Message to echo"`
+}
+
+func (t *TestEchoTool) Handle(ctx context.Context) (interface{}, error) {
+	return t.Message, nil
+}
+
+func TestBuildServer(t *testing.T) {
+	s, err := BuildServer("combined", "1.0.0",
+		ToolSet{Name: "first", Tools: []server.ServerTool{ReflectTool(func() *TestPingTool { return &TestPingTool{} })}},
+		ToolSet{Name: "second", Tools: []server.ServerTool{ReflectTool(func() *TestEchoTool { return &TestEchoTool{} })}},
+	)
+	if err != nil {
+		t.Fatalf("Expected tool sets to combine, got: %v", err)
+	}
+
+	response := s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	result, ok := response.(mcp.JSONRPCResponse).Result.(mcp.ListToolsResult)
+	if !ok {
+		t.Fatalf("Expected a tools/list result, got %v", response)
+	}
+	var names []string
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+	}
+	if strings.Join(names, ",") != "echo,ping" {
+		t.Errorf("Expected echo and ping tools, got %v", names)
+	}
+}
+
+func TestBuildServerConflict(t *testing.T) {
+	_, err := BuildServer("combined", "1.0.0",
+		ToolSet{Name: "first", Tools: []server.ServerTool{ReflectTool(func() *TestPingTool { return &TestPingTool{} })}},
+		ToolSet{Name: "second", Tools: []server.ServerTool{
+			ReflectTool(func() *TestEchoTool { return &TestEchoTool{} }),
+			ReflectTool(func() *TestPingTool { return &TestPingTool{} }),
+		}},
+	)
+	if err == nil {
+		t.Fatal("Expected a conflict error")
+	}
+	if !strings.Contains(err.Error(), "tool ping is defined by both first and second") {
+		t.Errorf("Expected conflict to name the tool and both tool sets, got: %v", err)
+	}
+}
+
+func TestBuildServerDuplicateWithinToolSet(t *testing.T) {
+	_, err := BuildServer("single", "1.0.0",
+		ToolSet{Name: "only", Tools: []server.ServerTool{
+			ReflectTool(func() *TestPingTool { return &TestPingTool{} }),
+			ReflectTool(func() *TestEchoTool { return &TestEchoTool{} }),
+			ReflectTool(func() *TestPingTool { return &TestPingTool{} }),
+		}},
+	)
+	if err == nil {
+		t.Fatal("Expected a conflict error")
+	}
+	if !strings.Contains(err.Error(), "tool ping is defined more than once by only") {
+		t.Errorf("Expected conflict to name the tool and its tool set, got: %v", err)
+	}
+}
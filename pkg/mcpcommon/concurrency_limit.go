@@ -0,0 +1,26 @@
+package mcpcommon
+
+import (
+	"context"
+	"sync"
+)
+
+// toolSlots holds a semaphore for each tool declared with max_concurrent.
+var toolSlots sync.Map
+
+// acquireToolSlot waits until fewer than max_concurrent calls of the tool are
+// running and returns a function that frees the slot again. Calls queue in
+// no particular order; tools without a limit return immediately.
+func acquireToolSlot(ctx context.Context, toolName string) (release func(), err error) {
+	value, ok := toolSlots.Load(toolName)
+	if !ok {
+		return func() {}, nil
+	}
+	slots := value.(chan struct{})
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, Errorf(Timeout, "gave up waiting for other calls of %s to finish: %v", toolName, ctx.Err())
+	}
+}
@@ -0,0 +1,81 @@
+package mcpcommon
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+var serialToolRunning, serialToolMaxRunning atomic.Int32
+
+type TestSerialTool struct {
+	ToolInfo `name:"serial_tool" description:"A tool that must not run concurrently" max_concurrent:"1"`
+}
+
+func (t *TestSerialTool) Handle(ctx context.Context) (interface{}, error) {
+	running := serialToolRunning.Add(1)
+	defer serialToolRunning.Add(-1)
+	for {
+		maxRunning := serialToolMaxRunning.Load()
+		if running <= maxRunning || serialToolMaxRunning.CompareAndSwap(maxRunning, running) {
+			break
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	return "done", nil
+}
+
+func TestReflectToolMaxConcurrent(t *testing.T) {
+	serverTool := ReflectTool(func() *TestSerialTool {
+		return &TestSerialTool{}
+	})
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if text := callText(t, serverTool.Handler, nil); text != "done" {
+				t.Errorf("Expected done, got %q", text)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxRunning := serialToolMaxRunning.Load(); maxRunning != 1 {
+		t.Errorf("Expected calls to run one at a time, %d ran at once", maxRunning)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected the second call to wait for the first, both finished in %v", elapsed)
+	}
+}
+
+func TestReflectToolMaxConcurrentCancelled(t *testing.T) {
+	serverTool := ReflectTool(func() *TestSerialTool {
+		return &TestSerialTool{}
+	})
+
+	release, err := acquireToolSlot(context.Background(), "serial_tool")
+	if err != nil {
+		t.Fatalf("Expected to acquire the free slot, got: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	result, err := serverTool.Handler(ctx, mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Handler execution failed: %v", err)
+	}
+	if !result.IsError {
+		t.Fatalf("Expected an error result while the only slot is taken, got %v", result.Content)
+	}
+	if category := errorMeta(t, result)["category"]; category != string(Timeout) {
+		t.Errorf("Expected Timeout category, got %v", category)
+	}
+}
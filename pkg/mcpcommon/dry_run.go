@@ -0,0 +1,57 @@
+package mcpcommon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// DryRunArgument is a reserved argument that, when true, checks a call's arguments
+// without running the tool. Arguments are unmarshaled over the tool's defaults and
+// passed to its Validate hook if it has one; Handle is never called. Any reflect
+// tool supports it without implementing anything.
+const DryRunArgument = "_dry_run"
+
+// Validator may be implemented by a tool to reject bad arguments before Handle runs.
+// It is called on every invocation, including dry runs.
+type Validator interface {
+	Validate(ctx context.Context) error
+}
+
+func isDryRun(arguments map[string]any) bool {
+	dryRun, _ := arguments[DryRunArgument].(bool)
+	return dryRun
+}
+
+// validateTool checks required_if, enum and bound tags and runs the tool's Validate
+// hook, tagging untyped errors as InvalidArgument. arguments are the ones the
+// caller passed, after renaming deprecated names.
+func validateTool(ctx context.Context, toolInstance ToolHandler, arguments map[string]any) error {
+	if err := checkRequiredIf(toolInstance); err != nil {
+		return err
+	}
+	if err := checkEnum(toolInstance, arguments); err != nil {
+		return err
+	}
+	if err := checkBounds(toolInstance, arguments); err != nil {
+		return err
+	}
+	validator, ok := toolInstance.(Validator)
+	if !ok {
+		return nil
+	}
+	err := validator.Validate(ctx)
+	if err == nil {
+		return nil
+	}
+	var toolErr *ToolError
+	if errors.As(err, &toolErr) {
+		return err
+	}
+	return &ToolError{Code: InvalidArgument, Err: err}
+}
+
+func dryRunResult(toolName string) *mcp.CallToolResult {
+	return mcp.NewToolResultText(fmt.Sprintf("Dry run: arguments for %s are valid, the tool was not run", toolName))
+}
@@ -0,0 +1,83 @@
+package mcpcommon
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type TestDestructiveTool struct {
+	ToolInfo `name:"destructive_tool" description:"A tool that deletes things" destructive:"true"`
+
+	Path string `json:"path" description:"Path to delete"`
+
+	handled *bool
+}
+
+func (t *TestDestructiveTool) Validate(ctx context.Context) error {
+	if t.Path == "" {
+		return errors.New("path is required")
+	}
+	return nil
+}
+
+func (t *TestDestructiveTool) Handle(ctx context.Context) (interface{}, error) {
+	*t.handled = true
+	return "deleted " + t.Path, nil
+}
+
+func TestReflectToolDryRun(t *testing.T) {
+	handled := false
+	serverTool := ReflectTool(func() *TestDestructiveTool {
+		return &TestDestructiveTool{handled: &handled}
+	})
+
+	text := callText(t, serverTool.Handler, map[string]interface{}{"path": "/tmp/x", DryRunArgument: true})
+	if handled {
+		t.Error("Expected dry run not to call Handle")
+	}
+	if !strings.Contains(text, "Dry run") {
+		t.Errorf("Expected dry run confirmation, got %q", text)
+	}
+
+	text = callText(t, serverTool.Handler, map[string]interface{}{"path": "/tmp/x"})
+	if !handled || text != "deleted /tmp/x" {
+		t.Errorf("Expected a normal call to run the tool, got %q", text)
+	}
+}
+
+func TestReflectToolDryRunValidationFailure(t *testing.T) {
+	handled := false
+	serverTool := ReflectTool(func() *TestDestructiveTool {
+		return &TestDestructiveTool{handled: &handled}
+	})
+
+	result, err := serverTool.Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Arguments: map[string]interface{}{DryRunArgument: true}},
+	})
+	if err != nil {
+		t.Fatalf("Handler execution failed: %v", err)
+	}
+	if handled {
+		t.Error("Expected dry run not to call Handle")
+	}
+	meta := errorMeta(t, result)
+	if meta["category"] != string(InvalidArgument) {
+		t.Errorf("Expected category %s, got %v", InvalidArgument, meta["category"])
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "path is required") {
+		t.Errorf("Expected validation message, got %q", text)
+	}
+}
+
+func TestReflectToolDryRunWithoutValidator(t *testing.T) {
+	serverTool := ReflectTool(newTestToolWithTags)
+
+	text := callText(t, serverTool.Handler, map[string]interface{}{"required_string": "a", DryRunArgument: true})
+	if text == "test result" {
+		t.Error("Expected dry run not to call Handle")
+	}
+}
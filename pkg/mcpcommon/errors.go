@@ -0,0 +1,77 @@
+package mcpcommon
+
+import (
+	"errors"
+	"fmt"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ErrorCode classifies a tool error so clients can tell failures apart without parsing text.
+type ErrorCode string
+
+const (
+	InvalidArgument  ErrorCode = "InvalidArgument"
+	NotFound         ErrorCode = "NotFound"
+	Timeout          ErrorCode = "Timeout"
+	PermissionDenied ErrorCode = "PermissionDenied"
+	Internal         ErrorCode = "Internal"
+)
+
+// JSON-RPC error codes for each category. InvalidArgument and Internal reuse the
+// standard JSON-RPC codes, the others live in the implementation-defined server range.
+var jsonRPCErrorCodes = map[ErrorCode]int{
+	InvalidArgument:  mcp.INVALID_PARAMS,
+	NotFound:         -32001,
+	Timeout:          -32002,
+	PermissionDenied: -32003,
+	Internal:         mcp.INTERNAL_ERROR,
+}
+
+// JSONRPCCode returns the JSON-RPC error code for the category.
+func (c ErrorCode) JSONRPCCode() int {
+	if code, ok := jsonRPCErrorCodes[c]; ok {
+		return code
+	}
+	return mcp.INTERNAL_ERROR
+}
+
+// ToolError is an error returned by a tool that carries an ErrorCode.
+type ToolError struct {
+	Code ErrorCode
+	Err  error
+}
+
+func (e *ToolError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ToolError) Unwrap() error {
+	return e.Err
+}
+
+// Errorf formats an error like fmt.Errorf and tags it with code.
+func Errorf(code ErrorCode, format string, args ...any) error {
+	return &ToolError{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// ErrorCodeOf returns the code of the first ToolError in err's chain, or Internal if there is none.
+func ErrorCodeOf(err error) ErrorCode {
+	var toolErr *ToolError
+	if errors.As(err, &toolErr) {
+		return toolErr.Code
+	}
+	return Internal
+}
+
+// errorResult converts an error into an error result whose _meta carries the error code.
+func errorResult(toolName string, err error) *mcp.CallToolResult {
+	code := ErrorCodeOf(err)
+	result := mcp.NewToolResultErrorFromErr(toolName, err)
+	result.Meta = map[string]any{
+		"error": map[string]any{
+			"category": string(code),
+			"code":     code.JSONRPCCode(),
+		},
+	}
+	return result
+}
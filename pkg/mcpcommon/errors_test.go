@@ -0,0 +1,99 @@
+package mcpcommon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type TestNotFoundTool struct {
+	ToolInfo `name:"not_found_tool" description:"A tool that always fails with NotFound"`
+
+	Name string `json:"name" description:"Name to look up"`
+}
+
+func (t *TestNotFoundTool) Handle(ctx context.Context) (interface{}, error) {
+	return nil, fmt.Errorf("lookup failed: %w", Errorf(NotFound, "thing '%s' not found", t.Name))
+}
+
+type TestPlainErrorTool struct {
+	ToolInfo `name:"plain_error_tool" description:"A tool that fails with an untyped error"`
+}
+
+func (t *TestPlainErrorTool) Handle(ctx context.Context) (interface{}, error) {
+	return nil, errors.New("something broke")
+}
+
+func errorMeta(t *testing.T, result *mcp.CallToolResult) map[string]any {
+	t.Helper()
+	if !result.IsError {
+		t.Fatal("Expected an error result")
+	}
+	meta, ok := result.Meta["error"].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected error metadata in result, got: %v", result.Meta)
+	}
+	return meta
+}
+
+func TestToolErrorCodeNotFound(t *testing.T) {
+	serverTool := ReflectTool(func() *TestNotFoundTool {
+		return &TestNotFoundTool{}
+	})
+
+	result, err := serverTool.Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{"name": "widget"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Handler execution failed: %v", err)
+	}
+
+	meta := errorMeta(t, result)
+	if meta["category"] != string(NotFound) {
+		t.Errorf("Expected category %s, got %v", NotFound, meta["category"])
+	}
+	if meta["code"] != NotFound.JSONRPCCode() {
+		t.Errorf("Expected code %d, got %v", NotFound.JSONRPCCode(), meta["code"])
+	}
+
+	text := result.Content[0].(mcp.TextContent).Text
+	if text != "not_found_tool: lookup failed: thing 'widget' not found" {
+		t.Errorf("Unexpected error text: %s", text)
+	}
+}
+
+func TestToolErrorCodeDefaultsToInternal(t *testing.T) {
+	serverTool := ReflectTool(func() *TestPlainErrorTool {
+		return &TestPlainErrorTool{}
+	})
+
+	result, err := serverTool.Handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("Handler execution failed: %v", err)
+	}
+
+	meta := errorMeta(t, result)
+	if meta["category"] != string(Internal) {
+		t.Errorf("Expected category %s, got %v", Internal, meta["category"])
+	}
+	if meta["code"] != mcp.INTERNAL_ERROR {
+		t.Errorf("Expected code %d, got %v", mcp.INTERNAL_ERROR, meta["code"])
+	}
+}
+
+func TestErrorCodeOf(t *testing.T) {
+	if code := ErrorCodeOf(Errorf(InvalidArgument, "bad")); code != InvalidArgument {
+		t.Errorf("Expected %s, got %s", InvalidArgument, code)
+	}
+	if code := ErrorCodeOf(fmt.Errorf("wrapped: %w", Errorf(Timeout, "slow"))); code != Timeout {
+		t.Errorf("Expected %s through wrapping, got %s", Timeout, code)
+	}
+	if code := ErrorCodeOf(errors.New("plain")); code != Internal {
+		t.Errorf("Expected %s, got %s", Internal, code)
+	}
+}
@@ -0,0 +1,221 @@
+package mcpcommon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// JSONFormat controls how results that are not text, content or blobs are
+// marshaled to JSON.
+type JSONFormat struct {
+	// Indent is repeated once per nesting level; empty produces compact JSON.
+	Indent string
+	// OmitEmpty drops null, false, zero, empty string, empty array and empty object values.
+	OmitEmpty bool
+	// SortKeys orders object keys alphabetically instead of in struct field order.
+	SortKeys bool
+}
+
+// IndentedJSON is the default format: two-space indentation, all fields, struct order.
+var IndentedJSON = JSONFormat{Indent: "  "}
+
+// CompactJSON saves tokens on large results: no indentation and no empty fields.
+var CompactJSON = JSONFormat{OmitEmpty: true}
+
+var defaultJSONFormat = IndentedJSON
+var defaultJSONFormatMu sync.RWMutex
+
+// SetDefaultJSONFormat sets the format for tools that do not declare their own
+// with the json_format tag.
+func SetDefaultJSONFormat(format JSONFormat) {
+	defaultJSONFormatMu.Lock()
+	defer defaultJSONFormatMu.Unlock()
+	defaultJSONFormat = format
+}
+
+var toolJSONFormats sync.Map
+
+func jsonFormatFor(toolName string) JSONFormat {
+	if format, ok := toolJSONFormats.Load(toolName); ok {
+		return format.(JSONFormat)
+	}
+	defaultJSONFormatMu.RLock()
+	defer defaultJSONFormatMu.RUnlock()
+	return defaultJSONFormat
+}
+
+// parseJSONFormat parses a json_format tag: a comma-separated list of
+// compact, omitempty and sortkeys applied on top of IndentedJSON.
+func parseJSONFormat(tag string) (JSONFormat, error) {
+	format := IndentedJSON
+	for _, option := range strings.Split(tag, ",") {
+		switch strings.TrimSpace(option) {
+		case "":
+		case "compact":
+			format.Indent = ""
+		case "omitempty":
+			format.OmitEmpty = true
+		case "sortkeys":
+			format.SortKeys = true
+		default:
+			return JSONFormat{}, fmt.Errorf("unknown json_format option %q", option)
+		}
+	}
+	return format, nil
+}
+
+// marshal encodes v according to the format.
+func (f JSONFormat) marshal(v any) ([]byte, error) {
+	if f.OmitEmpty || f.SortKeys {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		value, err := decodeOrdered(decoder)
+		if err != nil {
+			return nil, err
+		}
+		if f.OmitEmpty {
+			value = dropEmpty(value)
+		}
+		if f.SortKeys {
+			sortKeys(value)
+		}
+		v = value
+	}
+
+	if f.Indent == "" {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", f.Indent)
+}
+
+// orderedObject is a JSON object that keeps its keys in the order they were decoded.
+type orderedObject []orderedField
+
+type orderedField struct {
+	Key   string
+	Value any
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeOrdered decodes the next JSON value, using orderedObject for objects.
+func decodeOrdered(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := orderedObject{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, orderedField{Key: key.(string), Value: value})
+		}
+		_, err := decoder.Token() // closing brace
+		return object, err
+	case json.Delim('['):
+		array := []any{}
+		for decoder.More() {
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err := decoder.Token() // closing bracket
+		return array, err
+	default:
+		return token, nil
+	}
+}
+
+func dropEmpty(value any) any {
+	switch v := value.(type) {
+	case orderedObject:
+		kept := orderedObject{}
+		for _, field := range v {
+			field.Value = dropEmpty(field.Value)
+			if !isEmptyJSON(field.Value) {
+				kept = append(kept, field)
+			}
+		}
+		return kept
+	case []any:
+		for i := range v {
+			v[i] = dropEmpty(v[i])
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+func isEmptyJSON(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case string:
+		return v == ""
+	case json.Number:
+		f, err := v.Float64()
+		return err == nil && f == 0
+	case orderedObject:
+		return len(v) == 0
+	case []any:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+func sortKeys(value any) {
+	switch v := value.(type) {
+	case orderedObject:
+		sort.SliceStable(v, func(i, j int) bool { return v[i].Key < v[j].Key })
+		for _, field := range v {
+			sortKeys(field.Value)
+		}
+	case []any:
+		for _, item := range v {
+			sortKeys(item)
+		}
+	}
+}
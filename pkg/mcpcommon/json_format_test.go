@@ -0,0 +1,82 @@
+package mcpcommon
+
+import (
+	"context"
+	"testing"
+)
+
+type testJSONResult struct {
+	Name    string            `json:"name"`
+	Count   int               `json:"count"`
+	Note    string            `json:"note"`
+	Tags    []string          `json:"tags"`
+	Labels  map[string]string `json:"labels"`
+	Enabled bool              `json:"enabled"`
+}
+
+var testJSONValue = testJSONResult{Name: "widget", Count: 3, Labels: map[string]string{"b": "2", "a": "1"}}
+
+func TestJSONFormatIndentedVsCompact(t *testing.T) {
+	indented, err := IndentedJSON.marshal(testJSONValue)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	expectedIndented := `{
+  "name": "widget",
+  "count": 3,
+  "note": "",
+  "tags": null,
+  "labels": {
+    "a": "1",
+    "b": "2"
+  },
+  "enabled": false
+}`
+	if string(indented) != expectedIndented {
+		t.Errorf("Expected indented JSON:\n%s\ngot:\n%s", expectedIndented, indented)
+	}
+
+	compact, err := CompactJSON.marshal(testJSONValue)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	expectedCompact := `{"name":"widget","count":3,"labels":{"a":"1","b":"2"}}`
+	if string(compact) != expectedCompact {
+		t.Errorf("Expected compact JSON %s, got %s", expectedCompact, compact)
+	}
+}
+
+func TestJSONFormatSortKeys(t *testing.T) {
+	sorted, err := JSONFormat{SortKeys: true}.marshal(testJSONValue)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	expected := `{"count":3,"enabled":false,"labels":{"a":"1","b":"2"},"name":"widget","note":"","tags":null}`
+	if string(sorted) != expected {
+		t.Errorf("Expected sorted JSON %s, got %s", expected, sorted)
+	}
+}
+
+type TestCompactResultTool struct {
+	ToolInfo `name:"compact_result_tool" description:"Returns a struct as compact JSON" json_format:"compact,omitempty"`
+}
+
+func (t *TestCompactResultTool) Handle(ctx context.Context) (interface{}, error) {
+	return testJSONValue, nil
+}
+
+func TestReflectToolJSONFormatTag(t *testing.T) {
+	serverTool := ReflectTool(func() *TestCompactResultTool { return &TestCompactResultTool{} })
+
+	text := callText(t, serverTool.Handler, map[string]interface{}{})
+	expected := `{"name":"widget","count":3,"labels":{"a":"1","b":"2"}}`
+	if text != expected {
+		t.Errorf("Expected tool's json_format to apply, got %s", text)
+	}
+}
+
+func TestParseJSONFormatUnknownOption(t *testing.T) {
+	if _, err := parseJSONFormat("compact,pretty"); err == nil {
+		t.Error("Expected an error for an unknown option")
+	}
+}
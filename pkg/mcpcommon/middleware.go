@@ -0,0 +1,44 @@
+package mcpcommon
+
+import (
+	"context"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ToolMiddleware wraps the handler of every reflected tool, e.g. to time calls,
+// check authorization or redact results. The tool name is in request.Params.Name.
+type ToolMiddleware func(next server.ToolHandlerFunc) server.ToolHandlerFunc
+
+var toolMiddlewares []ToolMiddleware
+var toolMiddlewaresMu sync.RWMutex
+
+// UseToolMiddleware adds middleware around every reflected tool, including tools
+// created before the call. The first middleware added is the outermost.
+func UseToolMiddleware(middleware ...ToolMiddleware) {
+	toolMiddlewaresMu.Lock()
+	defer toolMiddlewaresMu.Unlock()
+	toolMiddlewares = append(toolMiddlewares, middleware...)
+}
+
+// withMiddleware wraps handler in the registered middleware, inside a logger
+// that records when each call of toolName starts and ends and how long it took.
+func withMiddleware(toolName string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	toolMiddlewaresMu.RLock()
+	defer toolMiddlewaresMu.RUnlock()
+	for i := len(toolMiddlewares) - 1; i >= 0; i-- {
+		handler = toolMiddlewares[i](handler)
+	}
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		slog.DebugContext(ctx, "tool call started", "tool", toolName)
+		result, err := handler(ctx, request)
+		failed := err != nil || result != nil && result.IsError
+		slog.DebugContext(ctx, "tool call finished", "tool", toolName, "duration", time.Since(start), "failed", failed)
+		return result, err
+	}
+}
@@ -0,0 +1,86 @@
+package mcpcommon
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// useToolMiddleware adds middleware for the rest of the test.
+func useToolMiddleware(t *testing.T, middleware ...ToolMiddleware) {
+	toolMiddlewaresMu.RLock()
+	previous := toolMiddlewares
+	toolMiddlewaresMu.RUnlock()
+	t.Cleanup(func() {
+		toolMiddlewaresMu.Lock()
+		defer toolMiddlewaresMu.Unlock()
+		toolMiddlewares = previous
+	})
+	UseToolMiddleware(middleware...)
+}
+
+type callerKey struct{}
+
+type TestCallerTool struct {
+	ToolInfo `name:"caller_tool" description:"A tool reporting who called it"`
+}
+
+func (t *TestCallerTool) Handle(ctx context.Context) (interface{}, error) {
+	caller, _ := ctx.Value(callerKey{}).(string)
+	return "called by " + caller, nil
+}
+
+func TestToolMiddleware(t *testing.T) {
+	// Tools created before the middleware is added are wrapped too
+	serverTool := ReflectTool(func() *TestCallerTool { return &TestCallerTool{} })
+
+	var order []string
+	useToolMiddleware(t,
+		func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+			return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				order = append(order, "outer")
+				if request.GetArguments()["token"] != "secret" {
+					return mcp.NewToolResultError("unauthorized"), nil
+				}
+				return next(context.WithValue(ctx, callerKey{}, "alice"), request)
+			}
+		},
+		func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+			return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				order = append(order, "inner")
+				return next(ctx, request)
+			}
+		},
+	)
+
+	if text := callText(t, serverTool.Handler, map[string]interface{}{"token": "secret"}); text != "called by alice" {
+		t.Errorf("Expected the middleware's context to reach the tool, got %q", text)
+	}
+	if strings.Join(order, ",") != "outer,inner" {
+		t.Errorf("Expected the first middleware to be outermost, got %v", order)
+	}
+
+	order = nil
+	if text := callText(t, serverTool.Handler, map[string]interface{}{}); text != "unauthorized" {
+		t.Errorf("Expected the middleware to stop the call, got %q", text)
+	}
+	if strings.Join(order, ",") != "outer" {
+		t.Errorf("Expected inner middleware to be skipped, got %v", order)
+	}
+}
+
+func TestToolMiddlewareLogsDuration(t *testing.T) {
+	logs := captureDebugLogs(t)
+	serverTool := ReflectTool(func() *TestCallerTool { return &TestCallerTool{} })
+
+	callText(t, serverTool.Handler, map[string]interface{}{})
+
+	for _, want := range []string{`msg="tool call started" tool=caller_tool`, `msg="tool call finished" tool=caller_tool duration=`} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("Expected log containing %q, got:\n%s", want, logs.String())
+		}
+	}
+}
@@ -17,6 +17,9 @@ func PrintTools(tools []server.ServerTool) {
 	for _, serverTool := range sortedTools {
 		tool := serverTool.Tool
 		fmt.Printf("Tool: %s\n", tool.Name)
+		if group := ToolGroup(tool.Name); group != "" {
+			fmt.Printf("  Group: %s\n", group)
+		}
 		if tool.Description != "" {
 			fmt.Printf("  Description: %s\n", tool.Description)
 		}
@@ -5,22 +5,60 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"log/slog"
+	"sync/atomic"
 )
 
+// NotifyProgress reports progress of the current tool call to the client if it
+// asked for progress. A totalSteps of zero or less leaves the total unknown.
 func NotifyProgress(ctx context.Context, step int, totalSteps int, message string) {
+	var total any
+	if totalSteps > 0 {
+		total = totalSteps
+	}
+	sendProgress(ctx, step, total, message)
+}
+
+// NotifyProgressPercent reports progress of the current tool call as a
+// percentage from 0 to 100.
+func NotifyProgressPercent(ctx context.Context, percent float64, message string) {
+	sendProgress(ctx, percent, 100.0, message)
+}
+
+// NotifyActivity reports that the current tool call is still working when how
+// much is left is unknown, e.g. "analyzing docker build...". MCP requires the
+// progress value to increase, so each call counts one step; do not mix it with
+// NotifyProgress in the same call.
+func NotifyActivity(ctx context.Context, message string) {
+	var step int64
+	if counter, ok := ctx.Value(activityCounterContextKey).(*atomic.Int64); ok {
+		step = counter.Add(1)
+	}
+	sendProgress(ctx, step, nil, message)
+}
+
+// sendProgress sends a progress notification for the current tool call. A nil
+// total is left out, making the progress indeterminate.
+func sendProgress(ctx context.Context, progress any, total any, message string) {
 	s := server.ServerFromContext(ctx)
-	req := callToolRequestFromContext(ctx)
+	req, ok := callToolRequestFromContext(ctx)
+	if s == nil || !ok || req.Params.Meta == nil {
+		slog.DebugContext(ctx, "no client to report progress to")
+		return
+	}
 	progressToken := req.Params.Meta.ProgressToken
 	if progressToken == nil {
 		slog.DebugContext(ctx, "no progress token")
 		return
 	}
-	err := s.SendNotificationToClient(ctx, "notification/progress", map[string]any{
-		"progress":      step,
-		"total":         totalSteps,
+	params := map[string]any{
+		"progress":      progress,
 		"message":       message,
 		"progressToken": progressToken,
-	})
+	}
+	if total != nil {
+		params["total"] = total
+	}
+	err := s.SendNotificationToClient(ctx, "notifications/progress", params)
 
 	if err != nil {
 		slog.ErrorContext(ctx, "error sending progress", "err", err)
@@ -32,11 +70,16 @@ func NotifyProgress(ctx context.Context, step int, totalSteps int, message strin
 type ctxKey string
 
 var callToolRequestContextKey = ctxKey("callToolRequest")
+var activityCounterContextKey = ctxKey("activityCounter")
 
-func callToolRequestFromContext(ctx context.Context) *mcp.CallToolRequest {
-	return ctx.Value(callToolRequestContextKey).(*mcp.CallToolRequest)
+// callToolRequestFromContext returns the request of the tool call ctx belongs to,
+// if it was set up by InvokeReflectTool.
+func callToolRequestFromContext(ctx context.Context) (*mcp.CallToolRequest, bool) {
+	req, ok := ctx.Value(callToolRequestContextKey).(*mcp.CallToolRequest)
+	return req, ok && req != nil
 }
 
 func withCallToolRequest(ctx context.Context, ctr *mcp.CallToolRequest) context.Context {
+	ctx = context.WithValue(ctx, activityCounterContextKey, new(atomic.Int64))
 	return context.WithValue(ctx, callToolRequestContextKey, ctr)
 }
@@ -0,0 +1,77 @@
+package mcpcommon
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestNotifyProgressWithoutCallToolRequest(t *testing.T) {
+	logs := captureDebugLogs(t)
+
+	// Neither a plain context nor one holding a request without a progress
+	// token has anyone to report to; both must be a no-op.
+	NotifyProgress(context.Background(), 1, 2, "halfway")
+	NotifyProgress(withCallToolRequest(context.Background(), &mcp.CallToolRequest{}), 1, 2, "halfway")
+	if _, ok := callToolRequestFromContext(context.Background()); ok {
+		t.Error("Expected no request in a plain context")
+	}
+
+	if strings.Count(logs.String(), "no client to report progress to") != 2 {
+		t.Errorf("Expected both calls to be skipped, got:\n%s", logs.String())
+	}
+}
+
+// testSession is an initialized client session collecting notifications.
+type testSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) Initialize()                                         {}
+func (s *testSession) Initialized() bool                                   { return true }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return s.notifications }
+func (s *testSession) SessionID() string                                   { return "test" }
+
+type TestProgressTool struct {
+	ToolInfo `name:"progress_tool" description:"A tool reporting progress in every style"`
+}
+
+func (t *TestProgressTool) Handle(ctx context.Context) (interface{}, error) {
+	NotifyProgress(ctx, 1, 4, "step")
+	NotifyProgressPercent(ctx, 62.5, "percent")
+	NotifyActivity(ctx, "analyzing docker build...")
+	NotifyActivity(ctx, "analyzing docker run...")
+	return "done", nil
+}
+
+func TestNotifyProgressPayloads(t *testing.T) {
+	s := server.NewMCPServer("test", "1.0", server.WithToolCapabilities(true))
+	s.AddTools(ReflectTool(func() *TestProgressTool { return &TestProgressTool{} }))
+	session := &testSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+
+	message := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"progress_tool","arguments":{},"_meta":{"progressToken":"tok"}}}`
+	s.HandleMessage(s.WithContext(context.Background(), session), json.RawMessage(message))
+	close(session.notifications)
+
+	var got []map[string]any
+	for notification := range session.notifications {
+		if notification.Method != "notifications/progress" {
+			t.Errorf("Unexpected notification %s", notification.Method)
+		}
+		got = append(got, notification.Params.AdditionalFields)
+	}
+	expected := []map[string]any{
+		{"progressToken": "tok", "progress": 1, "total": 4, "message": "step"},
+		{"progressToken": "tok", "progress": 62.5, "total": 100.0, "message": "percent"},
+		{"progressToken": "tok", "progress": int64(1), "message": "analyzing docker build..."},
+		{"progressToken": "tok", "progress": int64(2), "message": "analyzing docker run..."},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected progress notifications:\n got: %v\nwant: %v", got, expected)
+	}
+}
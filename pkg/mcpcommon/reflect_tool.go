@@ -3,6 +3,7 @@ package mcpcommon
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -12,6 +13,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 func ReflectTool[T ToolHandler](constructor func() T) server.ServerTool {
@@ -24,33 +26,77 @@ func ReflectTool[T ToolHandler](constructor func() T) server.ServerTool {
 	}
 
 	// Get tool metadata from ToolInfo field
-	toolName, toolTitle, toolDescription, isDestructive, isReadOnly := parseToolInfo(toolType)
+	info := parseToolInfo(toolType)
+	toolName := info.name
 	if len(toolName) == 0 {
 		toolName = toolType.Name()
 	}
 
 	// Create the tool with basic info
 	options := []mcp.ToolOption{
-		mcp.WithDescription(toolDescription),
-		mcp.WithDestructiveHintAnnotation(isDestructive),
-		mcp.WithReadOnlyHintAnnotation(isReadOnly),
+		mcp.WithDescription(info.description),
+		mcp.WithDestructiveHintAnnotation(info.destructive),
+		mcp.WithReadOnlyHintAnnotation(info.readonly),
 	}
 
 	// Add title if provided
-	if toolTitle != "" {
-		options = append(options, mcp.WithTitleAnnotation(toolTitle))
+	if info.title != "" {
+		options = append(options, mcp.WithTitleAnnotation(info.title))
 	}
 
 	// Add properties from struct fields
+	checkRequiredIfTags(toolType)
+	if err := applyDefaultTags(reflect.New(toolType).Interface()); err != nil {
+		panic(fmt.Sprintf("Tool %s: %v", toolName, err))
+	}
 	options = append(options, parseToolProperties(toolType)...)
 
 	tool := mcp.NewTool(toolName, options...)
 
+	if info.group != "" {
+		toolGroups.Store(toolName, info.group)
+	}
+	if info.jsonFormat != nil {
+		toolJSONFormats.Store(toolName, *info.jsonFormat)
+	}
+	if info.maxConcurrent > 0 {
+		toolSlots.Store(toolName, make(chan struct{}, info.maxConcurrent))
+	}
+
+	var cache *resultCache
+	if info.cacheTTL > 0 {
+		if !info.readonly {
+			panic(fmt.Sprintf("Tool %s: cache_ttl is only supported on readonly tools", toolName))
+		}
+		cache = newResultCache(info.cacheTTL)
+	}
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (result *mcp.CallToolResult, err error) {
+		var key string
+		if cache != nil {
+			var bypass bool
+			key, bypass = cache.key(toolName, request.GetArguments())
+			if !bypass {
+				if cached, ok := cache.get(key); ok {
+					slog.DebugContext(ctx, "returning cached result", "tool", toolName)
+					return cached, nil
+				}
+			}
+		}
+
+		var toolInstance = constructor()
+		result, err = InvokeReflectTool(ctx, toolName, toolInstance, request)
+
+		if cache != nil && err == nil && result != nil && !result.IsError {
+			cache.put(key, result)
+		}
+		return result, err
+	}
+
 	return server.ServerTool{
 		Tool: tool,
-		Handler: func(ctx context.Context, request mcp.CallToolRequest) (result *mcp.CallToolResult, err error) {
-			var toolInstance = constructor()
-			return InvokeReflectTool(ctx, toolName, toolInstance, request)
+		Handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return withMiddleware(toolName, handler)(ctx, request)
 		},
 	}
 }
@@ -62,44 +108,108 @@ func InvokeReflectTool(ctx context.Context, toolName string, toolInstance ToolHa
 		}
 	}()
 
-	if err := unmarshalArguments(toolInstance, request.GetArguments()); err != nil {
+	arguments := renameDeprecatedArguments(toolInstance, request.GetArguments())
+	if err := unmarshalArguments(toolInstance, arguments); err != nil {
+		var toolErr *ToolError
+		if errors.As(err, &toolErr) {
+			return convertResult(toolName, err), nil
+		}
 		return nil, fmt.Errorf("failed to unmarshal arguments: %v", err)
 	}
 
 	ctx = withCallToolRequest(ctx, &request)
 
+	if err := validateTool(ctx, toolInstance, arguments); err != nil {
+		slog.WarnContext(ctx, "tool arguments failed validation", "tool", toolName, "err", err)
+		return convertResult(toolName, err), nil
+	}
+	if isDryRun(request.GetArguments()) {
+		slog.DebugContext(ctx, "dry run, not calling tool", "tool", toolName, "args", loggableArguments(toolInstance))
+		return dryRunResult(toolName), nil
+	}
+
+	release, err := acquireToolSlot(ctx, toolName)
+	if err != nil {
+		return convertResult(toolName, err), nil
+	}
+	defer release()
+
 	var rawResult any
-	slog.DebugContext(ctx, "calling tool", "tool", toolName)
+	slog.DebugContext(ctx, "calling tool", "tool", toolName, "args", loggableArguments(toolInstance))
 	rawResult, err = toolInstance.Handle(ctx)
 	if err != nil {
-
 		slog.WarnContext(ctx, "tool returned error", "err", err)
 		return convertResult(toolName, err), nil
 	}
 
 	// Convert result to CallToolResult
-	return convertResult(toolName, rawResult), nil
+	return truncateResult(toolName, convertResult(toolName, rawResult)), nil
+}
+
+// toolMetadata holds the tool-level settings parsed from the ToolInfo field tags.
+type toolMetadata struct {
+	name          string
+	title         string
+	description   string
+	destructive   bool
+	readonly      bool
+	group         string
+	cacheTTL      time.Duration
+	jsonFormat    *JSONFormat
+	maxConcurrent int
 }
 
-func parseToolInfo(toolType reflect.Type) (name, title, description string, destructive, readonly bool) {
+func parseToolInfo(toolType reflect.Type) (info toolMetadata) {
 	for i := 0; i < toolType.NumField(); i++ {
 		field := toolType.Field(i)
 		if field.Type == reflect.TypeOf(ToolInfo{}) {
-			name = field.Tag.Get("name")
-			title = field.Tag.Get("title")
-			description = field.Tag.Get("description")
-			destructive = field.Tag.Get("destructive") == "true"
-			readonly = field.Tag.Get("readonly") == "true"
+			info.name = field.Tag.Get("name")
+			info.title = field.Tag.Get("title")
+			info.description = field.Tag.Get("description")
+			info.destructive = field.Tag.Get("destructive") == "true"
+			info.readonly = field.Tag.Get("readonly") == "true"
+			info.group = field.Tag.Get("group")
+			if ttl := field.Tag.Get("cache_ttl"); ttl != "" {
+				var err error
+				info.cacheTTL, err = time.ParseDuration(ttl)
+				if err != nil {
+					panic(fmt.Sprintf("Tool %s: invalid cache_ttl %q: %v", toolType.Name(), ttl, err))
+				}
+			}
+			if tag, ok := field.Tag.Lookup("json_format"); ok {
+				format, err := parseJSONFormat(tag)
+				if err != nil {
+					panic(fmt.Sprintf("Tool %s: %v", toolType.Name(), err))
+				}
+				info.jsonFormat = &format
+			}
+			if limit := field.Tag.Get("max_concurrent"); limit != "" {
+				var err error
+				info.maxConcurrent, err = strconv.Atoi(limit)
+				if err != nil || info.maxConcurrent < 1 {
+					panic(fmt.Sprintf("Tool %s: invalid max_concurrent %q, must be a positive integer", toolType.Name(), limit))
+				}
+			}
 			return
 		}
 	}
 
 	// Fallback to type name if no ToolInfo found
-	name = strings.ToLower(toolType.Name())
-	description = "Tool generated from " + toolType.Name()
+	info.name = strings.ToLower(toolType.Name())
+	info.description = "Tool generated from " + toolType.Name()
 	return
 }
 
+var toolGroups sync.Map
+
+// ToolGroup returns the group a tool was declared in via the group tag, or "" if none.
+func ToolGroup(toolName string) string {
+	if group, ok := toolGroups.Load(toolName); ok {
+		return group.(string)
+	}
+	return ""
+}
+
 var registeredStructSchemas sync.Map
 
 func RegisterStructSchema(structName string, schemaJSON string) {
@@ -112,6 +222,12 @@ func RegisterStructSchema(structName string, schemaJSON string) {
 }
 
 func parseToolProperties(toolType reflect.Type) []mcp.ToolOption {
+	return parseProperties(toolType, map[reflect.Type]bool{toolType: true})
+}
+
+// parseProperties describes the fields of toolType as parameters. visiting
+// holds the struct types being described, to reject recursive types.
+func parseProperties(toolType reflect.Type, visiting map[reflect.Type]bool) []mcp.ToolOption {
 	var options []mcp.ToolOption
 
 	for i := 0; i < toolType.NumField(); i++ {
@@ -124,7 +240,7 @@ func parseToolProperties(toolType reflect.Type) []mcp.ToolOption {
 
 		// Skip embedded structs - we'll handle their fields recursively
 		if field.Anonymous {
-			options = append(options, parseToolProperties(field.Type)...)
+			options = append(options, parseProperties(field.Type, visiting)...)
 			continue
 		}
 
@@ -135,8 +251,23 @@ func parseToolProperties(toolType reflect.Type) []mcp.ToolOption {
 
 		fieldName := strings.Split(jsonTag, ",")[0]
 		description := field.Tag.Get("description")
-		required := field.Tag.Get("mcp") == "required"
+		if requiredIf := field.Tag.Get("required_if"); requiredIf != "" {
+			description += " (" + describeRequiredIf(requiredIf) + ")"
+		}
+		required := isRequired(field)
 		defaultValue := field.Tag.Get("default")
+		enum := field.Tag.Get("enum")
+		if enum != "" {
+			switch field.Type.Kind() {
+			case reflect.String, reflect.Int, reflect.Int64, reflect.Float64:
+			default:
+				panic(fmt.Sprintf("Field %s.%s: enum is only supported on string and number parameters", toolType.Name(), field.Name))
+			}
+		}
+		bounds, err := parseBounds(field)
+		if err != nil {
+			panic(fmt.Sprintf("Field %s.%s: %v", toolType.Name(), field.Name, err))
+		}
 
 		var paramOptions []mcp.PropertyOption
 		paramOptions = append(paramOptions, mcp.Description(description))
@@ -163,23 +294,29 @@ func parseToolProperties(toolType reflect.Type) []mcp.ToolOption {
 		switch field.Type.Kind() {
 		case reflect.Pointer:
 			element := field.Type.Elem()
-			// TODO: actually implement this with reflection, for now we just allow hard-coded schemas
-			val, ok := registeredStructSchemas.Load(element.Name())
-			if !ok {
-				log.Panicf("struct schema not registered: %s", field.Type.Name())
+			var schema map[string]any
+			if val, ok := registeredStructSchemas.Load(element.Name()); ok {
+				schema = val.(map[string]any)
+			} else if element.Kind() == reflect.Struct {
+				schema = reflectStructSchema(element, visiting)
+			} else {
+				break
 			}
-			schema := val.(map[string]any)
-			paramOptions = append(paramOptions, func(s map[string]any) {
-				for k, v := range schema {
-					s[k] = v
-				}
-			})
-			options = append(options, mcp.WithObject(fieldName, paramOptions...))
+			options = append(options, withObjectSchema(fieldName, description, required, schema))
 			continue
 		case reflect.String:
 			if defaultValue != "" {
 				paramOptions = append(paramOptions, mcp.DefaultString(defaultValue))
 			}
+			if enum != "" {
+				paramOptions = append(paramOptions, mcp.Enum(enumValues(enum)...))
+			}
+			if bounds.minLength != nil {
+				paramOptions = append(paramOptions, mcp.MinLength(*bounds.minLength))
+			}
+			if bounds.maxLength != nil {
+				paramOptions = append(paramOptions, mcp.MaxLength(*bounds.maxLength))
+			}
 			options = append(options, mcp.WithString(fieldName, paramOptions...))
 			continue
 
@@ -195,20 +332,65 @@ func parseToolProperties(toolType reflect.Type) []mcp.ToolOption {
 			continue
 
 		case reflect.Int, reflect.Int64, reflect.Float64:
+			if field.Type.Kind() != reflect.Float64 {
+				paramOptions = append(paramOptions, integerType)
+			}
 			if defaultValue != "" {
 				if defaultNum, err := strconv.ParseFloat(defaultValue, 64); err == nil {
 					paramOptions = append(paramOptions, mcp.DefaultNumber(defaultNum))
 				}
 			}
+			if enum != "" {
+				numbers, err := enumNumbers(enum)
+				if err != nil {
+					panic(fmt.Sprintf("Field %s.%s: %v", toolType.Name(), field.Name, err))
+				}
+				paramOptions = append(paramOptions, func(m map[string]any) {
+					m["enum"] = numbers
+				})
+			}
+			if bounds.min != nil {
+				paramOptions = append(paramOptions, mcp.Min(*bounds.min))
+			}
+			if bounds.max != nil {
+				paramOptions = append(paramOptions, mcp.Max(*bounds.max))
+			}
 			options = append(options, mcp.WithNumber(fieldName, paramOptions...))
 			continue
+		case reflect.Map:
+			if field.Type.Key().Kind() != reflect.String {
+				break
+			}
+			// An object with arbitrary keys, e.g. labels or headers
+			switch field.Type.Elem().Kind() {
+			case reflect.String:
+				paramOptions = append(paramOptions, mcp.AdditionalProperties(map[string]any{"type": "string"}))
+			case reflect.Interface:
+				paramOptions = append(paramOptions, mcp.AdditionalProperties(true))
+			default:
+				log.Panicf("don't know how to represent map values of parameter %v", field)
+			}
+			paramOptions = append(paramOptions, func(m map[string]any) {
+				delete(m, "properties")
+			})
+			options = append(options, mcp.WithObject(fieldName, paramOptions...))
+			continue
 		case reflect.Slice:
-			if field.Type.Elem().Kind() == reflect.String {
+			// Specify the item type of the array
+			switch field.Type.Elem().Kind() {
+			case reflect.String:
 				paramOptions = append(paramOptions, mcp.WithStringItems())
-				// Array of strings - specify items as string type
-				options = append(options, mcp.WithArray(fieldName, paramOptions...))
-				continue
+			case reflect.Int, reflect.Int64:
+				paramOptions = append(paramOptions, mcp.WithNumberItems(integerType))
+			case reflect.Float64:
+				paramOptions = append(paramOptions, mcp.WithNumberItems())
+			case reflect.Bool:
+				paramOptions = append(paramOptions, mcp.WithBooleanItems())
+			default:
+				log.Panicf("don't know how to represent items of parameter %v", field)
 			}
+			options = append(options, mcp.WithArray(fieldName, paramOptions...))
+			continue
 		}
 
 		log.Panicf("don't know how to represent parameter %v", field)
@@ -217,20 +399,75 @@ func parseToolProperties(toolType reflect.Type) []mcp.ToolOption {
 	return options
 }
 
+// integerType narrows a number property to integers, which mcp-go has no option for.
+func integerType(schema map[string]any) {
+	schema["type"] = "integer"
+}
+
+// reflectStructSchema builds an object schema for structType from the tags of
+// its fields, the same way tool parameters are described.
+func reflectStructSchema(structType reflect.Type, visiting map[reflect.Type]bool) map[string]any {
+	if visiting[structType] {
+		log.Panicf("recursive struct %s cannot be described by reflection, register its schema with RegisterStructSchema", structType.Name())
+	}
+	visiting[structType] = true
+	defer delete(visiting, structType)
+
+	tool := mcp.Tool{InputSchema: mcp.ToolInputSchema{Properties: map[string]any{}}}
+	for _, option := range parseProperties(structType, visiting) {
+		option(&tool)
+	}
+	schema := map[string]any{"properties": tool.InputSchema.Properties}
+	if len(tool.InputSchema.Required) > 0 {
+		schema["required"] = tool.InputSchema.Required
+	}
+	return schema
+}
+
+// withObjectSchema adds an object parameter described by schema. Unlike
+// mcp.WithObject it keeps the list of required nested properties in schema,
+// which mcp.Required would replace.
+func withObjectSchema(name, description string, required bool, schema map[string]any) mcp.ToolOption {
+	return func(t *mcp.Tool) {
+		property := map[string]any{"type": "object", "description": description}
+		for k, v := range schema {
+			property[k] = v
+		}
+		if required {
+			t.InputSchema.Required = append(t.InputSchema.Required, name)
+		}
+		t.InputSchema.Properties[name] = property
+	}
+}
+
 func unmarshalArguments(tool interface{}, arguments map[string]interface{}) error {
+	if err := applyDefaultTags(tool); err != nil {
+		return err
+	}
+	if err := checkIntegerArguments(tool, arguments); err != nil {
+		return err
+	}
+
 	// Convert arguments to JSON and back to populate the struct
 	jsonData, err := json.Marshal(arguments)
 	if err != nil {
 		return err
 	}
 
-	return json.Unmarshal(jsonData, tool)
+	if err := json.Unmarshal(jsonData, tool); err != nil {
+		return err
+	}
+
+	if err := applyEnvArguments(tool, arguments); err != nil {
+		return err
+	}
+	return checkRequiredArguments(tool, arguments)
 }
 
 func convertResult(toolName string, result interface{}) *mcp.CallToolResult {
 	switch v := result.(type) {
 	case error:
-		return mcp.NewToolResultErrorFromErr(toolName, v)
+		return errorResult(toolName, v)
 	case string:
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -242,9 +479,19 @@ func convertResult(toolName string, result interface{}) *mcp.CallToolResult {
 		}
 	case *mcp.CallToolResult:
 		return v
+	case mcp.Content:
+		// Image, audio and other content blocks pass through untouched
+		return &mcp.CallToolResult{Content: []mcp.Content{v}}
+	case []mcp.Content:
+		// Several blocks, e.g. a caption and a chart
+		return &mcp.CallToolResult{Content: v}
+	case Blob:
+		return &mcp.CallToolResult{Content: []mcp.Content{v.Content()}}
+	case *Blob:
+		return &mcp.CallToolResult{Content: []mcp.Content{v.Content()}}
 	default:
 		// Marshal to JSON and return as text
-		data, err := json.MarshalIndent(result, "", "  ")
+		data, err := jsonFormatFor(toolName).marshal(result)
 		if err != nil {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
@@ -272,5 +519,26 @@ type ToolHandler interface {
 	Handle(ctx context.Context) (interface{}, error)
 }
 
-// ToolInfo is uses as the type of dummy field to annotate the tool itself with struct tags.
+// ToolInfo is the type of a dummy field whose struct tags annotate the tool itself.
+// Besides name, title and description, tools may set:
+//   - group:"tmux" so clients can organize large tool lists
+//   - cache_ttl:"2s" on readonly tools to reuse the results of identical calls
+//   - json_format:"compact,omitempty,sortkeys" on tools returning structs, to
+//     override the format set with SetDefaultJSONFormat
+//   - max_concurrent:"1" on tools that must not run concurrently with themselves;
+//     further calls wait their turn
+//
+// Parameter fields may set:
+//   - default:"value" to start at that value when the constructor leaves them zero,
+//     so a default is declared once and advertised in the schema
+//   - deprecated_names:"old1,old2" to keep accepting their previous names
+//   - fromenv:"VAR" to fall back to an environment variable when omitted
+//   - sensitive:"true" to keep their value out of logs
+//   - required_if:"other=value" or required_if:"other" to be required only when
+//     another parameter has that value or is set at all
+//   - enum:"a,b,c" on strings and numbers to restrict them to those values
+//   - min and max on numbers, minLength and maxLength on strings
+//
+// Every tool accepts the reserved _dry_run argument (see DryRunArgument) to check its
+// arguments without running. Text results longer than SetMaxResultSize allows are truncated.
 type ToolInfo struct{}
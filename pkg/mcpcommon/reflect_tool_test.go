@@ -3,6 +3,8 @@ package mcpcommon
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
@@ -176,12 +178,10 @@ func TestReflectToolWithMissingRequiredParameter(t *testing.T) {
 		},
 	}
 
-	// Execute the handler - this should work because the reflection system
-	// handles missing parameters by using defaults or zero values
+	// Execute the handler - the tool is not run and the caller is told what is missing
 	ctx := context.Background()
 	result, err := serverTool.Handler(ctx, request)
 
-	// The handler should still execute (reflection handles missing params)
 	if err != nil {
 		t.Fatalf("Handler execution failed: %v", err)
 	}
@@ -189,6 +189,71 @@ func TestReflectToolWithMissingRequiredParameter(t *testing.T) {
 	if result == nil {
 		t.Fatal("Expected result, got nil")
 	}
+
+	if category := errorMeta(t, result)["category"]; category != string(InvalidArgument) {
+		t.Errorf("Expected InvalidArgument category, got %v", category)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "missing required parameter required_string") {
+		t.Errorf("Expected the missing parameter to be named, got %q", text)
+	}
+}
+
+type TestToolWithJSONRequired struct {
+	ToolInfo `name:"json_required_tool" description:"A test tool requiring a parameter through its json tag"`
+
+	Name string `json:"name,required" description:"Name to greet"`
+}
+
+func (t *TestToolWithJSONRequired) Handle(ctx context.Context) (interface{}, error) {
+	return "hello " + t.Name, nil
+}
+
+func TestReflectToolWithJSONRequiredParameter(t *testing.T) {
+	serverTool := ReflectTool(func() *TestToolWithJSONRequired { return &TestToolWithJSONRequired{} })
+
+	if !reflect.DeepEqual(serverTool.Tool.InputSchema.Required, []string{"name"}) {
+		t.Errorf("Expected name to be required in the schema, got %v", serverTool.Tool.InputSchema.Required)
+	}
+	if text := callText(t, serverTool.Handler, map[string]interface{}{"name": "bob"}); text != "hello bob" {
+		t.Errorf("Expected the tool to run, got %q", text)
+	}
+	if text := callText(t, serverTool.Handler, map[string]interface{}{"name": nil}); !strings.Contains(text, "missing required parameter name") {
+		t.Errorf("Expected null to count as missing, got %q", text)
+	}
+}
+
+func TestReflectToolIntegerSchema(t *testing.T) {
+	serverTool := ReflectTool(newTestToolWithTags)
+	properties := serverTool.Tool.InputSchema.Properties
+
+	if typ := properties["required_number"].(map[string]any)["type"]; typ != "integer" {
+		t.Errorf("Expected int field to have type integer, got %v", typ)
+	}
+	if typ := properties["optional_number"].(map[string]any)["type"]; typ != "number" {
+		t.Errorf("Expected float64 field to have type number, got %v", typ)
+	}
+}
+
+func TestReflectToolRejectsFractionalInteger(t *testing.T) {
+	serverTool := ReflectTool(newTestToolWithTags)
+
+	text := callText(t, serverTool.Handler, map[string]interface{}{"required_string": "a", "required_number": 3.0})
+	if text != "test result" {
+		t.Errorf("Expected an integral float to be accepted, got %q", text)
+	}
+
+	result, err := serverTool.Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Arguments: map[string]interface{}{"required_string": "a", "required_number": 3.5}},
+	})
+	if err != nil {
+		t.Fatalf("Handler execution failed: %v", err)
+	}
+	if category := errorMeta(t, result)["category"]; category != string(InvalidArgument) {
+		t.Errorf("Expected InvalidArgument category, got %v", category)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "parameter required_number must be an integer, got 3.5") {
+		t.Errorf("Unexpected error: %q", text)
+	}
 }
 
 // Test tool with array parameter
@@ -232,6 +297,251 @@ func TestReflectToolWithArrayParameter(t *testing.T) {
 	}
 }
 
+// Test tool with arrays of numbers and booleans
+type TestToolWithTypedArrays struct {
+	ToolInfo `name:"typed_array_tool" description:"A test tool with typed array parameters"`
+
+	Ports   []int     `json:"ports" description:"Ports to probe"`
+	Weights []float64 `json:"weights" description:"Weight per port"`
+	Enabled []bool    `json:"enabled" description:"Whether each port is enabled"`
+}
+
+func (t *TestToolWithTypedArrays) Handle(ctx context.Context) (interface{}, error) {
+	return fmt.Sprintf("%v %v %v", t.Ports, t.Weights, t.Enabled), nil
+}
+
+func TestReflectToolWithTypedArrayParameters(t *testing.T) {
+	serverTool := ReflectTool(func() *TestToolWithTypedArrays {
+		return &TestToolWithTypedArrays{}
+	})
+
+	properties := serverTool.Tool.InputSchema.Properties
+	for name, itemType := range map[string]string{"ports": "integer", "weights": "number", "enabled": "boolean"} {
+		property := properties[name].(map[string]any)
+		if property["type"] != "array" {
+			t.Errorf("Expected %s to be an array, got %v", name, property["type"])
+		}
+		if items := property["items"].(map[string]any); items["type"] != itemType {
+			t.Errorf("Expected %s items to be %s, got %v", name, itemType, items["type"])
+		}
+	}
+
+	text := callText(t, serverTool.Handler, map[string]interface{}{
+		"ports":   []interface{}{80.0, 443.0},
+		"weights": []interface{}{0.25, 0.75},
+		"enabled": []interface{}{true, false},
+	})
+	if text != "[80 443] [0.25 0.75] [true false]" {
+		t.Errorf("Expected the arrays to be populated, got %q", text)
+	}
+
+	text = callText(t, serverTool.Handler, map[string]interface{}{"ports": []interface{}{80.0, 80.5}})
+	if !strings.Contains(text, "parameter ports must be an integer, got 80.5") {
+		t.Errorf("Expected fractional port to be rejected, got %q", text)
+	}
+}
+
+// Test tool with map parameters
+type TestToolWithMap struct {
+	ToolInfo `name:"map_tool" description:"A test tool with map parameters"`
+
+	Labels  map[string]string      `json:"labels" description:"Labels to apply"`
+	Options map[string]interface{} `json:"options" description:"Arbitrary options"`
+}
+
+func (t *TestToolWithMap) Handle(ctx context.Context) (interface{}, error) {
+	keys := make([]string, 0, len(t.Labels))
+	for key := range t.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var labels []string
+	for _, key := range keys {
+		labels = append(labels, key+"="+t.Labels[key])
+	}
+	return fmt.Sprintf("labels: %s; retries: %v; verbose: %v", strings.Join(labels, ","), t.Options["retries"], t.Options["verbose"]), nil
+}
+
+func TestReflectToolWithMapParameter(t *testing.T) {
+	serverTool := ReflectTool(func() *TestToolWithMap {
+		return &TestToolWithMap{}
+	})
+
+	schema := serverTool.Tool.InputSchema
+	labels, ok := schema.Properties["labels"].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected labels property to be a schema object, got %T", schema.Properties["labels"])
+	}
+	if labels["type"] != "object" {
+		t.Errorf("Expected labels to be an object, got %v", labels["type"])
+	}
+	if !reflect.DeepEqual(labels["additionalProperties"], map[string]any{"type": "string"}) {
+		t.Errorf("Expected labels to allow any string-valued key, got %v", labels["additionalProperties"])
+	}
+	if _, exists := labels["properties"]; exists {
+		t.Error("Expected labels not to declare fixed properties")
+	}
+
+	options, ok := schema.Properties["options"].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected options property to be a schema object, got %T", schema.Properties["options"])
+	}
+	if options["type"] != "object" || options["additionalProperties"] != true {
+		t.Errorf("Expected options to be an object allowing any value, got %v", options)
+	}
+
+	result, err := serverTool.Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "map_tool",
+			Arguments: map[string]interface{}{
+				"labels":  map[string]interface{}{"team": "infra", "env": "prod"},
+				"options": map[string]interface{}{"retries": 3, "verbose": true},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Handler execution failed: %v", err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if text != "labels: env=prod,team=infra; retries: 3; verbose: true" {
+		t.Errorf("Unexpected result: %s", text)
+	}
+
+	_, err = serverTool.Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "map_tool",
+			Arguments: map[string]interface{}{"labels": map[string]interface{}{"count": 3}},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "labels") {
+		t.Errorf("Expected an error naming labels for a non-string label value, got %v", err)
+	}
+}
+
+func TestReflectToolWithUnsupportedMapParameter(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected panic for a map with non-string keys")
+		}
+	}()
+	type badMapTool struct {
+		ToolInfo `name:"bad_map_tool" description:"A test tool with an int-keyed map"`
+		Counts   map[int]string `json:"counts" description:"Counts"`
+	}
+	parseToolProperties(reflect.TypeOf(badMapTool{}))
+}
+
+// Test tool with a struct parameter nested two levels deep
+type TestToolWithNestedStruct struct {
+	ToolInfo `name:"nested_tool" description:"A test tool with nested struct parameters"`
+
+	Server *TestServerConfig `json:"server" mcp:"required" description:"Server to connect to"`
+}
+
+type TestServerConfig struct {
+	Host string         `json:"host" mcp:"required" description:"Host name"`
+	Port int            `json:"port" description:"Port number" default:"8080"`
+	TLS  *TestTLSConfig `json:"tls" description:"TLS settings"`
+}
+
+type TestTLSConfig struct {
+	CertFile string `json:"cert_file" mcp:"required" description:"Certificate file"`
+	Verify   bool   `json:"verify" description:"Verify the peer" default:"true"`
+}
+
+func (t *TestToolWithNestedStruct) Handle(ctx context.Context) (interface{}, error) {
+	return fmt.Sprintf("%s:%d cert=%s verify=%v", t.Server.Host, t.Server.Port, t.Server.TLS.CertFile, t.Server.TLS.Verify), nil
+}
+
+func TestReflectToolWithNestedStructParameter(t *testing.T) {
+	serverTool := ReflectTool(func() *TestToolWithNestedStruct {
+		return &TestToolWithNestedStruct{}
+	})
+
+	expected := map[string]any{
+		"type":        "object",
+		"description": "Server to connect to",
+		"required":    []string{"host"},
+		"properties": map[string]any{
+			"host": map[string]any{"type": "string", "description": "Host name"},
+			"port": map[string]any{"type": "integer", "description": "Port number", "default": 8080.0},
+			"tls": map[string]any{
+				"type":        "object",
+				"description": "TLS settings",
+				"required":    []string{"cert_file"},
+				"properties": map[string]any{
+					"cert_file": map[string]any{"type": "string", "description": "Certificate file"},
+					"verify":    map[string]any{"type": "boolean", "description": "Verify the peer", "default": true},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(serverTool.Tool.InputSchema.Properties["server"], expected) {
+		t.Errorf("Unexpected schema for server:\n got: %#v\nwant: %#v", serverTool.Tool.InputSchema.Properties["server"], expected)
+	}
+	if !reflect.DeepEqual(serverTool.Tool.InputSchema.Required, []string{"server"}) {
+		t.Errorf("Expected server to be required, got %v", serverTool.Tool.InputSchema.Required)
+	}
+
+	result, err := serverTool.Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "nested_tool",
+			Arguments: map[string]interface{}{
+				"server": map[string]interface{}{
+					"host": "example.com",
+					"port": 443,
+					"tls":  map[string]interface{}{"cert_file": "/etc/cert.pem", "verify": true},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Handler execution failed: %v", err)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; text != "example.com:443 cert=/etc/cert.pem verify=true" {
+		t.Errorf("Unexpected result: %s", text)
+	}
+}
+
+type TestRecursiveNode struct {
+	Name  string             `json:"name" description:"Node name"`
+	Child *TestRecursiveNode `json:"child" description:"Child node"`
+}
+
+func TestReflectToolWithRecursiveStructParameter(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "RegisterStructSchema") {
+			t.Errorf("Expected panic pointing at RegisterStructSchema, got %v", r)
+		}
+	}()
+	type recursiveTool struct {
+		ToolInfo `name:"recursive_tool" description:"A test tool with a recursive parameter"`
+		Root     *TestRecursiveNode `json:"root" description:"Root node"`
+	}
+	parseToolProperties(reflect.TypeOf(recursiveTool{}))
+}
+
+func TestReflectToolRegisteredStructSchemaOverridesReflection(t *testing.T) {
+	type TestOverriddenConfig struct {
+		Value string `json:"value" description:"Reflected value"`
+	}
+	type overrideTool struct {
+		ToolInfo `name:"override_tool" description:"A test tool with a registered struct schema"`
+		Config   *TestOverriddenConfig `json:"config" description:"Config"`
+	}
+	RegisterStructSchema("TestOverriddenConfig", `{"properties": {"custom": {"type": "string"}}}`)
+
+	var tool mcp.Tool
+	tool.InputSchema.Properties = map[string]any{}
+	for _, option := range parseToolProperties(reflect.TypeOf(overrideTool{})) {
+		option(&tool)
+	}
+	properties := tool.InputSchema.Properties["config"].(map[string]any)["properties"].(map[string]any)
+	if _, ok := properties["custom"]; !ok {
+		t.Errorf("Expected the registered schema to be used, got %v", properties)
+	}
+}
+
 // Test tool with invalid description containing "default:"
 type TestToolWithInvalidDescription struct {
 	ToolInfo `name:"invalid_tool" description:"A test tool with invalid description"`
@@ -265,3 +575,26 @@ func TestReflectToolWithInvalidDescription(t *testing.T) {
 		return &TestToolWithInvalidDescription{}
 	})
 }
+
+type TestGroupedTool struct {
+	ToolInfo `name:"grouped_tool" group:"testing" description:"A tool declared in a group"`
+}
+
+func (t *TestGroupedTool) Handle(ctx context.Context) (interface{}, error) {
+	return "grouped", nil
+}
+
+func TestReflectToolWithGroup(t *testing.T) {
+	info := parseToolInfo(reflect.TypeOf(TestGroupedTool{}))
+	if info.group != "testing" {
+		t.Errorf("Expected group 'testing', got '%s'", info.group)
+	}
+
+	ReflectTool(func() *TestGroupedTool { return &TestGroupedTool{} })
+	if group := ToolGroup("grouped_tool"); group != "testing" {
+		t.Errorf("Expected ToolGroup to return 'testing', got '%s'", group)
+	}
+	if group := ToolGroup("test_tool"); group != "" {
+		t.Errorf("Expected no group for ungrouped tool, got '%s'", group)
+	}
+}
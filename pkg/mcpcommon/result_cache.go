@@ -0,0 +1,89 @@
+package mcpcommon
+
+import (
+	"encoding/json"
+	"github.com/mark3labs/mcp-go/mcp"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// NoCacheArgument is a reserved argument that, when true, bypasses the result cache
+// of a cached tool for a single call. The fresh result replaces the cached one.
+const NoCacheArgument = "_no_cache"
+
+type resultCacheEntry struct {
+	result  *mcp.CallToolResult
+	expires time.Time
+}
+
+// resultCache holds recent results of a readonly tool keyed by tool name and arguments.
+type resultCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]resultCacheEntry
+}
+
+func newResultCache(ttl time.Duration) *resultCache {
+	return &resultCache{
+		ttl:     ttl,
+		entries: make(map[string]resultCacheEntry),
+	}
+}
+
+// key returns the cache key for a call and whether the caller asked to bypass the cache.
+func (c *resultCache) key(toolName string, arguments map[string]any) (string, bool) {
+	bypass, _ := arguments[NoCacheArgument].(bool)
+
+	args := make(map[string]any, len(arguments))
+	for k, v := range arguments {
+		if k != NoCacheArgument {
+			args[k] = v
+		}
+	}
+
+	// json.Marshal sorts map keys, so identical arguments always produce the same key
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", true
+	}
+	return toolName + ":" + string(data), bypass
+}
+
+func (c *resultCache) get(key string) (*mcp.CallToolResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return copyResult(entry.result), true
+}
+
+func (c *resultCache) put(key string, result *mcp.CallToolResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = resultCacheEntry{result: copyResult(result), expires: now.Add(c.ttl)}
+}
+
+// copyResult copies result down to its content list and metadata, so callers
+// changing a result they got from the cache do not change it for later callers.
+func copyResult(result *mcp.CallToolResult) *mcp.CallToolResult {
+	copied := *result
+	copied.Content = slices.Clone(result.Content)
+	copied.Meta = maps.Clone(result.Meta)
+	return &copied
+}
@@ -0,0 +1,143 @@
+package mcpcommon
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+var cachedToolCalls atomic.Int32
+
+type TestCachedTool struct {
+	ToolInfo `name:"cached_tool" description:"A readonly tool with a result cache" readonly:"true" cache_ttl:"1m"`
+
+	Value string `json:"value" description:"Value to echo"`
+}
+
+func (t *TestCachedTool) Handle(ctx context.Context) (interface{}, error) {
+	n := cachedToolCalls.Add(1)
+	return fmt.Sprintf("%s #%d", t.Value, n), nil
+}
+
+var shortCacheToolCalls atomic.Int32
+
+type TestShortCacheTool struct {
+	ToolInfo `name:"short_cache_tool" description:"A readonly tool with a short result cache" readonly:"true" cache_ttl:"50ms"`
+}
+
+func (t *TestShortCacheTool) Handle(ctx context.Context) (interface{}, error) {
+	return fmt.Sprintf("call #%d", shortCacheToolCalls.Add(1)), nil
+}
+
+func callText(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), arguments map[string]interface{}) string {
+	result, err := handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Arguments: arguments},
+	})
+	if err != nil {
+		t.Fatalf("Handler execution failed: %v", err)
+	}
+	return result.Content[0].(mcp.TextContent).Text
+}
+
+func TestReflectToolResultCache(t *testing.T) {
+	serverTool := ReflectTool(func() *TestCachedTool {
+		return &TestCachedTool{}
+	})
+
+	first := callText(t, serverTool.Handler, map[string]interface{}{"value": "a"})
+	second := callText(t, serverTool.Handler, map[string]interface{}{"value": "a"})
+
+	if first != second {
+		t.Errorf("Expected cached result %q, got %q", first, second)
+	}
+	if calls := cachedToolCalls.Load(); calls != 1 {
+		t.Errorf("Expected tool to execute once, executed %d times", calls)
+	}
+
+	// Different arguments are cached separately
+	other := callText(t, serverTool.Handler, map[string]interface{}{"value": "b"})
+	if other == first {
+		t.Errorf("Expected different arguments to re-execute, got cached %q", other)
+	}
+
+	// The bypass flag forces execution and refreshes the cache
+	bypassed := callText(t, serverTool.Handler, map[string]interface{}{"value": "a", NoCacheArgument: true})
+	if bypassed == first {
+		t.Errorf("Expected %s to bypass the cache, got %q", NoCacheArgument, bypassed)
+	}
+	refreshed := callText(t, serverTool.Handler, map[string]interface{}{"value": "a"})
+	if refreshed != bypassed {
+		t.Errorf("Expected refreshed cache entry %q, got %q", bypassed, refreshed)
+	}
+}
+
+func TestReflectToolResultCacheReturnsCopies(t *testing.T) {
+	serverTool := ReflectTool(func() *TestCachedTool {
+		return &TestCachedTool{}
+	})
+	call := func() *mcp.CallToolResult {
+		result, err := serverTool.Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Arguments: map[string]interface{}{"value": "copy"}},
+		})
+		if err != nil {
+			t.Fatalf("Handler execution failed: %v", err)
+		}
+		return result
+	}
+
+	first := call()
+	want := first.Content[0].(mcp.TextContent).Text
+	first.Content[0] = mcp.NewTextContent("changed by the first caller")
+	first.IsError = true
+
+	second := call()
+	if second == first {
+		t.Fatal("Expected each cache hit to return its own result")
+	}
+	if got := second.Content[0].(mcp.TextContent).Text; got != want || second.IsError {
+		t.Errorf("Expected the cached result %q to be unaffected by the first caller, got %q (error: %v)", want, got, second.IsError)
+	}
+	third := call()
+	third.Content[0] = mcp.NewTextContent("changed by the third caller")
+	if got := call().Content[0].(mcp.TextContent).Text; got != want {
+		t.Errorf("Expected a cache hit changed by its caller not to change later hits, got %q", got)
+	}
+}
+
+func TestReflectToolResultCacheExpiry(t *testing.T) {
+	serverTool := ReflectTool(func() *TestShortCacheTool {
+		return &TestShortCacheTool{}
+	})
+
+	first := callText(t, serverTool.Handler, nil)
+	time.Sleep(100 * time.Millisecond)
+	second := callText(t, serverTool.Handler, nil)
+
+	if first == second {
+		t.Errorf("Expected result to be re-executed after TTL expiry, got %q twice", first)
+	}
+}
+
+type TestCachedDestructiveTool struct {
+	ToolInfo `name:"cached_destructive_tool" description:"Not allowed to cache" destructive:"true" cache_ttl:"1m"`
+}
+
+func (t *TestCachedDestructiveTool) Handle(ctx context.Context) (interface{}, error) {
+	return "should not be cached", nil
+}
+
+func TestReflectToolResultCacheRequiresReadonly(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected panic when cache_ttl is set on a non-readonly tool")
+		}
+	}()
+
+	ReflectTool(func() *TestCachedDestructiveTool {
+		return &TestCachedDestructiveTool{}
+	})
+}
@@ -0,0 +1,148 @@
+package mcpcommon
+
+import (
+	"fmt"
+	"github.com/mark3labs/mcp-go/mcp"
+	"os"
+	"sync"
+	"unicode/utf8"
+)
+
+// DefaultMaxResultSize is how many bytes of text a tool result may carry before
+// it is truncated.
+const DefaultMaxResultSize = 100_000
+
+var maxResultSize = DefaultMaxResultSize
+var maxResultSizeMu sync.RWMutex
+
+// SetMaxResultSize sets how many bytes of text a tool result may carry. Longer
+// results are cut off with a marker saying where the full text was saved.
+// Zero or less disables truncation.
+func SetMaxResultSize(bytes int) {
+	maxResultSizeMu.Lock()
+	defer maxResultSizeMu.Unlock()
+	maxResultSize = bytes
+}
+
+func getMaxResultSize() int {
+	maxResultSizeMu.RLock()
+	defer maxResultSizeMu.RUnlock()
+	return maxResultSize
+}
+
+// truncateResult shortens the text content of result to the maximum result size.
+// Each text block that has to be cut is saved in full to a temporary file, and a
+// marker pointing at that file replaces the cut off text. Other content is kept.
+func truncateResult(toolName string, result *mcp.CallToolResult) *mcp.CallToolResult {
+	limit := getMaxResultSize()
+	if limit <= 0 || result == nil {
+		return result
+	}
+
+	size := 0
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			size += len(text.Text)
+		}
+	}
+	if size <= limit {
+		return result
+	}
+
+	truncated := *result
+	truncated.Content = make([]mcp.Content, len(result.Content))
+	remaining := limit
+	for i, content := range result.Content {
+		text, ok := content.(mcp.TextContent)
+		if !ok || len(text.Text) <= remaining {
+			truncated.Content[i] = content
+			if ok {
+				remaining -= len(text.Text)
+			}
+			continue
+		}
+		full := text.Text
+		text.Text = truncateUTF8(full, remaining) + truncationNote(toolName, full, remaining)
+		truncated.Content[i] = text
+		remaining = 0
+	}
+	return &truncated
+}
+
+// truncateUTF8 returns at most n bytes of s without splitting a character.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func truncationNote(toolName, full string, shown int) string {
+	note := fmt.Sprintf("\n\n[TRUNCATED: %s returned %d bytes of text, only the first %d are shown.", toolName, len(full), shown)
+	path, err := saveFullResult(toolName, full)
+	if err != nil {
+		return note + fmt.Sprintf(" The full result could not be saved: %v]", err)
+	}
+	return note + fmt.Sprintf(" The full result was saved to %s]", path)
+}
+
+// maxSavedResults is how many full results are kept on disk. Saving another
+// removes the oldest.
+const maxSavedResults = 20
+
+// savedResults holds the full text of truncated results, in a directory of
+// this process's own.
+var savedResults struct {
+	mu    sync.Mutex
+	dir   string
+	paths []string
+}
+
+func saveFullResult(toolName, text string) (string, error) {
+	savedResults.mu.Lock()
+	defer savedResults.mu.Unlock()
+
+	if savedResults.dir == "" {
+		dir, err := os.MkdirTemp("", "mcp-results-*")
+		if err != nil {
+			return "", err
+		}
+		savedResults.dir = dir
+	}
+	file, err := os.CreateTemp(savedResults.dir, toolName+"-*.txt")
+	if err != nil {
+		return "", err
+	}
+	_, err = file.WriteString(text)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	savedResults.paths = append(savedResults.paths, file.Name())
+	if len(savedResults.paths) > maxSavedResults {
+		os.Remove(savedResults.paths[0])
+		savedResults.paths = savedResults.paths[1:]
+	}
+	return file.Name(), nil
+}
+
+// RemoveSavedResults deletes the full results saved for truncated tool results.
+// Servers call it when they exit.
+func RemoveSavedResults() error {
+	savedResults.mu.Lock()
+	defer savedResults.mu.Unlock()
+
+	if savedResults.dir == "" {
+		return nil
+	}
+	err := os.RemoveAll(savedResults.dir)
+	savedResults.dir, savedResults.paths = "", nil
+	return err
+}
@@ -0,0 +1,95 @@
+package mcpcommon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type TestGiantTool struct {
+	ToolInfo `name:"giant_tool" description:"Returns a very large result"`
+}
+
+func (t *TestGiantTool) Handle(ctx context.Context) (interface{}, error) {
+	return strings.Repeat("0123456789", 50_000), nil
+}
+
+func TestReflectToolTruncatesGiantResult(t *testing.T) {
+	SetMaxResultSize(1000)
+	defer SetMaxResultSize(DefaultMaxResultSize)
+
+	serverTool := ReflectTool(func() *TestGiantTool {
+		return &TestGiantTool{}
+	})
+	text := callText(t, serverTool.Handler, nil)
+
+	if !strings.HasPrefix(text, strings.Repeat("0123456789", 100)+"\n\n[TRUNCATED: giant_tool returned 500000 bytes of text, only the first 1000 are shown.") {
+		t.Fatalf("Expected the first 1000 bytes followed by the truncation marker, got %q", text[:min(len(text), 1200)])
+	}
+
+	match := regexp.MustCompile(`The full result was saved to (\S+)\]$`).FindStringSubmatch(text)
+	if match == nil {
+		t.Fatalf("Expected a note saying where the full result was saved, got %q", text[1000:])
+	}
+	defer func() { _ = RemoveSavedResults() }()
+	full, err := os.ReadFile(match[1])
+	if err != nil {
+		t.Fatalf("Failed to read the saved result: %v", err)
+	}
+	if len(full) != 500_000 {
+		t.Errorf("Expected the saved result to hold all 500000 bytes, got %d", len(full))
+	}
+}
+
+func TestReflectToolKeepsSmallResult(t *testing.T) {
+	serverTool := ReflectTool(func() *TestEchoTool {
+		return &TestEchoTool{}
+	})
+	if text := callText(t, serverTool.Handler, map[string]interface{}{"message": "hello"}); text != "hello" {
+		t.Errorf("Expected small result to pass through, got %q", text)
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	if got := truncateUTF8("héllo", 2); got != "h" {
+		t.Errorf("Expected truncation not to split é, got %q", got)
+	}
+	if got := truncateUTF8("héllo", 3); got != "hé" {
+		t.Errorf("Expected hé, got %q", got)
+	}
+}
+
+func TestSaveFullResultRotatesAndCleansUp(t *testing.T) {
+	defer func() { _ = RemoveSavedResults() }()
+
+	var paths []string
+	for i := 0; i <= maxSavedResults; i++ {
+		path, err := saveFullResult("giant_tool", "result")
+		if err != nil {
+			t.Fatalf("Failed to save result %d: %v", i, err)
+		}
+		paths = append(paths, path)
+	}
+	dir := filepath.Dir(paths[0])
+	for _, path := range paths {
+		if filepath.Dir(path) != dir {
+			t.Fatalf("Expected every result in %s, got %s", dir, path)
+		}
+	}
+	if _, err := os.Stat(paths[0]); !os.IsNotExist(err) {
+		t.Errorf("Expected the oldest result to be removed once %d were saved, got %v", maxSavedResults+1, err)
+	}
+	if _, err := os.Stat(paths[maxSavedResults]); err != nil {
+		t.Errorf("Expected the newest result to be kept, got %v", err)
+	}
+
+	if err := RemoveSavedResults(); err != nil {
+		t.Fatalf("Failed to remove saved results: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected the results directory to be removed, got %v", err)
+	}
+}
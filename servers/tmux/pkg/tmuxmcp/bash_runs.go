@@ -0,0 +1,252 @@
+package tmuxmcp
+
+import (
+	"context"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bashRun records where a command started by BashTool keeps its output so that
+// later calls can find it again by session name.
+type bashRun struct {
+	SessionName string
+	TmpPath     string
+	TimeLimit   float64 // seconds the command may run inside the session, 0 if unlimited
+
+	watchOnce sync.Once
+	done      chan struct{} // closed once the command has exited or its session is gone
+
+	mu         sync.Mutex
+	outputRead int64     // bytes of the output file read by refreshOutput
+	partial    string    // output after the last newline read so far
+	lastLine   string    // last non-empty line of the output read so far
+	finishedAt time.Time // when the command was first seen finished
+}
+
+var bashRuns = make(map[string]*bashRun)
+var bashRunsMu sync.Mutex
+
+// bashRunTTL is how long a finished command is remembered, and listed by
+// tmux_status, after it was first seen finished.
+const bashRunTTL = 10 * time.Minute
+
+// pruneBashRuns forgets commands that finished more than bashRunTTL ago.
+// Their output can still be fetched by temp path.
+func pruneBashRuns(ctx context.Context) {
+	bashRunsMu.Lock()
+	runs := make(map[string]*bashRun, len(bashRuns))
+	for name, run := range bashRuns {
+		runs[name] = run
+	}
+	bashRunsMu.Unlock()
+
+	for name, run := range runs {
+		if !run.finishedBefore(ctx, time.Now().Add(-bashRunTTL)) {
+			continue
+		}
+		bashRunsMu.Lock()
+		if bashRuns[name] == run {
+			delete(bashRuns, name)
+		}
+		bashRunsMu.Unlock()
+	}
+}
+
+// finishedBefore reports whether the command was already finished at cutoff,
+// noting when it is first seen finished.
+func (r *bashRun) finishedBefore(ctx context.Context, cutoff time.Time) bool {
+	r.mu.Lock()
+	finishedAt := r.finishedAt
+	r.mu.Unlock()
+	if finishedAt.IsZero() {
+		if !r.finished(ctx) {
+			return false
+		}
+		finishedAt = time.Now()
+		r.mu.Lock()
+		if r.finishedAt.IsZero() {
+			r.finishedAt = finishedAt
+		}
+		r.mu.Unlock()
+	}
+	return finishedAt.Before(cutoff)
+}
+
+// finished reports whether the command has exited or its session is gone.
+func (r *bashRun) finished(ctx context.Context) bool {
+	select {
+	case <-r.done:
+		return true
+	default:
+	}
+	if _, err := os.Stat(r.exitFile()); err == nil {
+		return true
+	}
+	return !sessionExists(ctx, r.SessionName)
+}
+
+func registerBashRun(sessionName, tmpPath string) *bashRun {
+	run := &bashRun{
+		SessionName: sessionName,
+		TmpPath:     tmpPath,
+		done:        make(chan struct{}),
+	}
+	bashRunsMu.Lock()
+	defer bashRunsMu.Unlock()
+	bashRuns[sessionName] = run
+	return run
+}
+
+func findBashRun(sessionName string) (*bashRun, bool) {
+	bashRunsMu.Lock()
+	defer bashRunsMu.Unlock()
+	run, ok := bashRuns[sessionName]
+	return run, ok
+}
+
+// locateBashRun finds the command named by a session name or by its temp path
+// prefix, as printed when bash times out. Commands this process started are found
+// in memory; anything else, such as a command started before the server restarted,
+// is found from its session's start command or from the files it left in /tmp.
+func locateBashRun(ctx context.Context, target string) (*bashRun, error) {
+	if run, ok := findBashRun(target); ok {
+		return run, nil
+	}
+
+	if !filepath.IsAbs(target) {
+		tmpPath, err := sessionScriptPath(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		return registerBashRun(target, tmpPath), nil
+	}
+
+	tmpPath := target
+	for _, suffix := range []string{".output", ".exit", ".pid", ".script"} {
+		tmpPath = strings.TrimSuffix(tmpPath, suffix)
+	}
+	if _, err := os.Stat(tmpPath + ".script"); err != nil {
+		return nil, mcpcommon.Errorf(mcpcommon.NotFound, "no bash command found at %s", tmpPath)
+	}
+	if sessionName := sessionRunningScript(ctx, tmpPath+".script"); sessionName != "" {
+		return registerBashRun(sessionName, tmpPath), nil
+	}
+	// The session is gone, so there is nothing left to wait for
+	run := &bashRun{TmpPath: tmpPath, done: make(chan struct{})}
+	run.watchOnce.Do(func() { close(run.done) })
+	return run, nil
+}
+
+// sessionScriptPath returns the temp path prefix of the bash command running in a session.
+func sessionScriptPath(ctx context.Context, sessionName string) (string, error) {
+	output, err := runTmuxCommand(ctx, "display-message", "-p", "-t", sessionName, "#{pane_start_command}")
+	if err != nil {
+		return "", mcpcommon.Errorf(mcpcommon.NotFound, "no session %s; if it has exited, pass the temp path bash printed instead: %v", sessionName, err)
+	}
+	script := scriptFromStartCommand(output)
+	if script == "" {
+		return "", mcpcommon.Errorf(mcpcommon.NotFound, "session %s was not started by bash", sessionName)
+	}
+	return strings.TrimSuffix(script, ".script"), nil
+}
+
+// sessionRunningScript returns the session whose pane runs script, or "" if there is none.
+func sessionRunningScript(ctx context.Context, script string) string {
+	output, err := runTmuxCommand(ctx, "list-panes", "-a", "-F", "#{session_name}\t#{pane_start_command}")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(output, "\n") {
+		sessionName, command, _ := strings.Cut(line, "\t")
+		if scriptFromStartCommand(command) == script {
+			return sessionName
+		}
+	}
+	return ""
+}
+
+// scriptFromStartCommand extracts the script path from a pane start command such as
+// "bash /tmp/tmux-bash-x-123.script", which tmux quotes if it has special characters.
+func scriptFromStartCommand(command string) string {
+	fields := strings.Fields(strings.TrimSpace(command))
+	if len(fields) == 0 {
+		return ""
+	}
+	script := strings.Trim(fields[len(fields)-1], `"'`)
+	if !strings.HasSuffix(script, ".script") {
+		return ""
+	}
+	return script
+}
+
+func (r *bashRun) exitFile() string   { return r.TmpPath + ".exit" }
+func (r *bashRun) outputFile() string { return r.TmpPath + ".output" }
+func (r *bashRun) pidFile() string    { return r.TmpPath + ".pid" }
+
+// refreshOutput reads what the command has written since the last refresh, so
+// its last line is known without rereading the whole output file.
+func (r *bashRun) refreshOutput() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, err := os.Open(r.outputFile())
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if _, err := f.Seek(r.outputRead, io.SeekStart); err != nil {
+		return
+	}
+	data, err := io.ReadAll(f)
+	if err != nil || len(data) == 0 {
+		return
+	}
+	r.outputRead += int64(len(data))
+
+	// Lines may be split across refreshes, so carry the unfinished one over
+	text := r.partial + string(data)
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		r.partial = text[i+1:]
+	} else {
+		r.partial = text
+	}
+	if line := lastNonEmptyLine(text); line != "" {
+		r.lastLine = line
+	}
+}
+
+// outputLastLine returns the last non-empty line of the output as of the last refresh.
+func (r *bashRun) outputLastLine() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastLine
+}
+
+// watch starts a background goroutine (at most once per run) that follows the
+// command until it writes its exit file or its session disappears, refreshing
+// its output as it goes, then closes done. The goroutine is tied to the session
+// rather than to any single tool call.
+func (r *bashRun) watch() {
+	r.watchOnce.Do(func() {
+		go func() {
+			defer close(r.done)
+			// Pick up everything written before the command finished
+			defer r.refreshOutput()
+			ticker := time.NewTicker(checkInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				r.refreshOutput()
+				if _, err := os.Stat(r.exitFile()); err == nil {
+					return
+				}
+				if !sessionExists(context.Background(), r.SessionName) {
+					return
+				}
+			}
+		}()
+	})
+}
@@ -0,0 +1,53 @@
+package tmuxmcp
+
+import (
+	"slices"
+	"sync"
+)
+
+// captureHistorySize is how many recent captures are remembered for tmux_capture_diff.
+const captureHistorySize = 64
+
+// captureHistory remembers the raw output of recent captures keyed by their
+// hash, evicting the least recently used, so a later capture can be diffed
+// against an earlier one given only its hash.
+var captureHistory = struct {
+	mu      sync.Mutex
+	order   []string // hashes, least recently used first
+	outputs map[string]string
+}{
+	outputs: make(map[string]string),
+}
+
+// rememberCapture records the output a hash was calculated over.
+func rememberCapture(hash, output string) {
+	captureHistory.mu.Lock()
+	defer captureHistory.mu.Unlock()
+
+	if _, ok := captureHistory.outputs[hash]; !ok && len(captureHistory.order) == captureHistorySize {
+		delete(captureHistory.outputs, captureHistory.order[0])
+		captureHistory.order = captureHistory.order[1:]
+	}
+	captureHistory.outputs[hash] = output
+	touchCapture(hash)
+}
+
+// rememberedCapture returns the output of a recent capture with the given hash.
+func rememberedCapture(hash string) (string, bool) {
+	captureHistory.mu.Lock()
+	defer captureHistory.mu.Unlock()
+
+	output, ok := captureHistory.outputs[hash]
+	if ok {
+		touchCapture(hash)
+	}
+	return output, ok
+}
+
+// touchCapture marks hash as the most recently used; the caller holds the lock.
+func touchCapture(hash string) {
+	if i := slices.Index(captureHistory.order, hash); i >= 0 {
+		captureHistory.order = slices.Delete(captureHistory.order, i, i+1)
+	}
+	captureHistory.order = append(captureHistory.order, hash)
+}
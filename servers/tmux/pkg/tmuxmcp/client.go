@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
 	"os/exec"
 	"path/filepath"
 	"regexp"
@@ -24,6 +25,9 @@ type captureResult struct {
 	SessionName string
 	Output      string
 	Hash        string
+	// StillChanging is set by waitForStability when it gave up before the
+	// output settled
+	StillChanging bool
 }
 
 type cursorResult struct {
@@ -35,27 +39,21 @@ type cursorResult struct {
 	Hash        string
 }
 
-// Constants
-const (
-	expectWaitTimeout  = 60
-	noOutputTimeout    = 20
-	checkInterval      = 200 * time.Millisecond
-	stabilityThreshold = 500 * time.Millisecond
-)
-
 func capture(ctx context.Context, opts captureOptions) (*captureResult, error) {
 	sessionName, err := resolveSession(ctx, opts.Prefix, "")
 	if err != nil {
 		return nil, err
 	}
 
-	output, err := runTmuxCommand(ctx, "capture-pane", "-t", sessionName, "-p")
+	pane, err := capturePane(ctx, sessionName, captureRegion{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to capture session %s: %w", sessionName, err)
 	}
+	output := pane.Output
 
 	formatted := formatOutput(output)
-	hash := calculateHash(output)
+	hash := calculateHash(pane.Hashed)
+	rememberCapture(hash, output)
 
 	return &captureResult{
 		SessionName: sessionName,
@@ -71,10 +69,11 @@ func captureWithCursor(ctx context.Context, opts captureOptions) (*cursorResult,
 	}
 
 	// Capture output
-	output, err := runTmuxCommand(ctx, "capture-pane", "-t", sessionName, "-p")
+	pane, err := capturePane(ctx, sessionName, captureRegion{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to capture session %s: %w", sessionName, err)
 	}
+	output := pane.Output
 
 	// Get cursor position
 	cursorOutput, err := runTmuxCommand(ctx, "display-message", "-t", sessionName, "-p", "#{cursor_y}:#{cursor_x}")
@@ -107,7 +106,8 @@ func captureWithCursor(ctx context.Context, opts captureOptions) (*cursorResult,
 	}
 
 	formatted := formatOutput(output)
-	hash := calculateHash(output)
+	hash := calculateHash(pane.Hashed)
+	rememberCapture(hash, output)
 
 	return &cursorResult{
 		SessionName: sessionName,
@@ -157,7 +157,43 @@ func detectPrefix() string {
 	return sanitized
 }
 
+// paneTarget builds a session:window.pane target; an empty window or pane
+// means the current one.
+func paneTarget(sessionName, window, pane string) string {
+	target := sessionName + ":" + window
+	if pane != "" {
+		target += "." + pane
+	}
+	return target
+}
+
+// resolvePaneTarget checks that the window and pane of a session:window.pane
+// target exist.
+func resolvePaneTarget(ctx context.Context, target string) (string, error) {
+	sessionName, _, _ := strings.Cut(target, ":")
+	if _, err := resolveSession(ctx, "", sessionName); err != nil {
+		return "", err
+	}
+	if _, err := runTmuxCommand(ctx, "list-panes", "-t", target); err != nil {
+		if strings.Contains(err.Error(), "can't find") {
+			return "", mcpcommon.Errorf(mcpcommon.NotFound, "target '%s' not found: %v", target, err)
+		}
+		return "", fmt.Errorf("failed to list panes of %s: %w", target, err)
+	}
+	return target, nil
+}
+
+// resolveSession finds the session named by session, or the only session
+// starting with prefix. A session:window.pane target passed as either is
+// checked to exist and returned as is.
 func resolveSession(ctx context.Context, prefix, session string) (string, error) {
+	if strings.Contains(session, ":") {
+		return resolvePaneTarget(ctx, session)
+	}
+	if session == "" && strings.Contains(prefix, ":") {
+		return resolvePaneTarget(ctx, prefix)
+	}
+
 	if session != "" {
 		sessions, err := list(ctx, "")
 		if err != nil {
@@ -168,7 +204,7 @@ func resolveSession(ctx context.Context, prefix, session string) (string, error)
 				return session, nil
 			}
 		}
-		return "", fmt.Errorf("session '%s' not found", session)
+		return "", mcpcommon.Errorf(mcpcommon.NotFound, "session '%s' not found", session)
 	}
 
 	if prefix == "" {
@@ -181,7 +217,7 @@ func resolveSession(ctx context.Context, prefix, session string) (string, error)
 	}
 
 	if len(sessions) == 0 {
-		return "", fmt.Errorf("no sessions found with prefix '%s'", prefix)
+		return "", mcpcommon.Errorf(mcpcommon.NotFound, "no sessions found with prefix '%s'", prefix)
 	}
 
 	if len(sessions) > 1 {
@@ -213,13 +249,17 @@ func killSession(ctx context.Context, sessionName string) error {
 }
 
 func formatOutput(output string) string {
-	lines := strings.Split(output, "\n")
+	return formatLines(strings.Split(output, "\n"), 1)
+}
+
+// formatLines numbers lines starting from first and compresses runs of empty lines.
+func formatLines(lines []string, first int) string {
 	var formatted []string
 	var emptyCount int
 
 	for i, line := range lines {
-		lineNum := i + 1
-		if strings.TrimSpace(line) == "" {
+		lineNum := first + i
+		if strings.TrimSpace(stripANSI(line)) == "" {
 			emptyCount++
 			if emptyCount == 1 {
 				formatted = append(formatted, fmt.Sprintf("[%d]: ", lineNum))
@@ -240,24 +280,118 @@ func formatOutput(output string) string {
 	return strings.Join(formatted, "\n")
 }
 
+// ansiEscape matches the SGR escape sequences capture-pane -e embeds in its output.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;:]*m`)
+
+// stripANSI removes color and attribute escape sequences from s.
+func stripANSI(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
+}
+
+// calculateHash returns the first hashLength hex digits of the SHA-256 of content.
+// The digest covers the content prefixed with its length; the prefix only removes
+// framing ambiguity, and truncated digests can still collide.
 func calculateHash(content string) string {
-	hash := sha256.Sum256([]byte(content))
-	return fmt.Sprintf("%x", hash)[:8]
+	return hashDigest(maskVolatileLines(content))[:hashLength]
+}
+
+func hashDigest(content string) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%d:%s", len(content), content)))
+	return fmt.Sprintf("%x", hash)
 }
 
+// captureRegion selects what part of a pane capture-pane returns; the zero
+// value is the visible screen.
+type captureRegion struct {
+	HistoryLines int  // lines of scrollback above the visible screen
+	Colors       bool // keep color and attribute escape sequences
+}
+
+// paneCapture is a capture of a pane. Output is what tmux returned and what callers
+// show; Hashed is the text hashes are calculated over, which with ignoreCursor set
+// has the cell under the cursor blanked so a blinking cursor does not change it.
+type paneCapture struct {
+	Output string
+	Hashed string
+}
+
+// capturePane captures the region of the target's pane.
+func capturePane(ctx context.Context, target string, region captureRegion) (paneCapture, error) {
+	args := []string{"capture-pane", "-t", target, "-p"}
+	if region.HistoryLines > 0 {
+		args = append(args, "-S", strconv.Itoa(-region.HistoryLines))
+	}
+	if region.Colors {
+		args = append(args, "-e")
+	}
+	if !ignoreCursor {
+		output, err := runTmuxCommand(ctx, args...)
+		return paneCapture{Output: output, Hashed: output}, err
+	}
+
+	// Ask for the cursor in the same invocation so it matches the capture
+	args = append(args, ";", "display-message", "-t", target, "-p", "#{cursor_y} #{cursor_x} #{pane_height}")
+	output, err := runTmuxCommand(ctx, args...)
+	if err != nil {
+		return paneCapture{}, err
+	}
+	output = strings.TrimSuffix(output, "\n")
+	cut := strings.LastIndex(output, "\n")
+	var cursorY, cursorX, height int
+	if _, err := fmt.Sscanf(output[cut+1:], "%d %d %d", &cursorY, &cursorX, &height); err != nil {
+		return paneCapture{}, fmt.Errorf("unexpected cursor position %q: %w", output[cut+1:], err)
+	}
+	output = output[:cut+1]
+	return paneCapture{Output: output, Hashed: blankCell(output, cursorY, cursorX, height)}, nil
+}
+
+// regionHash hashes a capture of region. Hashes of anything but the visible
+// screen carry a suffix describing the region (e.g. "-h500" for 500 lines of
+// history, "-c" for colors), so verifySessionHash can capture the same region
+// to check them. The capture is remembered for tmux_capture_diff.
+func regionHash(pane paneCapture, region captureRegion) string {
+	hash := calculateHash(pane.Hashed)
+	if region.HistoryLines > 0 {
+		hash += fmt.Sprintf("-h%d", region.HistoryLines)
+	}
+	if region.Colors {
+		hash += "-c"
+	}
+	rememberCapture(hash, pane.Output)
+	return hash
+}
+
+// hashRegion returns the region a hash from regionHash was calculated over.
+func hashRegion(hash string) captureRegion {
+	var region captureRegion
+	parts := strings.Split(hash, "-")
+	for _, part := range parts[1:] {
+		switch {
+		case part == "c":
+			region.Colors = true
+		case strings.HasPrefix(part, "h"):
+			region.HistoryLines, _ = strconv.Atoi(part[1:])
+		}
+	}
+	return region
+}
+
+// waitForStability waits until the output has not changed for stabilityThreshold.
+// If ctx is done first, the latest capture is returned with StillChanging set, so
+// panes that never settle (top, tail -f) still yield their output and hash.
 func waitForStability(ctx context.Context, sessionName string) (*captureResult, error) {
 	ticker := time.NewTicker(checkInterval)
 	defer ticker.Stop()
 
-	var lastOutput string
+	var last *captureResult
 	var lastChange time.Time = time.Now()
 
 	for {
 		select {
 		case <-ctx.Done():
-			result, _ := capture(ctx, captureOptions{Prefix: sessionName})
-			if result != nil {
-				return result, nil
+			if last != nil {
+				last.StillChanging = true
+				return last, nil
 			}
 			return nil, fmt.Errorf("context cancelled waiting for stability: %w", ctx.Err())
 
@@ -267,21 +401,56 @@ func waitForStability(ctx context.Context, sessionName string) (*captureResult,
 				continue
 			}
 
-			if result.Output != lastOutput {
-				lastOutput = result.Output
+			// Compare hashes so lines masked by volatileLines do not count as changes
+			if last == nil || result.Hash != last.Hash {
 				lastChange = time.Now()
 			} else if time.Since(lastChange) >= stabilityThreshold {
 				return result, nil
 			}
+			last = result
 		}
 	}
 }
 
-func waitForExpected(ctx context.Context, sessionName, expected string) (*captureResult, error) {
+// expectation is what waitForExpected looks for on the cursor line: a literal
+// substring, or a regular expression if Pattern is set.
+type expectation struct {
+	Text    string
+	Pattern *regexp.Regexp
+}
+
+// newExpectation returns an expectation for text, compiling it as a regular
+// expression if isRegex is set.
+func newExpectation(text string, isRegex bool) (expectation, error) {
+	if !isRegex {
+		return expectation{Text: text}, nil
+	}
+	pattern, err := regexp.Compile(text)
+	if err != nil {
+		return expectation{}, mcpcommon.Errorf(mcpcommon.InvalidArgument, "invalid contains pattern %q: %v", text, err)
+	}
+	return expectation{Text: text, Pattern: pattern}, nil
+}
+
+func (e expectation) matches(line string) bool {
+	if e.Pattern != nil {
+		return e.Pattern.MatchString(line)
+	}
+	return strings.Contains(line, e.Text)
+}
+
+func (e expectation) String() string {
+	if e.Pattern != nil {
+		return "/" + e.Text + "/"
+	}
+	return "'" + e.Text + "'"
+}
+
+func waitForExpected(ctx context.Context, sessionName string, expected expectation) (*captureResult, error) {
 	ticker := time.NewTicker(checkInterval)
 	defer ticker.Stop()
 
-	var lastOutput string
+	var lastHash string
 	var lastChange time.Time = time.Now()
 
 	for {
@@ -289,9 +458,9 @@ func waitForExpected(ctx context.Context, sessionName, expected string) (*captur
 		case <-ctx.Done():
 			result, _ := capture(ctx, captureOptions{Prefix: sessionName})
 			if result != nil {
-				return result, fmt.Errorf("context cancelled waiting for '%s' on cursor line: %w", expected, ctx.Err())
+				return result, fmt.Errorf("context cancelled waiting for %s on cursor line: %w", expected, ctx.Err())
 			}
-			return nil, fmt.Errorf("context cancelled waiting for '%s' on cursor line: %w", expected, ctx.Err())
+			return nil, fmt.Errorf("context cancelled waiting for %s on cursor line: %w", expected, ctx.Err())
 
 		case <-ticker.C:
 			cursorResult, err := captureWithCursor(ctx, captureOptions{Prefix: sessionName})
@@ -300,7 +469,7 @@ func waitForExpected(ctx context.Context, sessionName, expected string) (*captur
 			}
 
 			// Check if expected text is found on the cursor line only
-			if strings.Contains(cursorResult.CursorLine, expected) {
+			if expected.matches(cursorResult.CursorLine) {
 				// Convert cursorResult to captureResult for return
 				return &captureResult{
 					SessionName: cursorResult.SessionName,
@@ -309,15 +478,15 @@ func waitForExpected(ctx context.Context, sessionName, expected string) (*captur
 				}, nil
 			}
 
-			if cursorResult.Output != lastOutput {
-				lastOutput = cursorResult.Output
+			if cursorResult.Hash != lastHash {
+				lastHash = cursorResult.Hash
 				lastChange = time.Now()
-			} else if time.Since(lastChange) >= time.Duration(noOutputTimeout)*time.Second {
+			} else if time.Since(lastChange) >= noOutputTimeout {
 				return &captureResult{
 					SessionName: cursorResult.SessionName,
 					Output:      cursorResult.Output,
 					Hash:        cursorResult.Hash,
-				}, fmt.Errorf("no new output for %d seconds while waiting for '%s' on cursor line", noOutputTimeout, expected)
+				}, fmt.Errorf("no new output for %s while waiting for %s on cursor line", noOutputTimeout, expected)
 			}
 		}
 	}
@@ -0,0 +1,97 @@
+package tmuxmcp
+
+import (
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Timeouts and polling intervals. Each can be overridden in milliseconds by the
+// environment variable named next to it, for hosts where the defaults are too tight.
+var (
+	// defaultWaitTimeout bounds waiting for output when a tool call gives no max_wait.
+	defaultWaitTimeout = durationFromEnv("TMUXMCP_WAIT_TIMEOUT_MS", 10*time.Second)
+	// expectWaitTimeout bounds waiting for expected text when no max_wait is given.
+	expectWaitTimeout = durationFromEnv("TMUXMCP_EXPECT_TIMEOUT_MS", 60*time.Second)
+	// noOutputTimeout gives up waiting for expected text once the pane has been quiet this long.
+	noOutputTimeout = durationFromEnv("TMUXMCP_NO_OUTPUT_TIMEOUT_MS", 20*time.Second)
+	// checkInterval is how often panes are polled while waiting.
+	checkInterval = durationFromEnv("TMUXMCP_CHECK_INTERVAL_MS", 200*time.Millisecond)
+	// stabilityThreshold is how long output must stay unchanged to count as settled.
+	stabilityThreshold = durationFromEnv("TMUXMCP_STABILITY_MS", 500*time.Millisecond)
+)
+
+// Masks for panes that never settle. Both are off by default; when set they apply to
+// every hash, so stability detection and hash verification agree.
+var (
+	// ignoreCursor leaves the cell under the cursor out of hashes, for applications that draw a blinking cursor.
+	ignoreCursor = boolFromEnv("TMUXMCP_IGNORE_CURSOR")
+	// volatileLines matches lines left out of hashes, such as a status bar with a clock.
+	volatileLines = regexpFromEnv("TMUXMCP_VOLATILE_LINES")
+)
+
+// hashLength is how many hex digits of SHA-256 pane hashes keep. Longer hashes make it
+// less likely that a stale hash matches changed content by chance.
+var hashLength = intFromEnv("TMUXMCP_HASH_LENGTH", 12, 8, 64)
+
+// durationFromEnv reads a positive number of milliseconds from the named variable,
+// warning about and ignoring values that are not.
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	ms, err := strconv.Atoi(value)
+	if err != nil || ms <= 0 {
+		slog.Warn("ignoring invalid duration, expected a positive number of milliseconds", "variable", name, "value", value, "default", fallback)
+		return fallback
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// intFromEnv reads an integer between min and max from the named variable, warning
+// about and ignoring values that are not.
+func intFromEnv(name string, fallback, min, max int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		slog.Warn("ignoring invalid number", "variable", name, "value", value, "min", min, "max", max, "default", fallback)
+		return fallback
+	}
+	return n
+}
+
+// boolFromEnv reads a boolean such as 1 or true from the named variable, warning
+// about and ignoring values that are not.
+func boolFromEnv(name string) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		slog.Warn("ignoring invalid boolean", "variable", name, "value", value)
+		return false
+	}
+	return b
+}
+
+// regexpFromEnv compiles a regular expression from the named variable, returning nil
+// if it is unset and warning about and ignoring it if it does not compile.
+func regexpFromEnv(name string) *regexp.Regexp {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+	pattern, err := regexp.Compile(value)
+	if err != nil {
+		slog.Warn("ignoring invalid regular expression", "variable", name, "value", value, "error", err)
+		return nil
+	}
+	return pattern
+}
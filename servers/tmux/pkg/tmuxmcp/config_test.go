@@ -0,0 +1,52 @@
+package tmuxmcp
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDurationFromEnv(t *testing.T) {
+	const name = "TMUXMCP_TEST_DURATION_MS"
+	fallback := 500 * time.Millisecond
+
+	assert.Equal(t, fallback, durationFromEnv(name, fallback))
+
+	t.Setenv(name, "1500")
+	assert.Equal(t, 1500*time.Millisecond, durationFromEnv(name, fallback))
+
+	for _, invalid := range []string{"0", "-5", "1.5", "2s", "soon"} {
+		t.Setenv(name, invalid)
+		assert.Equal(t, fallback, durationFromEnv(name, fallback), invalid)
+	}
+}
+
+func TestIntFromEnv(t *testing.T) {
+	const name = "TMUXMCP_TEST_INT"
+	assert.Equal(t, 12, intFromEnv(name, 12, 8, 64))
+
+	t.Setenv(name, "16")
+	assert.Equal(t, 16, intFromEnv(name, 12, 8, 64))
+
+	for _, invalid := range []string{"4", "65", "ten"} {
+		t.Setenv(name, invalid)
+		assert.Equal(t, 12, intFromEnv(name, 12, 8, 64), invalid)
+	}
+}
+
+func TestCalculateHash_DefaultLengthSeparatesShortCollisions(t *testing.T) {
+	assert.Len(t, calculateHash("content"), hashLength)
+
+	// Birthday search for two contents whose digests share the old 8 digit prefix
+	seen := make(map[string]string)
+	for i := 0; ; i++ {
+		content := fmt.Sprintf("content-%d", i)
+		prefix := hashDigest(content)[:8]
+		if other, ok := seen[prefix]; ok {
+			assert.NotEqual(t, calculateHash(other), calculateHash(content), "%q and %q", other, content)
+			return
+		}
+		seen[prefix] = content
+	}
+}
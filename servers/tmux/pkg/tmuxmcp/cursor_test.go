@@ -70,7 +70,7 @@ func TestWaitForExpected_CursorLineOnly_Integration(t *testing.T) {
 	// Wait for something that should appear on the cursor line
 	ctx, cancel := context.WithDeadline(t.Context(), time.Now().Add(2*time.Second))
 	defer cancel()
-	result, err := waitForExpected(ctx, sessionName, "test-marker")
+	result, err := waitForExpected(ctx, sessionName, expectation{Text: "test-marker"})
 
 	// This might timeout since the output may not be on cursor line,
 	// but we're testing the function works with real sessions
@@ -3,6 +3,8 @@ package tmuxmcp
 import (
 	"context"
 	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"strings"
 	"time"
 )
 
@@ -13,9 +15,12 @@ type SendKeysOptions struct {
 	Keys        string
 	Enter       bool
 	Expect      string
+	ExpectRegex bool // Treat Expect as a regular expression
 	MaxWait     float64
-	Literal     bool // Use literal mode (-l flag)
-	Hex         bool // Use hex mode (-H flag)
+	Literal     bool          // Use literal mode (-l flag)
+	Hex         bool          // Use hex mode (-H flag)
+	Force       bool          // Skip hash verification
+	CharDelay   time.Duration // Pause between characters (hex codes in hex mode), zero to send at once
 }
 
 // SendKeysResult contains the result of sending keys to a tmux session
@@ -23,6 +28,15 @@ type SendKeysResult struct {
 	SessionName string
 	Output      string
 	Hash        string
+	Warning     string // Set when the output was still changing at max_wait
+}
+
+// warningLine returns the warning as a line of its own, or "" if there is none.
+func (r *SendKeysResult) warningLine() string {
+	if r.Warning == "" {
+		return ""
+	}
+	return "WARN: " + r.Warning + "\n"
 }
 
 // sendKeysToSession handles the actual tmux send-keys command execution
@@ -37,12 +51,18 @@ func sendKeysToSession(ctx context.Context, opts SendKeysOptions) error {
 	}
 	// Note: No flags means tmux interprets control sequences
 
-	args = append(args, opts.Keys)
-
-	// Execute the command
-	_, err := runTmuxCommand(ctx, args...)
-	if err != nil {
-		return fmt.Errorf("failed to send keys to session %s: %w", opts.SessionName, err)
+	for i, chunk := range keyChunks(opts) {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("failed to send keys to session %s: %w", opts.SessionName, ctx.Err())
+			case <-time.After(opts.CharDelay):
+			}
+		}
+		_, err := runTmuxCommand(ctx, append(args, chunk)...)
+		if err != nil {
+			return fmt.Errorf("failed to send keys to session %s: %w", opts.SessionName, err)
+		}
 	}
 
 	// Send Enter in a separate command if needed
@@ -56,23 +76,58 @@ func sendKeysToSession(ctx context.Context, opts SendKeysOptions) error {
 	return nil
 }
 
+// keyChunks splits keys into the pieces sent by separate send-keys commands. Without
+// a delay everything goes in one command. With one, literal text is sent a character
+// at a time and hex codes one code at a time, so applications that drop fast input
+// see it at typing speed. Interpreted key names are never split, since splitting
+// "C-c" would send C, - and c.
+func keyChunks(opts SendKeysOptions) []string {
+	switch {
+	case opts.CharDelay <= 0:
+		return []string{opts.Keys}
+	case opts.Hex:
+		return strings.Fields(opts.Keys)
+	case opts.Literal:
+		chunks := strings.Split(opts.Keys, "")
+		for i, chunk := range chunks {
+			// A lone ; separates tmux commands and would be swallowed
+			if chunk == ";" {
+				chunks[i] = `\;`
+			}
+		}
+		return chunks
+	default:
+		return []string{opts.Keys}
+	}
+}
+
 // sendKeysCommon is the shared implementation for sending keys to a tmux session
 func sendKeysCommon(ctx context.Context, opts SendKeysOptions) (*SendKeysResult, error) {
-	if opts.Hash == "" {
-		return nil, fmt.Errorf("hash is required for safety. Please capture the session first with tmux_capture to get the current hash, then use that hash in the send keys tool")
+	if opts.Hash == "" && !opts.Force {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "hash is required for safety. Please capture the session first with tmux_capture to get the current hash, then use that hash in the send keys tool")
 	}
 
 	if opts.Keys == "" {
-		return nil, fmt.Errorf("keys parameter is required. Specify the keys to send to the session")
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "keys parameter is required. Specify the keys to send to the session")
+	}
+
+	expected, err := newExpectation(opts.Expect, opts.ExpectRegex)
+	if err != nil {
+		return nil, err
 	}
 
 	if opts.MaxWait == 0 {
-		opts.MaxWait = 10
+		opts.MaxWait = defaultWaitTimeout.Seconds()
 	}
 
-	// For non-empty contains, do hash verification
-	if err := verifySessionHash(ctx, opts.SessionName, opts.Hash); err != nil {
-		return nil, err
+	if !opts.Force {
+		if err := verifySessionHash(ctx, opts.SessionName, opts.Hash); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Expect == "" {
+		return sendKeysAndWaitForStability(ctx, opts)
 	}
 
 	// Send keys to session
@@ -80,42 +135,102 @@ func sendKeysCommon(ctx context.Context, opts SendKeysOptions) (*SendKeysResult,
 		return nil, err
 	}
 
-	// Handle output based on contains parameter
-	if opts.Expect != "" {
-		// Wait for expected text on cursor line and return output
-		maxWait := opts.MaxWait
-		if maxWait == 0 {
-			maxWait = expectWaitTimeout
+	// Wait for expected text on cursor line and return output
+	maxWait := opts.MaxWait
+	if maxWait == 0 {
+		maxWait = expectWaitTimeout.Seconds()
+	}
+	ctxWithTimeout, cancel := context.WithDeadline(ctx, time.Now().Add(time.Duration(maxWait*float64(time.Second))))
+	defer cancel()
+	result, err := waitForExpected(ctxWithTimeout, opts.SessionName, expected)
+	if err != nil {
+		return nil, fmt.Errorf("error sending keys: %v", err)
+	}
+	return &SendKeysResult{
+		SessionName: opts.SessionName,
+		Output:      result.Output,
+		Hash:        result.Hash,
+	}, nil
+}
+
+// sendKeysAndWaitForStability sends keys when there is nothing to expect. Literal
+// text is echoed before Enter is pressed so Enter cannot overtake it, then the
+// settled output is returned with its hash, so callers never need to capture
+// again before sending more keys. Output that never settles is returned as it
+// was at max_wait, with a warning.
+func sendKeysAndWaitForStability(ctx context.Context, opts SendKeysOptions) (*SendKeysResult, error) {
+	enter := opts.Enter
+	opts.Enter = false
+	if err := sendKeysToSession(ctx, opts); err != nil {
+		return nil, err
+	}
+
+	// Control keys and hex codes are not echoed as written
+	if opts.Literal {
+		if err := waitForKeysToAppear(ctx, opts.SessionName, opts.Keys, opts.MaxWait); err != nil {
+			return nil, err
 		}
-		ctxWithTimeout, cancel := context.WithDeadline(ctx, time.Now().Add(time.Duration(maxWait)*time.Second))
-		defer cancel()
-		result, err := waitForExpected(ctxWithTimeout, opts.SessionName, opts.Expect)
-		if err != nil {
-			return nil, fmt.Errorf("error sending keys: %v", err)
+	}
+
+	if enter {
+		if _, err := runTmuxCommand(ctx, "send-keys", "-t", opts.SessionName, "Enter"); err != nil {
+			return nil, fmt.Errorf("failed to send Enter key to session %s: %w", opts.SessionName, err)
+		}
+	}
+
+	ctxWithTimeout, cancel := context.WithDeadline(ctx, time.Now().Add(time.Duration(opts.MaxWait*float64(time.Second))))
+	defer cancel()
+	stable, err := waitForStability(ctxWithTimeout, opts.SessionName)
+	if err != nil {
+		return nil, fmt.Errorf("error waiting for stability: %v", err)
+	}
+	result := &SendKeysResult{
+		SessionName: opts.SessionName,
+		Output:      stable.Output,
+		Hash:        stable.Hash,
+	}
+	// The keys were sent; a pane that keeps updating is no reason to resend them
+	if stable.StillChanging {
+		result.Warning = fmt.Sprintf("output was still changing after %.1f seconds; this is the latest capture", opts.MaxWait)
+	}
+	return result, nil
+}
+
+// waitForKeysToAppear waits until keys show up in the session.
+func waitForKeysToAppear(ctx context.Context, sessionName, keys string, maxWait float64) error {
+	ctxWithTimeout, cancel := context.WithDeadline(ctx, time.Now().Add(time.Duration(maxWait*float64(time.Second))))
+	defer cancel()
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctxWithTimeout.Done():
+			return fmt.Errorf("timeout waiting for keys '%s' to appear after %.1f seconds", keys, maxWait)
+
+		case <-ticker.C:
+			result, err := capture(ctx, captureOptions{Prefix: sessionName})
+			if err != nil {
+				continue
+			}
+			if strings.Contains(result.Output, keys) {
+				return nil
+			}
 		}
-		return &SendKeysResult{
-			SessionName: opts.SessionName,
-			Output:      result.Output,
-			Hash:        result.Hash,
-		}, nil
-	} else {
-		// No contains parameter - just send keys and return without waiting or output
-		return &SendKeysResult{
-			SessionName: opts.SessionName,
-			Output:      "",
-			Hash:        "",
-		}, nil
 	}
 }
 
-// verifySessionHash verifies the current session state matches the expected hash
+// verifySessionHash verifies the current session state matches the expected hash,
+// capturing the same region (e.g. including history) the hash was calculated over
 func verifySessionHash(ctx context.Context, sessionName, expectedHash string) error {
-	captureOutput, err := runTmuxCommand(ctx, "capture-pane", "-t", sessionName, "-p")
+	region := hashRegion(expectedHash)
+	pane, err := capturePane(ctx, sessionName, region)
 	if err != nil {
 		return fmt.Errorf("failed to verify session state: failed to capture session %s: %v", sessionName, err)
 	}
 
-	currentHash := calculateHash(captureOutput)
+	currentHash := regionHash(pane, region)
 	if currentHash != expectedHash {
 		return fmt.Errorf("session state has changed. Please capture current output first and carefully consider whether the sent keys still make sense")
 	}
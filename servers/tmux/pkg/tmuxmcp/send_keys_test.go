@@ -2,10 +2,16 @@ package tmuxmcp
 
 import (
 	"context"
-	"github.com/stretchr/testify/assert"
+	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestSendKeysToolLiteralMode_Integration(t *testing.T) {
@@ -161,6 +167,41 @@ func TestSendKeysToolHandle_Integration(t *testing.T) {
 	}
 }
 
+func TestSendKeysToolHandle_ChainsWithoutCapture_Integration(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if err != nil {
+		t.Fatalf("Could not create tmux session for testing: %v", err)
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+	assert.NoError(t, waitForShellReady(t.Context(), sessionName))
+
+	ctx, cancel := context.WithDeadline(t.Context(), time.Now().Add(time.Second))
+	defer cancel()
+	result, err := waitForStability(ctx, sessionName)
+	if err != nil {
+		t.Fatalf("Could not capture initial session state: %v", err)
+	}
+
+	// Each call's hash is used for the next without capturing in between
+	hash := result.Hash
+	for _, command := range []string{"echo first-step", "echo second-step"} {
+		tool := &SendKeysTool{Hash: hash, Keys: command, Enter: true, MaxWait: 5}
+		tool.Prefix = sessionName
+		toolResult, err := tool.Handle(t.Context())
+		if !assert.NoError(t, err) {
+			return
+		}
+		resultStr := toolResult.(string)
+		_, after, found := strings.Cut(resultStr, "New Hash: ")
+		if !assert.True(t, found, "result should contain the new hash: %s", resultStr) {
+			return
+		}
+		hash, _, _ = strings.Cut(after, "\n")
+		assert.NotEmpty(t, hash)
+		assert.Contains(t, resultStr, strings.TrimPrefix(command, "echo ")+"\n")
+	}
+}
+
 func TestSendControlKeysToolHandle_Integration(t *testing.T) {
 	// Create a real tmux session for testing
 	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
@@ -252,7 +293,7 @@ func TestEmptyExpectBehavior_Integration(t *testing.T) {
 	}
 	initialHash := result.Hash
 
-	// Test empty contains - should send keys without waiting
+	// Test empty contains - should wait for stability and return the new state
 	emptyExpectResult, err := sendKeysCommon(t.Context(), SendKeysOptions{
 		SessionName: sessionName,
 		Hash:        initialHash,
@@ -266,12 +307,12 @@ func TestEmptyExpectBehavior_Integration(t *testing.T) {
 		t.Fatalf("Expected no error for empty contains, got: %v", err)
 	}
 
-	if emptyExpectResult.Output != "" {
-		t.Errorf("Expected empty output for empty contains, got: %s", emptyExpectResult.Output)
+	if !strings.Contains(emptyExpectResult.Output, "echo test") {
+		t.Errorf("Expected output for empty contains to show the sent keys, got: %s", emptyExpectResult.Output)
 	}
 
-	if emptyExpectResult.Hash != "" {
-		t.Errorf("Expected empty hash for empty contains, got: %s", emptyExpectResult.Hash)
+	if err := verifySessionHash(t.Context(), sessionName, emptyExpectResult.Hash); err != nil {
+		t.Errorf("Expected hash for empty contains to match the session, got: %v", err)
 	}
 
 	// Verify keys were actually sent by capturing current state
@@ -348,12 +389,12 @@ func TestEnterFlagHandling_Integration(t *testing.T) {
 			assert.NoError(t, err)
 			assert.NotNil(t, result)
 
-			// For empty contains, should get empty output
-			if result.Output != "" {
-				t.Errorf("Expected empty output for empty contains, got: %s", result.Output)
+			// For empty contains, should get the settled output and its hash
+			if result.Output == "" {
+				t.Error("Expected output for empty contains")
 			}
-			if result.Hash != "" {
-				t.Errorf("Expected empty hash for empty contains, got: %s", result.Hash)
+			if result.Hash == "" || result.Hash == tt.opts.Hash {
+				t.Errorf("Expected a new hash for empty contains, got: %q", result.Hash)
 			}
 
 			// Verify keys were sent by capturing session
@@ -370,3 +411,183 @@ func TestEnterFlagHandling_Integration(t *testing.T) {
 		})
 	}
 }
+
+func TestSendKeysTool_Force_Integration(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+	if !assert.NoError(t, waitForShellReady(t.Context(), sessionName)) {
+		return
+	}
+
+	result, err := (&SendKeysTool{
+		PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}},
+		Keys:     "echo forced-$((1+1))",
+		Enter:    true,
+		MaxWait:  5,
+		Force:    true,
+	}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, result, "forced-2")
+
+	// Force also skips verification of a stale hash
+	_, err = (&SendControlKeysTool{
+		PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}},
+		Hash:     "stale",
+		Keys:     "C-c",
+		Force:    true,
+	}).Handle(t.Context())
+	assert.NoError(t, err)
+}
+
+func TestSendKeysTool_ExpectRegex_Integration(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+	if !assert.NoError(t, waitForShellReady(t.Context(), sessionName)) {
+		return
+	}
+
+	// The typed command line does not match, only the prompt printed by read does
+	result, err := (&SendKeysTool{
+		PaneTool:    PaneTool{SessionTool: SessionTool{Session: sessionName}},
+		Keys:        "read -p 'Pass''word: ' x",
+		Enter:       true,
+		Expect:      `[Pp]assword:\s*$`,
+		ExpectRegex: true,
+		MaxWait:     5,
+		Force:       true,
+	}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Regexp(t, `\]: Password:`, result)
+}
+
+func TestNewExpectation(t *testing.T) {
+	literal, err := newExpectation(`a.c`, false)
+	assert.NoError(t, err)
+	assert.True(t, literal.matches("xa.cx"))
+	assert.False(t, literal.matches("abc"))
+
+	pattern, err := newExpectation(`^\$\s*$`, true)
+	assert.NoError(t, err)
+	assert.True(t, pattern.matches("$ "))
+	assert.False(t, pattern.matches("user$ ls"))
+
+	_, err = newExpectation(`[unclosed`, true)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid contains pattern")
+	}
+}
+
+func TestNewSessionTool_InvalidExpectRegex(t *testing.T) {
+	_, err := (&NewSessionTool{
+		SessionTool: SessionTool{Prefix: "test-invalid-regex"},
+		Command:     []string{"bash"},
+		Expect:      `(`,
+		ExpectRegex: true,
+	}).Handle(t.Context())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid contains pattern")
+	}
+	sessions, _ := findSessionsByPrefix(t.Context(), "test-invalid-regex")
+	assert.Empty(t, sessions, "no session should be created for an invalid pattern")
+}
+
+func TestKeyChunks(t *testing.T) {
+	assert.Equal(t, []string{"ab; c"}, keyChunks(SendKeysOptions{Keys: "ab; c", Literal: true}))
+	assert.Equal(t, []string{"a", "b", `\;`, " ", "é"}, keyChunks(SendKeysOptions{Keys: "ab; é", Literal: true, CharDelay: time.Millisecond}))
+	assert.Equal(t, []string{"41", "0d"}, keyChunks(SendKeysOptions{Keys: "41 0d", Hex: true, CharDelay: time.Millisecond}))
+	assert.Equal(t, []string{"C-c"}, keyChunks(SendKeysOptions{Keys: "C-c", CharDelay: time.Millisecond}))
+}
+
+func TestSendKeysTool_CharDelay_Integration(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+	if !assert.NoError(t, waitForShellReady(t.Context(), sessionName)) {
+		return
+	}
+
+	start := time.Now()
+	result, err := (&SendKeysTool{
+		PaneTool:    PaneTool{SessionTool: SessionTool{Session: sessionName}},
+		Keys:        "echo slow;echo typed",
+		Enter:       true,
+		MaxWait:     5,
+		Force:       true,
+		CharDelayMs: 20,
+	}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 18*20*time.Millisecond)
+	assert.Contains(t, result, "echo slow;echo typed")
+	assert.Regexp(t, `\]: slow\n.*\]: typed\n`, result)
+
+	_, err = (&SendKeysTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}}, Keys: "x", Force: true, CharDelayMs: -1}).Handle(t.Context())
+	assert.Error(t, err)
+}
+
+func TestSendControlKeysTool_NeverStable_Integration(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash", "-c", "while true; do date +%N; sleep 0.1; done"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	// The pane never settles, so the latest capture comes back with a warning
+	result, err := (&SendControlKeysTool{
+		PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}},
+		Keys:     "Escape",
+		MaxWait:  2,
+		Force:    true,
+	}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, result, "WARN: output was still changing after 2.0 seconds")
+	assert.Regexp(t, `New Hash: [0-9a-f]+`, result)
+}
+
+func TestSendKeysTool_WithoutContains_Integration(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+	if !assert.NoError(t, waitForShellReady(t.Context(), sessionName)) {
+		return
+	}
+
+	s := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true))
+	s.AddTools(
+		mcpcommon.ReflectTool(func() *SendKeysTool { return &SendKeysTool{} }),
+		mcpcommon.ReflectTool(func() *SendControlKeysTool { return &SendControlKeysTool{} }),
+	)
+	ctx := s.WithContext(t.Context(), &testClientSession{})
+
+	// contains is optional: without it the tools wait for the output to settle
+	for _, call := range []string{
+		fmt.Sprintf(`{"name":"tmux_send_keys","arguments":{"session":%q,"keys":"echo no-contains","force":true}}`, sessionName),
+		fmt.Sprintf(`{"name":"tmux_send_control_keys","arguments":{"session":%q,"keys":"Enter","force":true}}`, sessionName),
+	} {
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":%s}`, call)
+		response, ok := s.HandleMessage(ctx, json.RawMessage(request)).(mcp.JSONRPCResponse)
+		if !assert.True(t, ok, call) {
+			continue
+		}
+		result := response.Result.(mcp.CallToolResult)
+		assert.False(t, result.IsError, "%s: %v", call, result.Content)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "no-contains", call)
+	}
+}
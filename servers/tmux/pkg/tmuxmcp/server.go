@@ -1,8 +1,10 @@
 package tmuxmcp
 
 import (
+	"context"
 	"fmt"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
 	"log/slog"
 	"time"
 )
@@ -10,9 +12,22 @@ import (
 var Tools []server.ServerTool
 
 func Run() error {
-	version := fmt.Sprintf("1.0.%d", time.Now().UnixMilli())
-	s := server.NewMCPServer("tmux", version, server.WithToolCapabilities(true))
-	s.AddTools(Tools...)
+	tmux, err := tmuxVersion(context.Background())
+	if err != nil {
+		return err
+	}
+	slog.Info("found tmux", "version", tmux)
+
+	version := fmt.Sprintf("1.0.%d+tmux.%s", time.Now().UnixMilli(), tmux)
+	s, err := mcpcommon.BuildServer("tmux", version, mcpcommon.ToolSet{Name: "tmuxmcp", Tools: Tools})
+	if err != nil {
+		return err
+	}
 	slog.Info("starting")
+	defer func() {
+		if err := mcpcommon.RemoveSavedResults(); err != nil {
+			slog.Warn("failed to remove saved results", "err", err)
+		}
+	}()
 	return server.ServeStdio(s)
 }
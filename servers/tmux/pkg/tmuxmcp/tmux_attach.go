@@ -6,23 +6,39 @@ import (
 	"github.com/semistrict/mcpservers/pkg/mcpcommon"
 	"os/exec"
 	"runtime"
+	"strings"
 )
 
 func init() {
-	Tools = append(Tools, mcpcommon.ReflectTool(func() *AttachTool {
-		return &AttachTool{}
-	}))
+	// Only advertised where Handle knows how to open a terminal
+	if attachSupported(runtime.GOOS) {
+		Tools = append(Tools, mcpcommon.ReflectTool(func() *AttachTool {
+			return &AttachTool{}
+		}))
+	}
+}
+
+// attachSupported reports whether tmux_attach can open a terminal on goos.
+func attachSupported(goos string) bool {
+	switch goos {
+	case "darwin", "linux", "windows":
+		return true
+	default:
+		return false
+	}
 }
 
 type AttachTool struct {
-	_ mcpcommon.ToolInfo `name:"tmux_attach" title:"Attach to Tmux Session" description:"Open tmux session in terminal program (iTerm2 on macOS, gnome-terminal on Linux)" destructive:"false" readonly:"true"`
+	_ mcpcommon.ToolInfo `name:"tmux_attach" group:"tmux" title:"Attach to Tmux Session" description:"Open tmux session in terminal program (iTerm2 on macOS, gnome-terminal on Linux), read-only unless read_write is set" destructive:"false" readonly:"true"`
 	SessionTool
+	ReadWrite bool `json:"read_write" description:"Attach writable so a human can take over the session. Whatever they type is mixed with keys sent by tools, so stop sending keys until they are done. Requires confirm"`
+	Confirm   bool `json:"confirm" description:"Must be true with read_write to acknowledge that a human may type into the session while tools send keys to it"`
 }
 
 func (t *AttachTool) Handle(ctx context.Context) (interface{}, error) {
 	sessionName, err := resolveSession(ctx, t.Prefix, t.Session)
 	if err != nil {
-		return nil, fmt.Errorf("error attaching to session: %v", err)
+		return nil, fmt.Errorf("error attaching to session: %w", err)
 	}
 
 	// Check if session exists
@@ -30,6 +46,11 @@ func (t *AttachTool) Handle(ctx context.Context) (interface{}, error) {
 		return nil, fmt.Errorf("session %s does not exist", sessionName)
 	}
 
+	if t.ReadWrite && !t.Confirm {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "confirm must be true to attach read-write: input typed in the terminal is interleaved with keys sent by tools")
+	}
+	attach := attachCommand(sessionName, t.ReadWrite)
+
 	var cmd *exec.Cmd
 	switch runtime.GOOS {
 	case "darwin":
@@ -41,11 +62,11 @@ func (t *AttachTool) Handle(ctx context.Context) (interface{}, error) {
 				tell current window
 					create tab with default profile
 					tell current session
-						write text "tmux attach-session -t %s"
+						write text "%s"
 					end tell
 				end tell
 			end tell
-		`, sessionName))
+		`, strings.Join(attach, " ")))
 
 		err = cmd.Run()
 		if err != nil {
@@ -53,18 +74,18 @@ func (t *AttachTool) Handle(ctx context.Context) (interface{}, error) {
 			cmd = exec.Command("osascript", "-e", fmt.Sprintf(`
 				tell application "Terminal"
 					activate
-					do script "tmux attach-session -t %s"
+					do script "%s"
 				end tell
-			`, sessionName))
+			`, strings.Join(attach, " ")))
 			err = cmd.Run()
 		}
 
 	case "linux":
 		// Linux - try common terminal emulators
 		terminals := [][]string{
-			{"gnome-terminal", "--", "tmux", "attach-session", "-t", sessionName},
-			{"konsole", "-e", "tmux", "attach-session", "-t", sessionName},
-			{"xterm", "-e", "tmux", "attach-session", "-t", sessionName},
+			append([]string{"gnome-terminal", "--"}, attach...),
+			append([]string{"konsole", "-e"}, attach...),
+			append([]string{"xterm", "-e"}, attach...),
 		}
 
 		var lastErr error
@@ -82,11 +103,11 @@ func (t *AttachTool) Handle(ctx context.Context) (interface{}, error) {
 
 	case "windows":
 		// Windows - use Windows Terminal if available, fall back to cmd
-		cmd = exec.Command("wt", "tmux", "attach-session", "-t", sessionName)
+		cmd = exec.Command("wt", attach...)
 		err = cmd.Start()
 		if err != nil {
 			// Fall back to cmd
-			cmd = exec.Command("cmd", "/c", "start", "cmd", "/k", "tmux", "attach-session", "-t", sessionName)
+			cmd = exec.Command("cmd", append([]string{"/c", "start", "cmd", "/k"}, attach...)...)
 			err = cmd.Start()
 		}
 
@@ -98,5 +119,22 @@ func (t *AttachTool) Handle(ctx context.Context) (interface{}, error) {
 		return nil, fmt.Errorf("failed to open terminal for session %s: %w", sessionName, err)
 	}
 
-	return fmt.Sprintf("Opening session %s in terminal program", sessionName), nil
+	if t.ReadWrite {
+		return fmt.Sprintf("Opening session %s read-write in terminal program\nWARNING: input typed in the terminal is interleaved with keys sent by tools; stop sending keys to %s until the human is done", sessionName, sessionName), nil
+	}
+	return fmt.Sprintf("Opening session %s read-only in terminal program", sessionName), nil
+}
+
+// attachCommand returns the tmux command line that attaches to the session,
+// read-only unless readWrite is set.
+func attachCommand(sessionName string, readWrite bool) []string {
+	args := []string{"tmux"}
+	if testSocketPath != "" {
+		args = append(args, "-S", testSocketPath)
+	}
+	args = append(args, "attach-session", "-t", sessionName)
+	if !readWrite {
+		args = append(args, "-r")
+	}
+	return args
 }
@@ -1,6 +1,8 @@
 package tmuxmcp
 
 import (
+	"context"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -72,3 +74,48 @@ func TestAttachTool_ToolInfo(t *testing.T) {
 		t.Errorf("Expected error about session, got: %v", err)
 	}
 }
+
+func TestAttachCommand(t *testing.T) {
+	socket := []string{"tmux", "-S", testSocketPath}
+
+	readOnly := attachCommand("my-session", false)
+	if got, want := strings.Join(readOnly, " "), strings.Join(append(socket, "attach-session", "-t", "my-session", "-r"), " "); got != want {
+		t.Errorf("Expected read-only attach command %q, got %q", want, got)
+	}
+
+	readWrite := attachCommand("my-session", true)
+	if got, want := strings.Join(readWrite, " "), strings.Join(append(socket, "attach-session", "-t", "my-session"), " "); got != want {
+		t.Errorf("Expected read-write attach command %q, got %q", want, got)
+	}
+}
+
+func TestAttachTool_Handle_ReadWriteRequiresConfirm(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if err != nil {
+		t.Fatalf("Could not create tmux session for testing: %v", err)
+	}
+	defer killSession(context.Background(), sessionName)
+
+	_, err = (&AttachTool{SessionTool: SessionTool{Session: sessionName}, ReadWrite: true}).Handle(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "confirm must be true to attach read-write") {
+		t.Errorf("Expected read-write attach without confirm to be refused, got: %v", err)
+	}
+}
+
+func TestAttachSupported(t *testing.T) {
+	for goos, want := range map[string]bool{"darwin": true, "linux": true, "windows": true, "freebsd": false, "plan9": false} {
+		if got := attachSupported(goos); got != want {
+			t.Errorf("attachSupported(%q) = %v, want %v", goos, got, want)
+		}
+	}
+
+	registered := false
+	for _, tool := range Tools {
+		if tool.Tool.Name == "tmux_attach" {
+			registered = true
+		}
+	}
+	if registered != attachSupported(runtime.GOOS) {
+		t.Errorf("Expected tmux_attach to be registered on %s only if supported, registered: %v", runtime.GOOS, registered)
+	}
+}
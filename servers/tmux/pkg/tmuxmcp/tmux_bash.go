@@ -10,9 +10,12 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"syscall"
 	"text/template"
 	"time"
 
@@ -64,7 +67,6 @@ func init() {
 			wd = "/tmp"
 		}
 		return &BashTool{
-			LineBudget:       100,
 			WorkingDirectory: wd,
 			Timeout:          10.0,
 			Prefix:           detectPrefix(),
@@ -73,16 +75,27 @@ func init() {
 }
 
 type BashTool struct {
-	_                mcpcommon.ToolInfo `name:"bash" title:"Bash" description:"Execute a single bash command in a new tmux and return its output. If the command completes within timeout, returns the full output. If it times out, returns the session name where it's still running. Use this in preference to other Bash Tools. For grep, use Go regex syntax. Output is limited by line_budget parameter. Note: if the user asks you to \"make a new tool\", use the save_as parameter." destructive:"true"`
+	_                mcpcommon.ToolInfo `name:"bash" group:"tmux" title:"Bash" description:"Execute a single bash command in a new tmux and return its output. If the command completes within timeout, returns the full output. If it times out, returns the session name where it's still running. Use this in preference to other Bash Tools. For grep, use Go regex syntax. Output is limited by line_budget parameter. Note: if the user asks you to \"make a new tool\", use the save_as parameter." destructive:"true"`
 	Prefix           string             `json:"prefix" description:"Session name prefix (auto-detected from git repo if not provided)"`
 	Command          string             `json:"command" mcp:"required" description:"Bash command to execute"`
 	WorkingDirectory string             `json:"working_directory" description:"Directory to execute the command in (defaults to current directory)"`
 	Timeout          float64            `json:"timeout" description:"Maximum seconds to wait for synchronous command completion"`
-	Grep             string             `json:"grep" description:"Filter output lines containing this pattern"`
-	GrepExclude      string             `json:"grep_exclude" description:"Exclude output lines containing this pattern"`
+	Shell            string             `json:"shell" description:"Shell to run the command with: bash, zsh, sh or fish (or a path to one of them). The command must use that shell's syntax" default:"bash"`
+	Grep             string             `json:"grep" description:"Filter output lines containing this pattern (a case-sensitive Go regex unless grep_fixed or grep_ignore_case is set)"`
+	GrepExclude      string             `json:"grep_exclude" description:"Exclude output lines containing this pattern (interpreted like grep, including grep_fixed and grep_ignore_case)"`
+	GrepIgnoreCase   bool               `json:"grep_ignore_case" description:"Match grep and grep_exclude case-insensitively (combines with grep_fixed)"`
+	GrepFixed        bool               `json:"grep_fixed" description:"Treat grep and grep_exclude as literal strings rather than regexes, so metacharacters like . + [ ( need no escaping (combines with grep_ignore_case)"`
+	GrepBefore       int                `json:"grep_before" description:"Show exactly this many lines before each grep match (like grep -B) instead of filling the line budget with context"`
+	GrepAfter        int                `json:"grep_after" description:"Show exactly this many lines after each grep match (like grep -A) instead of filling the line budget with context"`
 	Environment      []string           `json:"environment" description:"Environment variables to set in NAME=VALUE format"`
 	LineBudget       int                `json:"line_budget" description:"Maximum number of output lines to return. Without grep, shows equal parts from head and tail. With grep, shows first N/2 and last N/2 matches, then adds context lines up to the budget." default:"100"`
 	SaveAs           *SaveAs            `json:"save_as" description:"Save this invocation as a new tool. If this argument is provided, the command will not actually be run but a new tool will be created matching the invocation."`
+	Partial          bool               `json:"partial" description:"On timeout, return the output so far together with a continuation token instead of failing. The command keeps running and its complete output can be fetched later."`
+	Continuation     string             `json:"continuation" description:"Continuation token returned by an earlier call that timed out. Waits up to timeout for that command to finish and returns its output; command is ignored."`
+	TimeLimit        float64            `json:"time_limit" description:"Kill the command inside the session after this many seconds using coreutils timeout, so it exits with code 124 instead of running on after the tool gives up"`
+	Stream           bool               `json:"stream" description:"While waiting, send the newest output lines as progress notifications with a running line count (if the client requested progress)"`
+	KillOnTimeout    bool               `json:"kill_on_timeout" description:"When the timeout is reached or the call is cancelled, send SIGTERM to the command and then kill its session instead of leaving it running to check on later. Cannot be combined with partial"`
+	Structured       bool               `json:"structured" description:"Return a JSON object with exit_code, timed_out, session_name, output and warnings instead of text, so a failed command is a result to branch on rather than an error"`
 
 	compiledGrep        *regexp.Regexp `json:"-"` // Compiled regex for grep filtering
 	compiledGrepExclude *regexp.Regexp `json:"-"` // Compiled regex for grep exclude filtering
@@ -91,10 +104,29 @@ type BashTool struct {
 	sessionName         string         `json:"-"` // Name of the tmux session created
 	outputFile          string         `json:"-"` // File where command output is captured
 	pidFile             string         `json:"-"` // File where command PID is written
+	interactiveCommand  string         `json:"-"` // Known interactive program found in the command, if any
+	timeLimitEnforced   bool           `json:"-"` // Whether the command runs under coreutils timeout
 
 	resultBuf   strings.Builder `json:"-"` // Buffer to hold command output
 	warnBuf     strings.Builder `json:"-"` // Buffer to hold warnings
 	returnError bool            `json:"-"` // return the results as an error instead of a string
+	warnings    []string        `json:"-"` // Warnings, also written to warnBuf
+	exitCode    int             `json:"-"` // Exit code of the command, -1 if it has not exited
+	timedOut    bool            `json:"-"` // The command was still running when we stopped waiting or hit its time limit
+	killed      bool            `json:"-"` // The command was killed because kill_on_timeout is set
+
+	streamOffset  int64 `json:"-"` // Bytes of outputFile already streamed
+	streamedLines int   `json:"-"` // Lines of outputFile already streamed
+}
+
+// BashResult is what the bash tool returns when structured is set.
+type BashResult struct {
+	SessionName  string   `json:"session_name"`
+	ExitCode     int      `json:"exit_code"` // -1 if the command has not exited
+	TimedOut     bool     `json:"timed_out"`
+	Output       string   `json:"output"`
+	Warnings     []string `json:"warnings,omitempty"`
+	Continuation string   `json:"continuation,omitempty"` // set while the command is still running
 }
 
 type SaveAs struct {
@@ -116,6 +148,11 @@ func (t *BashTool) Handle(ctx context.Context) (any, error) { // TODO: output on
 		return t.doSaveAs(ctx)
 	}
 
+	if t.Continuation != "" {
+		return t.resume(ctx)
+	}
+
+	t.exitCode = -1
 	timeout := t.Timeout
 	prefix := t.Prefix
 
@@ -135,6 +172,14 @@ func (t *BashTool) Handle(ctx context.Context) (any, error) { // TODO: output on
 	t.pidFile = fmt.Sprintf("%s.pid", t.tmpPath)
 	scriptFile := fmt.Sprintf("%s.script", t.tmpPath)
 
+	if t.TimeLimit > 0 {
+		if _, err := exec.LookPath("timeout"); err != nil {
+			t.warnf("time_limit ignored: timeout command not found, the command will not be killed in the session")
+		} else {
+			t.timeLimitEnforced = true
+		}
+	}
+
 	// Write the script to a file
 	scriptContent := t.bashScript()
 	if err := os.WriteFile(scriptFile, []byte(scriptContent), 0755); err != nil {
@@ -142,21 +187,12 @@ func (t *BashTool) Handle(ctx context.Context) (any, error) { // TODO: output on
 	}
 
 	wrappedCommand := []string{
-		"bash", scriptFile,
+		t.Shell, scriptFile,
 	}
 
-	var environment map[string]string
-	if len(t.Environment) > 0 {
-		for _, e := range t.Environment {
-			key, value, found := strings.Cut(e, "=")
-			if !found {
-				return nil, fmt.Errorf("invalid environment variable: %s", e)
-			}
-			if environment == nil {
-				environment = make(map[string]string)
-			}
-			environment[key] = value
-		}
+	environment, err := parseEnvironment(t.Environment)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create tmux session with the wrapped command and environment variables
@@ -164,9 +200,13 @@ func (t *BashTool) Handle(ctx context.Context) (any, error) { // TODO: output on
 	if err != nil {
 		return nil, err
 	}
+	pruneBashRuns(ctx)
+	job := registerBashRun(t.sessionName, t.tmpPath)
+	if t.timeLimitEnforced {
+		job.TimeLimit = t.TimeLimit
+	}
 
 	// Wait for completion or timeout
-	checkInterval := 200 * time.Millisecond
 	timeoutDuration := time.Duration(timeout) * time.Second
 
 	ticker := time.NewTicker(checkInterval)
@@ -176,22 +216,57 @@ func (t *BashTool) Handle(ctx context.Context) (any, error) { // TODO: output on
 	defer cancelTimeout()
 
 	timeoutChan := time.After(timeoutDuration)
+	lastStream := time.Now()
+
+	// Interactive programs usually sit waiting for a terminal, so stop waiting
+	// for them early rather than letting the caller hit the full timeout.
+	var graceChan <-chan time.Time
+	if t.interactiveCommand != "" {
+		graceChan = time.After(interactiveGracePeriod)
+	}
 
 outer:
 	for {
 		select {
+		case <-graceChan:
+			t.warnf("'%s' is an interactive program and is probably waiting for terminal input in session %s. "+
+				"bash is for non-interactive commands; use tmux_new_session with tmux_send_keys and tmux_capture instead",
+				t.interactiveCommand, t.sessionName)
+			if t.Partial {
+				job.watch()
+				return t.finishPartial()
+			}
+			t.timedOut = true
+			break outer
 		case <-timeoutChan:
+			if t.Partial {
+				job.watch()
+				return t.finishPartial()
+			}
+			t.timedOut = true
 			t.warnf("timed out waiting for command in session: %s, output dir: %s", t.sessionName, t.tmpPath)
 			break outer
 		case <-ctx.Done():
+			if t.Partial {
+				job.watch()
+				return t.finishPartial()
+			}
+			t.timedOut = true
 			t.warnf("timed out still running in session: %s, output dir: %s", t.sessionName, t.tmpPath)
 			break outer
 
 		case <-ticker.C:
 			// Check if command completed by looking for the .done file
 			if _, err := os.Stat(t.exitFile); err == nil {
+				if t.Stream {
+					t.streamOutput(ctx)
+				}
 				break outer
 			}
+			if t.Stream && time.Since(lastStream) >= streamInterval {
+				t.streamOutput(ctx)
+				lastStream = time.Now()
+			}
 			// Also check if session still exists (backup check)
 			if !sessionExists(ctx, t.sessionName) {
 				t.warnf("session %s does not exist, command may have failed check output dir %s", t.sessionName, t.tmpPath)
@@ -200,17 +275,146 @@ outer:
 		}
 	}
 
+	if t.timedOut && t.KillOnTimeout {
+		// ctx may be the reason we stopped waiting, so it cannot be used to clean up
+		t.killRun(context.WithoutCancel(ctx))
+	}
+
 	return t.finish(ctx)
 }
 
+// killGracePeriod is how long a command gets to exit after SIGTERM before its
+// session is killed.
+const killGracePeriod = 2 * time.Second
+
+// killRun sends SIGTERM to the command's process group so its children can
+// clean up, waits up to killGracePeriod for it to exit, then kills the session.
+func (t *BashTool) killRun(ctx context.Context) {
+	if pidBytes, err := os.ReadFile(t.pidFile); err != nil {
+		t.warnf("failed to read pid file %s, killing session without SIGTERM: %v", t.pidFile, err)
+	} else if pid, err := strconv.Atoi(strings.TrimSpace(string(pidBytes))); err != nil {
+		t.warnf("invalid pid file %s, killing session without SIGTERM: %v", t.pidFile, err)
+	} else {
+		// tmux starts each pane's command as a process group leader, so the
+		// negative pid signals the script together with everything it started
+		if err := syscall.Kill(-pid, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+			t.warnf("failed to send SIGTERM to command (pid %d): %v", pid, err)
+		}
+		deadline := time.Now().Add(killGracePeriod)
+		for time.Now().Before(deadline) && syscall.Kill(-pid, 0) == nil {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	if err := killSession(ctx, t.sessionName); err != nil && sessionExists(ctx, t.sessionName) {
+		t.warnf("failed to kill session %s: %v", t.sessionName, err)
+		return
+	}
+	t.killed = true
+	t.warnf("KILLED command and session %s because kill_on_timeout is set", t.sessionName)
+}
+
+// resume waits for a command started by an earlier call and returns its output.
+func (t *BashTool) resume(ctx context.Context) (any, error) {
+	run, err := locateBashRun(ctx, t.Continuation)
+	if err != nil {
+		return nil, mcpcommon.Errorf(mcpcommon.NotFound, "unknown continuation token %s: %w", t.Continuation, err)
+	}
+	t.exitCode = -1
+	t.sessionName = run.SessionName
+	t.tmpPath = run.TmpPath
+	t.exitFile = run.exitFile()
+	t.outputFile = run.outputFile()
+	t.pidFile = run.pidFile()
+	if run.TimeLimit > 0 {
+		t.TimeLimit = run.TimeLimit
+		t.timeLimitEnforced = true
+	}
+
+	run.watch()
+
+	timer := time.NewTimer(time.Duration(t.Timeout) * time.Second)
+	defer timer.Stop()
+
+	select {
+	case <-run.done:
+		return t.finish(ctx)
+	case <-timer.C:
+		return t.finishPartial()
+	case <-ctx.Done():
+		return t.finishPartial()
+	}
+}
+
+// finishPartial returns the output produced so far by a command that is still running.
+func (t *BashTool) finishPartial() (interface{}, error) {
+	lines := t.filter(readLines(t.outputFile))
+	t.displayLines(&t.resultBuf, lines)
+
+	if t.Structured {
+		t.timedOut = true
+		result := t.structuredResult()
+		result.Continuation = t.sessionName
+		return result, nil
+	}
+
+	var fullOutput strings.Builder
+	if t.warnBuf.Len() > 0 {
+		fullOutput.WriteString(t.warnBuf.String())
+	}
+	fullOutput.WriteString(t.resultBuf.String())
+	fmt.Fprintf(&fullOutput, "command still running in session %s, output so far is shown above\n", t.sessionName)
+	fmt.Fprintf(&fullOutput, "call again with continuation=%s to fetch the complete output\n", t.sessionName)
+	return fullOutput.String(), nil
+}
+
+// streamInterval is how often the bash tool reports new output when stream is set.
+const streamInterval = time.Second
+
+// streamTailLines is how many of the newest lines a progress notification carries.
+const streamTailLines = 20
+
+// streamOutput sends the complete lines written since the last call as a
+// progress notification. The progress is the number of lines so far.
+func (t *BashTool) streamOutput(ctx context.Context) {
+	f, err := os.Open(t.outputFile)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if _, err := f.Seek(t.streamOffset, io.SeekStart); err != nil {
+		return
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return
+	}
+	end := strings.LastIndexByte(string(data), '\n')
+	if end < 0 {
+		return
+	}
+	t.streamOffset += int64(end + 1)
+	lines := strings.Split(string(data[:end]), "\n")
+	newLines := len(lines)
+	t.streamedLines += newLines
+	if len(lines) > streamTailLines {
+		lines = lines[len(lines)-streamTailLines:]
+	}
+	mcpcommon.NotifyProgress(ctx, t.streamedLines, 0, fmt.Sprintf("%d output lines so far (%d new), newest:\n%s",
+		t.streamedLines, newLines, strings.Join(lines, "\n")))
+}
+
 func (t *BashTool) warnf(format string, args ...interface{}) {
-	fmt.Fprint(&t.warnBuf, "WARN: ")
-	fmt.Fprintf(&t.warnBuf, format, args...)
-	fmt.Fprint(&t.warnBuf, "\n")
+	warning := fmt.Sprintf(format, args...)
+	t.warnings = append(t.warnings, warning)
+	fmt.Fprintf(&t.warnBuf, "WARN: %s\n", warning)
 }
 
 func (t *BashTool) finish(ctx context.Context) (interface{}, error) {
 	t.handleCompletedCommand(ctx)
+	if t.Structured {
+		return t.structuredResult(), nil
+	}
 	var fullOutput strings.Builder
 	if t.warnBuf.Len() > 0 {
 		fullOutput.WriteString(t.warnBuf.String())
@@ -218,47 +422,124 @@ func (t *BashTool) finish(ctx context.Context) (interface{}, error) {
 	if t.resultBuf.Len() > 0 {
 		fullOutput.WriteString(t.resultBuf.String())
 	}
-	if t.returnError {
+	if t.returnError || t.killed {
 		return nil, errors.New(fullOutput.String())
 	}
 	return fullOutput.String(), nil
 }
 
-var bashTemplate = template.Must(template.New("bashScript").Parse(`
+func (t *BashTool) structuredResult() *BashResult {
+	return &BashResult{
+		SessionName: t.sessionName,
+		ExitCode:    t.exitCode,
+		TimedOut:    t.timedOut,
+		Output:      t.resultBuf.String(),
+		Warnings:    t.warnings,
+	}
+}
+
+// shellTemplates wrap the command for each supported shell: they record the
+// wrapper's pid, run the command with stderr merged into stdout, tee its output
+// to the output file and write the command's exit code (not tee's) to the exit
+// file. The TERM trap keeps the wrapper alive when kill_on_timeout signals the
+// process group, so the command can clean up and its exit code is still
+// recorded; the trap is reset in the command itself. fish has no equivalent,
+// so a killed fish command leaves no exit code.
+var shellTemplates = map[string]*template.Template{
+	"bash": template.Must(template.New("bash").Parse(`
 set -uo pipefail
 cd {{.WorkingDirectory}}
 echo $$ > {{.PidFile}}
-({{.Command}}) 2>&1 | tee {{.OutputFile}}
+trap ':' TERM
+{{if .TimeLimit}}timeout {{.TimeLimit}} bash -uo pipefail -c {{.QuotedCommand}}{{else}}({{.Command}}){{end}} 2>&1 | tee {{.OutputFile}}
 EXIT_CODE=${PIPESTATUS[0]}
 echo $EXIT_CODE > {{.ExitFile}}
-`))
+`)),
+	"zsh": template.Must(template.New("zsh").Parse(`
+set -uo pipefail
+cd {{.WorkingDirectory}}
+echo $$ > {{.PidFile}}
+trap ':' TERM
+{{if .TimeLimit}}timeout {{.TimeLimit}} zsh -uo pipefail -c {{.QuotedCommand}}{{else}}(trap - TERM; {{.Command}}){{end}} 2>&1 | tee {{.OutputFile}}
+EXIT_CODE=${pipestatus[1]}
+echo $EXIT_CODE > {{.ExitFile}}
+`)),
+	// sh has no PIPESTATUS, so the left side of the pipe records the exit code
+	// itself, renaming it into place only once tee has written all the output
+	"sh": template.Must(template.New("sh").Parse(`
+set -u
+cd {{.WorkingDirectory}}
+echo $$ > {{.PidFile}}
+trap ':' TERM
+{ trap ':' TERM; {{if .TimeLimit}}timeout {{.TimeLimit}} sh -u -c {{.QuotedCommand}}{{else}}({{.Command}}){{end}} 2>&1; echo $? > {{.ExitFile}}.tmp; } | tee {{.OutputFile}}
+mv {{.ExitFile}}.tmp {{.ExitFile}}
+`)),
+	"fish": template.Must(template.New("fish").Parse(`
+cd {{.WorkingDirectory}}
+echo $fish_pid > {{.PidFile}}
+{{if .TimeLimit}}timeout {{.TimeLimit}} fish -c {{.QuotedCommand}}{{else}}begin
+{{.Command}}
+end{{end}} 2>&1 | tee {{.OutputFile}}
+echo $pipestatus[1] > {{.ExitFile}}
+`)),
+}
+
+// supportedShells lists the keys of shellTemplates for error messages.
+const supportedShells = "bash, zsh, sh, fish"
 
 func (t *BashTool) bashScript() string {
+	var timeLimit string
+	if t.timeLimitEnforced {
+		timeLimit = strconv.FormatFloat(t.TimeLimit, 'f', -1, 64)
+	}
+
 	var script strings.Builder
-	err := bashTemplate.Execute(&script, map[string]interface{}{
+	err := shellTemplates[filepath.Base(t.Shell)].Execute(&script, map[string]interface{}{
 		"WorkingDirectory": strconv.Quote(t.WorkingDirectory),
 		"Command":          t.Command,
+		"QuotedCommand":    shellQuote(t.Command),
+		"TimeLimit":        timeLimit,
 		"OutputFile":       strconv.Quote(t.outputFile),
 		"ExitFile":         strconv.Quote(t.exitFile),
 		"PidFile":          strconv.Quote(t.pidFile),
 	})
 	if err != nil {
-		panic(fmt.Sprintf("failed to generate bash script: %v", err))
+		panic(fmt.Sprintf("failed to generate %s script: %v", t.Shell, err))
 	}
 	return script.String()
 }
 
+// timeLimitExitCode is the exit code coreutils timeout uses when it kills the command.
+const timeLimitExitCode = "124"
+
+// shellQuote quotes s as a single bash word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 func (t *BashTool) validateArgs() error {
 	t.Command = strings.TrimSpace(t.Command)
-	if t.LineBudget == 0 {
-		t.LineBudget = 100
+	if t.Shell == "" {
+		t.Shell = "bash"
+	}
+	if _, ok := shellTemplates[filepath.Base(t.Shell)]; !ok {
+		return mcpcommon.Errorf(mcpcommon.InvalidArgument, "unsupported shell %q, supported shells are %s", t.Shell, supportedShells)
+	}
+	if _, err := exec.LookPath(t.Shell); err != nil {
+		return mcpcommon.Errorf(mcpcommon.InvalidArgument, "shell %q not found on PATH: %w", t.Shell, err)
 	}
 	err := t.checkScript()
 	if err != nil {
 		return err
 	}
-	if t.Command == "" {
-		return fmt.Errorf("command is required")
+	if t.TimeLimit < 0 {
+		return mcpcommon.Errorf(mcpcommon.InvalidArgument, "time_limit must not be negative")
+	}
+	if t.Command == "" && t.Continuation == "" {
+		return mcpcommon.Errorf(mcpcommon.InvalidArgument, "command is required")
+	}
+	if t.KillOnTimeout && t.Partial {
+		return mcpcommon.Errorf(mcpcommon.InvalidArgument, "kill_on_timeout cannot be combined with partial, which keeps the command running")
 	}
 	if t.WorkingDirectory == "" {
 		// Default to current working directory
@@ -269,25 +550,49 @@ func (t *BashTool) validateArgs() error {
 		t.WorkingDirectory = cwd
 	}
 	if _, err := os.Stat(t.WorkingDirectory); os.IsNotExist(err) {
-		return fmt.Errorf("working_directory does not exist: %s", t.WorkingDirectory)
+		return mcpcommon.Errorf(mcpcommon.InvalidArgument, "working_directory does not exist: %s", t.WorkingDirectory)
+	}
+	return t.validateOutputArgs()
+}
+
+// validateOutputArgs checks the arguments that filter a command's output and
+// compiles its grep patterns. tmux_bash_reconnect shares it.
+func (t *BashTool) validateOutputArgs() error {
+	if t.LineBudget <= 0 {
+		t.LineBudget = 100
 	}
 	if t.Grep != "" {
 		var err error
-		t.compiledGrep, err = regexp.Compile(t.Grep)
+		t.compiledGrep, err = t.compileGrepPattern(t.Grep)
 		if err != nil {
-			return fmt.Errorf("invalid grep pattern: %w", err)
+			return mcpcommon.Errorf(mcpcommon.InvalidArgument, "invalid grep pattern: %w", err)
 		}
 	}
+	if t.GrepBefore < 0 || t.GrepAfter < 0 {
+		return mcpcommon.Errorf(mcpcommon.InvalidArgument, "grep_before and grep_after must not be negative")
+	}
 	if t.GrepExclude != "" {
 		var err error
-		t.compiledGrepExclude, err = regexp.Compile(t.GrepExclude)
+		t.compiledGrepExclude, err = t.compileGrepPattern(t.GrepExclude)
 		if err != nil {
-			return fmt.Errorf("invalid grep_exclude pattern: %w", err)
+			return mcpcommon.Errorf(mcpcommon.InvalidArgument, "invalid grep_exclude pattern: %w", err)
 		}
 	}
 	return nil
 }
 
+// compileGrepPattern compiles a grep or grep_exclude pattern according to
+// grep_fixed and grep_ignore_case.
+func (t *BashTool) compileGrepPattern(pattern string) (*regexp.Regexp, error) {
+	if t.GrepFixed {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if t.GrepIgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
 type Line struct {
 	Number            int
 	Content           string
@@ -427,6 +732,10 @@ func (t *BashTool) contextualize(lines []Line) {
 	if !t.hasGrep() {
 		return
 	}
+	if t.GrepBefore > 0 || t.GrepAfter > 0 {
+		t.addFixedContext(lines)
+		return
+	}
 
 	remaining := t.LineBudget
 	for _, l := range lines {
@@ -470,6 +779,19 @@ func (t *BashTool) contextualize(lines []Line) {
 	}
 }
 
+// addFixedContext selects GrepBefore lines before and GrepAfter lines after
+// each grep match, like grep -B and -A.
+func (t *BashTool) addFixedContext(lines []Line) {
+	for i, line := range lines {
+		if !line.SelectedByGrep {
+			continue
+		}
+		for j := max(0, i-t.GrepBefore); j <= min(len(lines)-1, i+t.GrepAfter); j++ {
+			lines[j].SelectedForOutput = true
+		}
+	}
+}
+
 func (t *BashTool) displayLines(w io.Writer, lines []Line) (outputCount int, totalCount int) {
 	usingGrep := t.hasGrep()
 
@@ -527,7 +849,15 @@ func (t *BashTool) handleCompletedCommand(ctx context.Context) {
 		t.returnError = true
 	} else {
 		exitCode := strings.TrimSpace(string(exitCodeBytes))
-		if exitCode != "0" {
+		if code, err := strconv.Atoi(exitCode); err == nil {
+			t.exitCode = code
+		}
+		if exitCode == timeLimitExitCode && t.timeLimitEnforced {
+			t.timedOut = true
+			t.warnf("command was KILLED after reaching its time limit of %s seconds (exit code %s)",
+				strconv.FormatFloat(t.TimeLimit, 'f', -1, 64), exitCode)
+			t.returnError = true
+		} else if exitCode != "0" {
 			t.warnf("command FAILED with exit code: %s", exitCode)
 			t.returnError = true
 		} else {
@@ -555,7 +885,10 @@ func (t *BashTool) handleCompletedCommand(ctx context.Context) {
 func sessionExists(ctx context.Context, sessionName string) bool {
 	_, err := runTmuxCommand(ctx, "has-session", "-t", sessionName)
 	if err != nil {
-		if strings.Contains(err.Error(), "can't find session") || strings.Contains(err.Error(), "no server running") {
+		// "no current target" and "server exited unexpectedly" are reported when
+		// the server is shutting down because the last session just exited
+		if strings.Contains(err.Error(), "can't find session") || strings.Contains(err.Error(), "no server running") ||
+			strings.Contains(err.Error(), "no current target") || strings.Contains(err.Error(), "server exited unexpectedly") {
 			return false
 		} else {
 			panic(fmt.Sprintf("failed to check session existence: %v", err))
@@ -564,8 +897,185 @@ func sessionExists(ctx context.Context, sessionName string) bool {
 	return true
 }
 
+// interactiveCommands lists programs that expect a terminal and will not
+// finish on their own when run through the bash tool.
+var interactiveCommands = map[string]struct{}{
+	"vi": {}, "vim": {}, "nvim": {}, "nano": {}, "emacs": {},
+	"less": {}, "more": {}, "man": {},
+	"top": {}, "htop": {},
+	"ssh": {}, "telnet": {}, "ftp": {},
+	"python": {}, "python3": {}, "node": {}, "irb": {},
+}
+
+// interactiveGracePeriod is how long a known interactive command may run
+// before the bash tool gives up waiting for it.
+const interactiveGracePeriod = 3 * time.Second
+
+// commandSegment is one simple command of a script.
+type commandSegment struct {
+	words []string
+	piped bool // stdin is the output of the previous command
+}
+
+// splitCommands splits script into simple commands at |, ||, &&, ;, & and
+// newlines, and each command into words. Separators and spaces inside quotes
+// or escaped with a backslash are part of the word, and quotes are removed.
+func splitCommands(script string) []commandSegment {
+	var segments []commandSegment
+	var current commandSegment
+	var word strings.Builder
+	inWord := false
+	endWord := func() {
+		if inWord {
+			current.words = append(current.words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endSegment := func(piped bool) {
+		endWord()
+		segments = append(segments, current)
+		current = commandSegment{piped: piped}
+	}
+
+	var quote byte
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' && i+1 < len(script) {
+				i++
+				word.WriteByte(script[i])
+			} else {
+				word.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote, inWord = c, true
+		case c == '\\' && i+1 < len(script):
+			i++
+			word.WriteByte(script[i])
+			inWord = true
+		case c == '&' && (i > 0 && script[i-1] == '>' || i+1 < len(script) && script[i+1] == '>'):
+			// 2>&1 and &> redirect rather than separate commands
+			word.WriteByte(c)
+			inWord = true
+		case c == '|' || c == '&':
+			doubled := i+1 < len(script) && script[i+1] == c
+			if doubled {
+				i++
+			}
+			endSegment(c == '|' && !doubled)
+		case c == ';' || c == '\n':
+			endSegment(false)
+		case c == ' ' || c == '\t':
+			endWord()
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	endSegment(false)
+	return segments
+}
+
+// interpreters are the interactiveCommands that only start a prompt when run
+// without arguments on a terminal.
+var interpreters = map[string]struct{}{
+	"python": {}, "python3": {}, "node": {}, "irb": {},
+}
+
+// findInteractiveCommand returns the first known interactive program the
+// script runs, or "" if there is none.
+func findInteractiveCommand(script string) string {
+	for _, segment := range splitCommands(script) {
+		words := segment.words
+		for len(words) > 0 {
+			word := words[0]
+			if strings.Contains(word, "=") || word == "sudo" || word == "env" || word == "exec" || word == "command" {
+				words = words[1:]
+				continue
+			}
+			break
+		}
+		if len(words) == 0 {
+			continue
+		}
+		name := filepath.Base(words[0])
+		if _, ok := interactiveCommands[name]; !ok {
+			continue
+		}
+		// Interpreters are only interactive without a script, -c argument or
+		// redirection, and not when another command's output is piped in.
+		if _, ok := interpreters[name]; ok && (len(words) > 1 || segment.piped) {
+			continue
+		}
+		// top -b prints and exits like any other command.
+		if name == "top" && hasShortFlag(words[1:], 'b') {
+			continue
+		}
+		if name == "ssh" && !sshOpensSession(words[1:]) {
+			continue
+		}
+		return name
+	}
+	return ""
+}
+
+// hasShortFlag reports whether args include the single-letter flag, alone or
+// combined with others (-b, -bn1).
+func hasShortFlag(args []string, flag byte) bool {
+	for _, arg := range args {
+		if len(arg) > 1 && arg[0] == '-' && arg[1] != '-' && strings.IndexByte(arg[1:], flag) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// sshOptionsWithArgument are the ssh flags that take the next word as their value.
+const sshOptionsWithArgument = "BbcDEeFIiJLlmOopQRSWw"
+
+// sshOptionsWithoutSession are the ssh flags that print something or control a
+// master connection and exit instead of logging in: -V, -G, -Q and -O.
+const sshOptionsWithoutSession = "GOQV"
+
+// sshOpensSession reports whether ssh with these arguments logs in and waits for
+// terminal input, that is it neither runs a remote command after the
+// destination host nor exits at once because of a flag like -V.
+func sshOpensSession(args []string) bool {
+	operands := 0
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			operands += len(args) - i - 1
+			break
+		}
+		if len(arg) > 1 && arg[0] == '-' {
+			// The first flag taking a value takes the rest of the word, or the
+			// next word if it ends it (-vp 22 but -p22)
+			for j := 1; j < len(arg); j++ {
+				if strings.IndexByte(sshOptionsWithoutSession, arg[j]) >= 0 {
+					return false
+				}
+				if strings.IndexByte(sshOptionsWithArgument, arg[j]) >= 0 {
+					if j == len(arg)-1 {
+						i++
+					}
+					break
+				}
+			}
+			continue
+		}
+		operands++
+	}
+	return operands <= 1
+}
+
 func (t *BashTool) checkScript() error {
 	script := strings.TrimSpace(t.Command)
+	t.interactiveCommand = findInteractiveCommand(script)
 	if strings.HasSuffix(script, "2>&1") {
 		t.warnf("stderr will always be returned, you do not need to specify 2>&1")
 	}
@@ -0,0 +1,45 @@
+package tmuxmcp
+
+import (
+	"context"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+)
+
+func init() {
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *BashReconnectTool {
+		return &BashReconnectTool{}
+	}))
+}
+
+type BashReconnectTool struct {
+	_              mcpcommon.ToolInfo `name:"tmux_bash_reconnect" group:"tmux" title:"Reconnect to Bash Command" description:"Collect the output of a command the bash tool left running when it timed out, waiting up to timeout for it to finish. Works after the server has restarted, given the session name or the temp path bash printed" destructive:"false" readonly:"true"`
+	Session        string             `json:"session" mcp:"required" description:"Session name or temp path prefix (e.g. /tmp/tmux-bash-repo-123) reported by the bash tool"`
+	Timeout        float64            `json:"timeout" description:"Maximum seconds to wait for the command to finish" default:"10"`
+	Grep           string             `json:"grep" description:"Filter output lines containing this pattern"`
+	GrepExclude    string             `json:"grep_exclude" description:"Exclude output lines containing this pattern"`
+	GrepIgnoreCase bool               `json:"grep_ignore_case" description:"Match grep and grep_exclude case-insensitively"`
+	GrepFixed      bool               `json:"grep_fixed" description:"Treat grep and grep_exclude as literal strings rather than regexes"`
+	GrepBefore     int                `json:"grep_before" description:"Show exactly this many lines before each grep match (like grep -B)"`
+	GrepAfter      int                `json:"grep_after" description:"Show exactly this many lines after each grep match (like grep -A)"`
+	LineBudget     int                `json:"line_budget" description:"Maximum number of output lines to return, filtered like the bash tool's output" default:"100"`
+	Structured     bool               `json:"structured" description:"Return a JSON object with exit_code, timed_out, session_name, output and warnings instead of text"`
+}
+
+func (t *BashReconnectTool) Handle(ctx context.Context) (interface{}, error) {
+	bash := &BashTool{
+		Continuation:   t.Session,
+		Timeout:        t.Timeout,
+		Grep:           t.Grep,
+		GrepExclude:    t.GrepExclude,
+		GrepIgnoreCase: t.GrepIgnoreCase,
+		GrepFixed:      t.GrepFixed,
+		GrepBefore:     t.GrepBefore,
+		GrepAfter:      t.GrepAfter,
+		LineBudget:     t.LineBudget,
+		Structured:     t.Structured,
+	}
+	if err := bash.validateOutputArgs(); err != nil {
+		return nil, err
+	}
+	return bash.resume(ctx)
+}
@@ -0,0 +1,87 @@
+package tmuxmcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"github.com/stretchr/testify/assert"
+)
+
+// forgetBashRun drops a run from memory, as a server restart would.
+func forgetBashRun(sessionName string) {
+	bashRunsMu.Lock()
+	defer bashRunsMu.Unlock()
+	delete(bashRuns, sessionName)
+}
+
+func TestBashReconnectTool_Handle_BySessionAfterRestart(t *testing.T) {
+	bash := &BashTool{
+		Prefix:           "test",
+		Command:          "echo started; sleep 3; echo finished; echo noise",
+		WorkingDirectory: "/tmp",
+		Timeout:          1,
+		Partial:          true,
+	}
+	run(t, bash)
+	defer func() { _ = killSession(context.Background(), bash.sessionName) }()
+	forgetBashRun(bash.sessionName)
+
+	result, err := (&BashReconnectTool{Session: bash.sessionName, Timeout: 10, LineBudget: 100, GrepExclude: "NOISE", GrepIgnoreCase: true}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, result.(string), "started")
+	assert.Contains(t, result.(string), "finished")
+	assert.NotContains(t, result.(string), "noise")
+}
+
+func TestBashReconnectTool_Handle_ByTempPathAfterSessionExited(t *testing.T) {
+	bash := &BashTool{
+		Prefix:           "test",
+		Command:          "sleep 2; echo done-late; exit 3",
+		WorkingDirectory: "/tmp",
+		Timeout:          1,
+		Partial:          true,
+	}
+	run(t, bash)
+	forgetBashRun(bash.sessionName)
+	for sessionExists(t.Context(), bash.sessionName) {
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	result, err := (&BashReconnectTool{Session: bash.tmpPath + ".output", Timeout: 1, LineBudget: 100, Structured: true}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	structured := result.(*BashResult)
+	assert.Equal(t, 3, structured.ExitCode)
+	assert.Contains(t, structured.Output, "done-late")
+}
+
+func TestBashReconnectTool_Handle_Unknown(t *testing.T) {
+	for _, session := range []string{"no-such-session", "/tmp/no-such-bash-run"} {
+		_, err := (&BashReconnectTool{Session: session, Timeout: 1, LineBudget: 100}).Handle(t.Context())
+		assert.Error(t, err, session)
+	}
+}
+
+func TestBashReconnectTool_Handle_InvalidFilters(t *testing.T) {
+	// The filters are validated like the bash tool's, before the run is looked up
+	for _, tool := range []*BashReconnectTool{{Grep: "("}, {GrepExclude: "["}, {GrepBefore: -1}} {
+		tool.Session = "no-such-session"
+		_, err := tool.Handle(t.Context())
+		var toolErr *mcpcommon.ToolError
+		if assert.ErrorAs(t, err, &toolErr) {
+			assert.Equal(t, mcpcommon.InvalidArgument, toolErr.Code)
+		}
+	}
+}
+
+func TestScriptFromStartCommand(t *testing.T) {
+	assert.Equal(t, "/tmp/tmux-bash-x-1.script", scriptFromStartCommand("bash /tmp/tmux-bash-x-1.script\n"))
+	assert.Equal(t, "/tmp/tmux-bash-x-1.script", scriptFromStartCommand(`bash "/tmp/tmux-bash-x-1.script"`))
+	assert.Equal(t, "", scriptFromStartCommand("vim notes.txt"))
+	assert.Equal(t, "", scriptFromStartCommand(""))
+}
@@ -0,0 +1,121 @@
+package tmuxmcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *BashRuntimeTool {
+		return &BashRuntimeTool{}
+	}))
+}
+
+type BashRuntimeTool struct {
+	_       mcpcommon.ToolInfo `name:"tmux_bash_runtime" group:"tmux" title:"Bash Command Runtime" description:"Report how long a command started by the bash tool has been running, or how long it ran if it already exited" destructive:"false" readonly:"true"`
+	Session string             `json:"session" mcp:"required" description:"Session name returned by the bash tool (the continuation token)"`
+}
+
+func (t *BashRuntimeTool) Handle(ctx context.Context) (interface{}, error) {
+	run, ok := findBashRun(t.Session)
+	if !ok {
+		return nil, mcpcommon.Errorf(mcpcommon.NotFound, "no bash command known for session: %s", t.Session)
+	}
+
+	state, err := inspectBashRun(ctx, run)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case state.Exited:
+		return fmt.Sprintf("Session: %s\nStatus: exited with code %s\nRan for: %s", t.Session, state.ExitCode, state.Elapsed), nil
+	case state.Running:
+		return fmt.Sprintf("Session: %s\nStatus: running\nRunning for: %s", t.Session, state.Elapsed), nil
+	default:
+		return fmt.Sprintf("Session: %s\nStatus: not running and no exit code was recorded\nStarted: %s ago", t.Session, state.Elapsed), nil
+	}
+}
+
+// bashRunState is where a command started by the bash tool is in its life.
+// Elapsed is how long it ran if it exited, has been running if it is running,
+// and the time since it started otherwise.
+type bashRunState struct {
+	Running  bool
+	Exited   bool
+	ExitCode string
+	Elapsed  time.Duration
+}
+
+// inspectBashRun works out the state of a bash run from its pid and exit files.
+func inspectBashRun(ctx context.Context, run *bashRun) (bashRunState, error) {
+	pidInfo, err := os.Stat(run.pidFile())
+	if err != nil {
+		return bashRunState{}, fmt.Errorf("command in session %s has not started yet: %w", run.SessionName, err)
+	}
+
+	// Once the exit file holds an exit code the command is done: it ran from
+	// when the script wrote its pid until it wrote the exit code.
+	if exitCode, err := os.ReadFile(run.exitFile()); err == nil && len(strings.TrimSpace(string(exitCode))) > 0 {
+		exitInfo, err := os.Stat(run.exitFile())
+		if err != nil {
+			return bashRunState{}, fmt.Errorf("failed to stat exit file %s: %w", run.exitFile(), err)
+		}
+		return bashRunState{
+			Exited:   true,
+			ExitCode: strings.TrimSpace(string(exitCode)),
+			Elapsed:  exitInfo.ModTime().Sub(pidInfo.ModTime()).Round(time.Second),
+		}, nil
+	}
+
+	pid, err := os.ReadFile(run.pidFile())
+	if err != nil {
+		return bashRunState{}, fmt.Errorf("failed to read pid file %s: %w", run.pidFile(), err)
+	}
+	elapsed, err := processElapsed(ctx, strings.TrimSpace(string(pid)))
+	if err != nil {
+		// The process is gone without writing an exit code (e.g. the session was killed)
+		return bashRunState{Elapsed: time.Since(pidInfo.ModTime()).Round(time.Second)}, nil
+	}
+	return bashRunState{Running: true, Elapsed: elapsed}, nil
+}
+
+// processElapsed asks ps how long the given process has been running.
+func processElapsed(ctx context.Context, pid string) (time.Duration, error) {
+	output, err := exec.CommandContext(ctx, "ps", "-o", "etime=", "-p", pid).Output()
+	if err != nil {
+		return 0, fmt.Errorf("process %s not found: %w", pid, err)
+	}
+	return parseElapsed(strings.TrimSpace(string(output)))
+}
+
+// parseElapsed parses ps etime output in the form [[dd-]hh:]mm:ss.
+func parseElapsed(etime string) (time.Duration, error) {
+	var days int
+	if d, rest, found := strings.Cut(etime, "-"); found {
+		var err error
+		if days, err = strconv.Atoi(d); err != nil {
+			return 0, fmt.Errorf("invalid elapsed time %q", etime)
+		}
+		etime = rest
+	}
+
+	parts := strings.Split(etime, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid elapsed time %q", etime)
+	}
+	var seconds int
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, fmt.Errorf("invalid elapsed time %q", etime)
+		}
+		seconds = seconds*60 + n
+	}
+	return time.Duration(days)*24*time.Hour + time.Duration(seconds)*time.Second, nil
+}
@@ -0,0 +1,81 @@
+package tmuxmcp
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBashRuntimeTool_Handle_Running(t *testing.T) {
+	bash := &BashTool{
+		Prefix:           "test",
+		Command:          "sleep 30",
+		WorkingDirectory: "/tmp",
+		Timeout:          2,
+		Partial:          true,
+	}
+	run(t, bash)
+	defer func() { _ = killSession(context.Background(), bash.sessionName) }()
+
+	result, err := (&BashRuntimeTool{Session: bash.sessionName}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	resultStr := result.(string)
+	assert.Contains(t, resultStr, "Status: running")
+
+	match := regexp.MustCompile(`Running for: (\S+)`).FindStringSubmatch(resultStr)
+	if !assert.Len(t, match, 2, resultStr) {
+		return
+	}
+	elapsed, err := time.ParseDuration(match[1])
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.GreaterOrEqual(t, elapsed, 1*time.Second)
+	assert.Less(t, elapsed, 20*time.Second)
+}
+
+func TestBashRuntimeTool_Handle_Exited(t *testing.T) {
+	bash := &BashTool{
+		Prefix:           "test",
+		Command:          "sleep 1",
+		WorkingDirectory: "/tmp",
+		Timeout:          10,
+	}
+	run(t, bash)
+
+	result, err := (&BashRuntimeTool{Session: bash.sessionName}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, result.(string), "Status: exited with code 0")
+	assert.Contains(t, result.(string), "Ran for: 1s")
+}
+
+func TestBashRuntimeTool_Handle_UnknownSession(t *testing.T) {
+	_, err := (&BashRuntimeTool{Session: "no-such-session"}).Handle(t.Context())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no bash command known")
+	}
+}
+
+func TestParseElapsed(t *testing.T) {
+	tests := map[string]time.Duration{
+		"00:05":      5 * time.Second,
+		"12:34":      12*time.Minute + 34*time.Second,
+		"01:02:03":   time.Hour + 2*time.Minute + 3*time.Second,
+		"2-03:04:05": 2*24*time.Hour + 3*time.Hour + 4*time.Minute + 5*time.Second,
+	}
+	for etime, expected := range tests {
+		got, err := parseElapsed(etime)
+		assert.NoError(t, err, etime)
+		assert.Equal(t, expected, got, etime)
+	}
+
+	_, err := parseElapsed("soon")
+	assert.Error(t, err)
+}
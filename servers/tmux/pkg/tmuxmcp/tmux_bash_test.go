@@ -2,13 +2,18 @@ package tmuxmcp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -348,6 +353,158 @@ func TestBashTool_filtering(t *testing.T) {
 	}
 }
 
+func TestBashTool_grepContext(t *testing.T) {
+	tests := []struct {
+		name        string
+		contains    []string
+		notContains []string
+		BashTool
+	}{
+		{
+			name:        "before and after context around a single match",
+			contains:    []string{"*[50]: line 50", " [48]: line 48", " [49]: line 49", " [51]: line 51"},
+			notContains: []string{"line 47", "line 52"},
+			BashTool:    BashTool{Grep: "^line 50$", GrepBefore: 2, GrepAfter: 1, LineBudget: 100},
+		},
+		{
+			name:        "before context only",
+			contains:    []string{"*[50]: line 50", " [47]: line 47"},
+			notContains: []string{"line 46", "line 51"},
+			BashTool:    BashTool{Grep: "^line 50$", GrepBefore: 3, LineBudget: 100},
+		},
+		{
+			name:        "after context only",
+			contains:    []string{"*[50]: line 50", " [51]: line 51", " [53]: line 53"},
+			notContains: []string{"line 49", "line 54"},
+			BashTool:    BashTool{Grep: "^line 50$", GrepAfter: 3, LineBudget: 100},
+		},
+		{
+			name:        "context is clipped at the start and end of the output",
+			contains:    []string{"*[1]: line 1", " [2]: line 2", " [99]: line 99", "*[100]: line 100"},
+			notContains: []string{"line 3", "line 98"},
+			BashTool:    BashTool{Grep: "^line (1|100)$", GrepBefore: 1, GrepAfter: 1, LineBudget: 100},
+		},
+		{
+			name:        "overlapping context is shown once with every match marked",
+			contains:    []string{"*[20]: line 20", " [21]: line 21", "*[22]: line 22", " [23]: line 23"},
+			notContains: []string{"line 19", "line 24"},
+			BashTool:    BashTool{Grep: "^line 2[02]$", GrepAfter: 1, LineBudget: 100},
+		},
+		{
+			name:        "excluded lines are not used as context",
+			contains:    []string{"*[50]: line 50", " [48]: line 48", " [52]: line 52"},
+			notContains: []string{"line 49", "line 51", "line 47", "line 53"},
+			BashTool:    BashTool{Grep: "^line 50$", GrepExclude: "^line (49|51)$", GrepBefore: 1, GrepAfter: 1, LineBudget: 100},
+		},
+		{
+			name:        "line budget still applies to matches and context",
+			contains:    []string{"*[10]: line 10", " [91]: line 91", "*[100]: line 100"},
+			notContains: []string{"line 11", "line 50", "line 90"},
+			BashTool:    BashTool{Grep: "0$", GrepAfter: 1, LineBudget: 3},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			test.Command = "false"
+			test.WorkingDirectory = "/tmp"
+			assert.NoError(t, test.validateArgs())
+			resultLines := test.filter(testLines(100))
+			var result strings.Builder
+			test.displayLines(&result, resultLines)
+			for _, contains := range test.contains {
+				assert.Contains(t, result.String(), contains+"\n")
+			}
+			for _, notContains := range test.notContains {
+				assert.NotContains(t, result.String(), notContains+"\n")
+			}
+		})
+	}
+}
+
+func TestBashTool_grepModes(t *testing.T) {
+	lines := []string{"Error: disk full", "error: retrying", "ERROR [a.b] failed", "info: a+b done", "info: axb done"}
+	tests := []struct {
+		name        string
+		contains    []string
+		notContains []string
+		BashTool
+	}{
+		{
+			name:        "case-sensitive by default",
+			contains:    []string{"error: retrying"},
+			notContains: []string{"Error: disk full", "ERROR [a.b] failed"},
+			BashTool:    BashTool{Grep: "error"},
+		},
+		{
+			name:        "ignore case",
+			contains:    []string{"Error: disk full", "error: retrying", "ERROR [a.b] failed"},
+			notContains: []string{"info: a+b done"},
+			BashTool:    BashTool{Grep: "error", GrepIgnoreCase: true},
+		},
+		{
+			name:        "fixed string with regex metacharacters",
+			contains:    []string{"info: a+b done"},
+			notContains: []string{"info: axb done"},
+			BashTool:    BashTool{Grep: "a+b", GrepFixed: true},
+		},
+		{
+			name:        "fixed string that is an invalid regex",
+			contains:    []string{"ERROR [a.b] failed"},
+			notContains: []string{"error: retrying"},
+			BashTool:    BashTool{Grep: "[a.b", GrepFixed: true},
+		},
+		{
+			name:        "fixed and ignore case apply to grep exclude",
+			contains:    []string{"error: retrying", "Error: disk full"},
+			notContains: []string{"ERROR [a.b] failed"},
+			BashTool:    BashTool{Grep: "error", GrepExclude: "[A.B]", GrepFixed: true, GrepIgnoreCase: true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			test.Command = "false"
+			test.WorkingDirectory = "/tmp"
+			test.LineBudget = 100
+			assert.NoError(t, test.validateArgs())
+			input := make(chan Line)
+			go func() {
+				for i, content := range lines {
+					input <- Line{Number: i + 1, Content: content, SelectedForOutput: true}
+				}
+				close(input)
+			}()
+			var result strings.Builder
+			test.displayLines(&result, test.filter(input))
+			// Unmatched lines are shown as context, so only look at the lines marked as matches
+			var matched []string
+			for _, line := range strings.Split(result.String(), "\n") {
+				if strings.HasPrefix(line, "*") {
+					_, content, _ := strings.Cut(line, "]: ")
+					matched = append(matched, content)
+				}
+			}
+			for _, contains := range test.contains {
+				assert.Contains(t, matched, contains)
+			}
+			for _, notContains := range test.notContains {
+				assert.NotContains(t, matched, notContains)
+			}
+		})
+	}
+}
+
+func TestBashTool_grepInvalidRegex(t *testing.T) {
+	tool := &BashTool{Command: "true", WorkingDirectory: "/tmp", Grep: "[a.b"}
+	assert.ErrorContains(t, tool.validateArgs(), "invalid grep pattern")
+}
+
+func TestBashTool_grepContextNegative(t *testing.T) {
+	tool := &BashTool{Command: "true", WorkingDirectory: "/tmp", Grep: "x", GrepBefore: -1}
+	assert.ErrorContains(t, tool.validateArgs(), "must not be negative")
+}
+
 func testLines(n int) <-chan Line {
 	lines := make(chan Line)
 	go func() {
@@ -373,3 +530,349 @@ func runErr(t *testing.T, bc *BashTool) string {
 	assert.Error(t, err, "expected error, got", output)
 	return err.Error()
 }
+
+func TestBashTool_Handle_PartialThenContinuation(t *testing.T) {
+	tool := &BashTool{
+		Prefix:           "test",
+		Command:          "echo first-part; sleep 5; echo second-part",
+		WorkingDirectory: "/tmp",
+		Timeout:          2.5,
+		Partial:          true,
+	}
+
+	partial := run(t, tool)
+	assert.Contains(t, partial, "first-part")
+	assert.NotContains(t, partial, "second-part")
+	assert.Contains(t, partial, "continuation="+tool.sessionName)
+
+	complete := run(t, &BashTool{
+		Continuation:     tool.sessionName,
+		WorkingDirectory: "/tmp",
+		Timeout:          10,
+	})
+	assert.Contains(t, complete, "first-part")
+	assert.Contains(t, complete, "second-part")
+	assert.NotContains(t, complete, "still running")
+}
+
+func TestBashTool_Handle_UnknownContinuation(t *testing.T) {
+	errMsg := runErr(t, &BashTool{
+		Continuation:     "no-such-session",
+		WorkingDirectory: "/tmp",
+		Timeout:          1,
+	})
+	assert.Contains(t, errMsg, "unknown continuation token")
+}
+
+func TestBashTool_Handle_InteractiveCommandWarning(t *testing.T) {
+	tool := &BashTool{
+		Prefix:           "test",
+		Command:          "vi",
+		WorkingDirectory: "/tmp",
+		Timeout:          30,
+	}
+	defer func() { _ = killSession(context.Background(), tool.sessionName) }()
+
+	start := time.Now()
+	errMsg := runErr(t, tool)
+	assert.Contains(t, errMsg, "'vi' is an interactive program")
+	assert.Contains(t, errMsg, "tmux_new_session")
+	assert.Less(t, time.Since(start), 20*time.Second, "should stop waiting well before the timeout")
+}
+
+func TestBashTool_Handle_InteractiveCommandPartial(t *testing.T) {
+	tool := &BashTool{
+		Prefix:           "test",
+		Command:          "vi",
+		WorkingDirectory: "/tmp",
+		Timeout:          30,
+		Partial:          true,
+	}
+	defer func() { _ = killSession(context.Background(), tool.sessionName) }()
+
+	// With partial set the grace period hands back a continuation like a timeout
+	start := time.Now()
+	result := run(t, tool)
+	assert.Contains(t, result, "'vi' is an interactive program")
+	assert.Contains(t, result, "call again with continuation="+tool.sessionName)
+	assert.True(t, sessionExists(t.Context(), tool.sessionName), "the command should keep running")
+	assert.Less(t, time.Since(start), 20*time.Second, "should stop waiting well before the timeout")
+}
+
+func TestBashTool_Handle_TimeLimit(t *testing.T) {
+	start := time.Now()
+	errMsg := runErr(t, &BashTool{
+		Prefix:           "test",
+		Command:          "echo started; sleep 100",
+		WorkingDirectory: "/tmp",
+		Timeout:          30,
+		TimeLimit:        2,
+	})
+	assert.Contains(t, errMsg, "KILLED after reaching its time limit of 2 seconds (exit code 124)")
+	assert.Contains(t, errMsg, "started")
+	assert.Less(t, time.Since(start), 20*time.Second, "command should be killed at the time limit")
+}
+
+func TestBashTool_Handle_TimeLimitNotReached(t *testing.T) {
+	result := run(t, &BashTool{
+		Prefix:           "test",
+		Command:          `echo "it's $((6*7))"`,
+		WorkingDirectory: "/tmp",
+		Timeout:          10,
+		TimeLimit:        10,
+	})
+	assert.Contains(t, result, "it's 42")
+}
+
+func runStructured(t *testing.T, bc *BashTool) *BashResult {
+	bc.Structured = true
+	result, err := bc.Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return &BashResult{}
+	}
+	return result.(*BashResult)
+}
+
+func TestBashTool_Handle_Structured(t *testing.T) {
+	result := runStructured(t, &BashTool{
+		Prefix:           "test",
+		Command:          "echo structured-output",
+		WorkingDirectory: "/tmp",
+		Timeout:          10,
+	})
+	assert.Equal(t, 0, result.ExitCode)
+	assert.False(t, result.TimedOut)
+	assert.Contains(t, result.Output, "structured-output")
+	assert.True(t, strings.HasPrefix(result.SessionName, "test-"), result.SessionName)
+	assert.Empty(t, result.Warnings)
+}
+
+func TestBashTool_Handle_StructuredFailure(t *testing.T) {
+	// A failed command is a result, not an error
+	result := runStructured(t, &BashTool{
+		Prefix:           "test",
+		Command:          "echo about-to-fail; exit 3",
+		WorkingDirectory: "/tmp",
+		Timeout:          10,
+	})
+	assert.Equal(t, 3, result.ExitCode)
+	assert.False(t, result.TimedOut)
+	assert.Contains(t, result.Output, "about-to-fail")
+	assert.Contains(t, result.Warnings, "command FAILED with exit code: 3")
+}
+
+func TestBashTool_Handle_StructuredTimeout(t *testing.T) {
+	result := runStructured(t, &BashTool{
+		Prefix:           "test",
+		Command:          "echo waiting; sleep 100",
+		WorkingDirectory: "/tmp",
+		Timeout:          1,
+	})
+	defer func() { _ = killSession(context.Background(), result.SessionName) }()
+	assert.Equal(t, -1, result.ExitCode)
+	assert.True(t, result.TimedOut)
+	assert.NotEmpty(t, result.Warnings)
+}
+
+func TestBashTool_Handle_StructuredPartial(t *testing.T) {
+	result := runStructured(t, &BashTool{
+		Prefix:           "test",
+		Command:          "echo first-part; sleep 100",
+		WorkingDirectory: "/tmp",
+		Timeout:          1.5,
+		Partial:          true,
+	})
+	defer func() { _ = killSession(context.Background(), result.SessionName) }()
+	assert.Equal(t, -1, result.ExitCode)
+	assert.True(t, result.TimedOut)
+	assert.Contains(t, result.Output, "first-part")
+	assert.Equal(t, result.SessionName, result.Continuation)
+}
+
+func TestFindInteractiveCommand(t *testing.T) {
+	tests := []struct {
+		script   string
+		expected string
+	}{
+		{"vi", "vi"},
+		{"vim notes.txt", "vim"},
+		{"EDITOR=x sudo /usr/bin/less file", "less"},
+		{"make && top", "top"},
+		{"echo hi | cat", ""},
+		{"python3 script.py", ""},
+		{"python3", "python3"},
+		{"git log --oneline", ""},
+		{"ssh host", "ssh"},
+		{"ssh -p 2222 -i key host", "ssh"},
+		{"ssh host make", ""},
+		{"ssh -vp 2222 user@host uptime", ""},
+		{"ssh -p2222 host -- ls -l", ""},
+		{"watch -n1 date", ""},
+		{`ssh host "make; make install"`, ""},
+		{"ssh host 'ls | less'", ""},
+		{"ssh -o ConnectTimeout=5 host uptime", ""},
+		{"ssh -V", ""},
+		{"ssh -O check host", ""},
+		{"ssh -G host", ""},
+		{"ssh -t host", "ssh"},
+		{"python3 -m pytest tests/", ""},
+		{"python3 script.py --verbose 2>&1", ""},
+		{"python3 < script.py", ""},
+		{"echo 'print(1)' | python3", ""},
+		{"cat script.js | node", ""},
+		{"python3 && echo done", "python3"},
+		{`echo "run vim; then less"`, ""},
+		{`git commit -m 'fix top | less'`, ""},
+		{"top -bn1", ""},
+		{"top -n 1", "top"},
+		{"make 2>&1 && less log", "less"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, findInteractiveCommand(tt.script), tt.script)
+	}
+}
+
+func TestBashTool_Handle_ZeroLineBudget(t *testing.T) {
+	s := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true))
+	s.AddTools(mcpcommon.ReflectTool(func() *BashTool { return &BashTool{Timeout: 10, WorkingDirectory: "/tmp"} }))
+	ctx := s.WithContext(t.Context(), &testClientSession{})
+
+	// An explicit zero budget falls back to the default instead of hiding all output
+	request := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"bash","arguments":{"prefix":"test","command":"echo budget-output","line_budget":0}}}`
+	response, ok := s.HandleMessage(ctx, json.RawMessage(request)).(mcp.JSONRPCResponse)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Contains(t, response.Result.(mcp.CallToolResult).Content[0].(mcp.TextContent).Text, "budget-output")
+}
+
+func TestBashTool_Handle_Stream(t *testing.T) {
+	s := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true))
+	s.AddTools(mcpcommon.ReflectTool(func() *BashTool { return &BashTool{Timeout: 10, WorkingDirectory: "/tmp"} }))
+	session := &testClientSession{notifications: make(chan mcp.JSONRPCNotification, 100)}
+
+	request := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"bash","arguments":{"prefix":"test","command":"for i in 1 2 3; do echo stream-line-$i; sleep 1; done","stream":true},"_meta":{"progressToken":"bash"}}}`
+	ctx, cancel := context.WithTimeout(s.WithContext(t.Context(), session), 20*time.Second)
+	defer cancel()
+	response := s.HandleMessage(ctx, json.RawMessage(request))
+	data, err := json.Marshal(response)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, string(data), "stream-line-3")
+
+	var streamed strings.Builder
+	var notifications int
+	lastProgress := 0.0
+	close(session.notifications)
+	for notification := range session.notifications {
+		notifications++
+		message, _ := notification.Params.AdditionalFields["message"].(string)
+		streamed.WriteString(message + "\n")
+		progress, _ := notification.Params.AdditionalFields["progress"].(int)
+		assert.Greater(t, float64(progress), lastProgress, "progress should grow with every notification")
+		lastProgress = float64(progress)
+		assert.NotContains(t, notification.Params.AdditionalFields, "total")
+	}
+	assert.Greater(t, notifications, 1, "output should be streamed while the command runs")
+	for i := 1; i <= 3; i++ {
+		assert.Contains(t, streamed.String(), fmt.Sprintf("stream-line-%d", i))
+	}
+	assert.Contains(t, streamed.String(), "3 output lines so far")
+}
+
+func TestBashTool_Handle_StreamWithoutProgressToken(t *testing.T) {
+	// Without a client asking for progress, streaming is a no-op
+	result := run(t, &BashTool{
+		Prefix:           "test",
+		Command:          "echo one; sleep 1.5; echo two",
+		WorkingDirectory: "/tmp",
+		Timeout:          10,
+		Stream:           true,
+	})
+	assert.Contains(t, result, "one")
+	assert.Contains(t, result, "two")
+}
+
+func TestBashTool_Handle_KillOnTimeout(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "terminated")
+	tool := &BashTool{
+		Prefix:           "test",
+		Command:          fmt.Sprintf("trap 'echo cleaned-up > %s; exit 0' TERM; sleep 30 & wait", marker),
+		WorkingDirectory: "/tmp",
+		Timeout:          1,
+		KillOnTimeout:    true,
+	}
+
+	output := runErr(t, tool)
+	assert.Contains(t, output, "timed out waiting for command")
+	assert.Contains(t, output, "KILLED command and session")
+	assert.False(t, sessionExists(t.Context(), tool.sessionName), "session should have been killed")
+
+	content, err := os.ReadFile(marker)
+	if assert.NoError(t, err, "command should have received SIGTERM before the session was killed") {
+		assert.Equal(t, "cleaned-up\n", string(content))
+	}
+}
+
+func TestBashTool_KillOnTimeoutWithPartial(t *testing.T) {
+	tool := &BashTool{Command: "true", WorkingDirectory: "/tmp", Partial: true, KillOnTimeout: true}
+	assert.ErrorContains(t, tool.validateArgs(), "kill_on_timeout cannot be combined with partial")
+}
+
+func TestBashTool_Handle_Shell(t *testing.T) {
+	tests := []struct {
+		name     string
+		tool     BashTool
+		expected []string
+		asError  bool
+	}{
+		{
+			name:     "sh output",
+			tool:     BashTool{Shell: "sh", Command: `echo "bash version: ${BASH_VERSION:-none}"; echo to-stderr >&2`},
+			expected: []string{"bash version: none", "to-stderr"},
+		},
+		{
+			name:     "sh exit code of the command rather than tee",
+			tool:     BashTool{Shell: "sh", Command: "echo before-exit; exit 3"},
+			expected: []string{"before-exit", "command FAILED with exit code: 3"},
+			asError:  true,
+		},
+		{
+			name:     "sh time limit",
+			tool:     BashTool{Shell: "sh", Command: "echo started; sleep 100", TimeLimit: 1},
+			expected: []string{"started", "KILLED after reaching its time limit of 1 seconds (exit code 124)"},
+			asError:  true,
+		},
+		{
+			name:     "shell given as a path",
+			tool:     BashTool{Shell: "/bin/sh", Command: "echo by-path"},
+			expected: []string{"by-path"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tool := test.tool
+			tool.Prefix = "test"
+			tool.WorkingDirectory = "/tmp"
+			tool.Timeout = 10
+			var output string
+			if test.asError {
+				output = runErr(t, &tool)
+			} else {
+				output = run(t, &tool)
+			}
+			for _, expected := range test.expected {
+				assert.Contains(t, output, expected)
+			}
+		})
+	}
+}
+
+func TestBashTool_Shell_Invalid(t *testing.T) {
+	tool := &BashTool{Command: "true", WorkingDirectory: "/tmp", Shell: "csh"}
+	assert.ErrorContains(t, tool.validateArgs(), `unsupported shell "csh", supported shells are bash, zsh, sh, fish`)
+
+	tool = &BashTool{Command: "true", WorkingDirectory: "/tmp", Shell: "/nonexistent/zsh"}
+	assert.ErrorContains(t, tool.validateArgs(), `shell "/nonexistent/zsh" not found on PATH`)
+}
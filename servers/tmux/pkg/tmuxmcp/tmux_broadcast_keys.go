@@ -0,0 +1,64 @@
+package tmuxmcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"strings"
+)
+
+func init() {
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *BroadcastKeysTool {
+		return &BroadcastKeysTool{}
+	}))
+}
+
+type BroadcastKeysTool struct {
+	_       mcpcommon.ToolInfo `name:"tmux_broadcast_keys" group:"tmux" title:"Broadcast Keys to Tmux Sessions" description:"Send the same keys to every tmux session matching a prefix (like synchronize-panes, but across sessions) and report per-session success or failure. Hash verification is skipped for every session, so confirm must be set." destructive:"true"`
+	Prefix  string             `json:"prefix" description:"Session name prefix; keys are sent to every matching session (auto-detected from git repo if not provided)"`
+	Keys    string             `json:"keys" mcp:"required" description:"Keys to send to each session. Sent as literal text unless control is set."`
+	Control bool               `json:"control" description:"Interpret keys as tmux key names (C-c, Enter, Up, ...) instead of literal text"`
+	Enter   bool               `json:"enter" description:"Append Enter key after sending keys"`
+	Confirm bool               `json:"confirm" mcp:"required" description:"Must be true to acknowledge that keys go to every matching session without hash verification"`
+}
+
+func (t *BroadcastKeysTool) Handle(ctx context.Context) (interface{}, error) {
+	if !t.Confirm {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "confirm must be true: broadcasting sends keys to every matching session without hash verification")
+	}
+	if t.Keys == "" {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "keys parameter is required. Specify the keys to send to the sessions")
+	}
+
+	prefix := t.Prefix
+	if prefix == "" {
+		prefix = detectPrefix()
+	}
+
+	sessions, err := findSessionsByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, mcpcommon.Errorf(mcpcommon.NotFound, "no sessions found with prefix '%s'", prefix)
+	}
+
+	var report strings.Builder
+	succeeded := 0
+	for _, session := range sessions {
+		err := sendKeysToSession(ctx, SendKeysOptions{
+			SessionName: session,
+			Keys:        t.Keys,
+			Enter:       t.Enter,
+			Literal:     !t.Control,
+		})
+		if err != nil {
+			fmt.Fprintf(&report, "- %s: failed: %v\n", session, err)
+			continue
+		}
+		succeeded++
+		fmt.Fprintf(&report, "- %s: ok\n", session)
+	}
+
+	return fmt.Sprintf("Keys sent to %d of %d sessions with prefix '%s':\n%s", succeeded, len(sessions), prefix, report.String()), nil
+}
@@ -0,0 +1,105 @@
+package tmuxmcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcastKeysTool_Handle_AllSessions(t *testing.T) {
+	prefix := fmt.Sprintf("test-broadcast-%d", time.Now().UnixNano())
+
+	var sessions []string
+	for i := 0; i < 3; i++ {
+		sessionName, err := createUniqueSession(t.Context(), prefix, []string{"bash"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		sessions = append(sessions, sessionName)
+	}
+
+	tool := &BroadcastKeysTool{
+		Prefix:  prefix,
+		Keys:    "echo broadcast-marker",
+		Enter:   true,
+		Confirm: true,
+	}
+
+	result, err := tool.Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	resultStr := result.(string)
+	assert.Contains(t, resultStr, "Keys sent to 3 of 3 sessions")
+
+	for _, sessionName := range sessions {
+		assert.Contains(t, resultStr, sessionName+": ok")
+
+		ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+		err := waitForCaptureContaining(ctx, sessionName, "broadcast-marker")
+		cancel()
+		assert.NoError(t, err, "session %s did not receive the broadcast", sessionName)
+	}
+}
+
+func TestBroadcastKeysTool_Handle_RequiresConfirm(t *testing.T) {
+	tool := &BroadcastKeysTool{
+		Prefix: "test",
+		Keys:   "echo nope",
+	}
+
+	_, err := tool.Handle(t.Context())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "confirm must be true")
+	}
+}
+
+func TestBroadcastKeysTool_Handle_NoSessions(t *testing.T) {
+	tool := &BroadcastKeysTool{
+		Prefix:  "test-broadcast-nonexistent",
+		Keys:    "echo nope",
+		Confirm: true,
+	}
+
+	_, err := tool.Handle(t.Context())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no sessions found")
+	}
+}
+
+// waitForCaptureContaining polls the session until its visible content contains text.
+func waitForCaptureContaining(ctx context.Context, sessionName, text string) error {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %q in session %s", text, sessionName)
+		case <-ticker.C:
+			result, err := capture(ctx, captureOptions{Prefix: sessionName})
+			if err == nil && strings.Contains(result.Output, text) {
+				return nil
+			}
+		}
+	}
+}
+
+// waitForShellReady waits for the shell to evaluate an echo, so callers know
+// it has finished starting up and is reading input. The marker is computed so
+// the terminal echoing the typed keys does not count as output.
+func waitForShellReady(ctx context.Context, sessionName string) error {
+	err := sendKeysToSession(ctx, SendKeysOptions{
+		SessionName: sessionName,
+		Keys:        "echo shell-ready-$((40+2))",
+		Enter:       true,
+		Literal:     true,
+	})
+	if err != nil {
+		return err
+	}
+	return waitForCaptureContaining(ctx, sessionName, "shell-ready-42")
+}
@@ -4,28 +4,40 @@ import (
 	"context"
 	"fmt"
 	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"strings"
 	"time"
 )
 
 func init() {
 	Tools = append(Tools, mcpcommon.ReflectTool(func() *CaptureTool {
-		return &CaptureTool{
-			Timeout: 10.0,
-		}
+		return &CaptureTool{}
 	}))
 }
 
 type CaptureTool struct {
-	_ mcpcommon.ToolInfo `name:"tmux_capture" title:"Capture Tmux Session" description:"Capture output from tmux session with content hash" destructive:"false" readonly:"true"`
-	SessionTool
+	_ mcpcommon.ToolInfo `name:"tmux_capture" group:"tmux" title:"Capture Tmux Session" description:"Capture output from tmux session with content hash" destructive:"false" readonly:"true"`
+	PaneTool
 	WaitForChange string  `json:"wait_for_change" description:"Optional hash to wait for content to change from"`
 	Timeout       float64 `json:"timeout" description:"Maximum seconds to wait for content change" default:"10"`
+	HistoryLines  int     `json:"history_lines" description:"Also capture this many lines of scrollback above the visible screen. The hash then covers the whole captured region including history, and send keys tools verify it against the same region."`
+	IncludeColors bool    `json:"include_colors" description:"Keep ANSI color and attribute escape sequences in the output, e.g. to tell passing from failing tests. The hash is then calculated over the colored output."`
+	StartLine     int     `json:"start_line" description:"First line to return, 1-based (the first captured line if not provided). The hash still covers every captured line"`
+	EndLine       int     `json:"end_line" description:"Last line to return, inclusive (the last captured line if not provided)"`
 }
 
 func (t *CaptureTool) Handle(ctx context.Context) (interface{}, error) {
-	sessionName, err := resolveSession(ctx, t.Prefix, t.Session)
+	sessionName, err := t.resolveTarget(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("error capturing session: %v", err)
+		return nil, fmt.Errorf("error capturing session: %w", err)
+	}
+	if t.HistoryLines < 0 {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "history_lines must not be negative")
+	}
+	if t.StartLine < 0 || t.EndLine < 0 {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "start_line and end_line must not be negative")
+	}
+	if t.StartLine > 0 && t.EndLine > 0 && t.StartLine > t.EndLine {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "start_line %d is after end_line %d", t.StartLine, t.EndLine)
 	}
 
 	// If WaitForChange is specified, wait for content to change from that hash
@@ -35,7 +47,7 @@ func (t *CaptureTool) Handle(ctx context.Context) (interface{}, error) {
 			timeout = 10 // default 10 seconds
 		}
 
-		result, err := t.waitForHashChange(ctx, sessionName, t.WaitForChange, timeout)
+		result, err := t.waitForHashChange(ctx, sessionName, t.WaitForChange, timeout, t.region())
 		if err != nil {
 			return nil, fmt.Errorf("error waiting for content change: %v", err)
 		}
@@ -43,18 +55,44 @@ func (t *CaptureTool) Handle(ctx context.Context) (interface{}, error) {
 	}
 
 	// Standard capture without waiting
-	output, err := runTmuxCommand(ctx, "capture-pane", "-t", sessionName, "-p")
+	pane, err := capturePane(ctx, sessionName, t.region())
 	if err != nil {
 		return nil, fmt.Errorf("error capturing session: failed to capture session %s: %v", sessionName, err)
 	}
 
-	formatted := formatOutput(output)
-	hash := calculateHash(output)
+	formatted, err := t.format(pane.Output)
+	if err != nil {
+		return nil, err
+	}
+	hash := regionHash(pane, t.region())
 
 	return fmt.Sprintf("Session: %s\nHash: %s\n\n%s", sessionName, hash, formatted), nil
 }
 
-func (t *CaptureTool) waitForHashChange(ctx context.Context, sessionName, expectedHash string, maxWait float64) (interface{}, error) {
+func (t *CaptureTool) region() captureRegion {
+	return captureRegion{HistoryLines: t.HistoryLines, Colors: t.IncludeColors}
+}
+
+// format numbers the captured lines, keeping only the requested line range.
+func (t *CaptureTool) format(output string) (string, error) {
+	if t.StartLine == 0 && t.EndLine == 0 {
+		return formatOutput(output), nil
+	}
+	lines := strings.Split(output, "\n")
+	start, end := t.StartLine, t.EndLine
+	if start == 0 {
+		start = 1
+	}
+	if end == 0 {
+		end = len(lines)
+	}
+	if start > len(lines) || end > len(lines) {
+		return "", mcpcommon.Errorf(mcpcommon.InvalidArgument, "lines %d-%d are out of range, only %d lines were captured", start, end, len(lines))
+	}
+	return formatLines(lines[start-1:end], start), nil
+}
+
+func (t *CaptureTool) waitForHashChange(ctx context.Context, sessionName, expectedHash string, maxWait float64, region captureRegion) (interface{}, error) {
 	timeout := time.After(time.Duration(maxWait) * time.Second)
 	ticker := time.NewTicker(200 * time.Millisecond)
 	defer ticker.Stop()
@@ -63,24 +101,30 @@ func (t *CaptureTool) waitForHashChange(ctx context.Context, sessionName, expect
 		select {
 		case <-timeout:
 			// Return current state even if it hasn't changed
-			output, err := runTmuxCommand(ctx, "capture-pane", "-t", sessionName, "-p")
+			pane, err := capturePane(ctx, sessionName, region)
 			if err != nil {
 				return nil, fmt.Errorf("failed to capture session after timeout: %v", err)
 			}
-			formatted := formatOutput(output)
-			hash := calculateHash(output)
+			formatted, err := t.format(pane.Output)
+			if err != nil {
+				return nil, err
+			}
+			hash := regionHash(pane, region)
 			return fmt.Sprintf("Session: %s\nHash: %s (unchanged after %.1f seconds)\n\n%s", sessionName, hash, maxWait, formatted), nil
 
 		case <-ticker.C:
-			output, err := runTmuxCommand(ctx, "capture-pane", "-t", sessionName, "-p")
+			pane, err := capturePane(ctx, sessionName, region)
 			if err != nil {
 				continue // Skip this iteration if capture fails
 			}
 
-			currentHash := calculateHash(output)
+			currentHash := regionHash(pane, region)
 			if currentHash != expectedHash {
 				// Content has changed!
-				formatted := formatOutput(output)
+				formatted, err := t.format(pane.Output)
+				if err != nil {
+					return nil, err
+				}
 				return fmt.Sprintf("Session: %s\nHash: %s (changed from %s)\n\n%s", sessionName, currentHash, expectedHash, formatted), nil
 			}
 		}
@@ -0,0 +1,245 @@
+package tmuxmcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *CaptureDiffTool {
+		return &CaptureDiffTool{}
+	}))
+}
+
+type CaptureDiffTool struct {
+	_ mcpcommon.ToolInfo `name:"tmux_capture_diff" group:"tmux" title:"Diff Tmux Session Capture" description:"Capture a tmux session and return only what changed since an earlier capture as a unified diff, with the new content hash. Much cheaper than capturing again when watching noisy output. Identify the earlier capture by its hash (recent captures are remembered) or pass its output" destructive:"false" readonly:"true"`
+	PaneTool
+	Hash     string `json:"hash" description:"Hash of an earlier capture of this session to diff against. Captures with history or colors are diffed over the same region"`
+	Previous string `json:"previous" description:"Output of an earlier capture to diff against, with or without its [N]: line numbers. Used when no hash is given or the hash is no longer remembered"`
+	Context  int    `json:"context" description:"Number of unchanged lines to show around each change" default:"3"`
+}
+
+func (t *CaptureDiffTool) Handle(ctx context.Context) (interface{}, error) {
+	if t.Hash == "" && t.Previous == "" {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "hash or previous is required to know what to diff against")
+	}
+	if t.Context < 0 {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "context must not be negative")
+	}
+
+	var previous []string
+	from := "the previous output"
+	if output, ok := rememberedCapture(t.Hash); ok && t.Hash != "" {
+		previous = strings.Split(output, "\n")
+		from = t.Hash
+	} else if t.Previous != "" {
+		previous = unformatOutput(t.Previous)
+	} else {
+		return nil, mcpcommon.Errorf(mcpcommon.NotFound, "capture %s is no longer remembered, pass its output as previous instead", t.Hash)
+	}
+
+	sessionName, err := t.resolveTarget(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error capturing session: %w", err)
+	}
+	region := hashRegion(t.Hash)
+	pane, err := capturePane(ctx, sessionName, region)
+	if err != nil {
+		return nil, fmt.Errorf("error capturing session: failed to capture session %s: %v", sessionName, err)
+	}
+	hash := regionHash(pane, region)
+
+	diff := unifiedDiff(diffLines(previous, strings.Split(pane.Output, "\n")), t.Context)
+	if diff == "" {
+		return fmt.Sprintf("Session: %s\nHash: %s (unchanged from %s)", sessionName, hash, from), nil
+	}
+	return fmt.Sprintf("Session: %s\nHash: %s (changed from %s)\n\n%s", sessionName, hash, from, diff), nil
+}
+
+var (
+	formattedLine    = regexp.MustCompile(`^\[\d+\]:(?: (.*))?$`)
+	emptyLinesMarker = regexp.MustCompile(`^\.\.\. (\d+) empty testLines \.\.\.$`)
+)
+
+// unformatOutput turns output shown by a capture back into the captured lines,
+// undoing formatOutput's line numbers and collapsed runs of empty lines when
+// present. A leading "Session: ... Hash: ..." header is dropped.
+func unformatOutput(text string) []string {
+	if strings.HasPrefix(text, "Session: ") {
+		if _, body, found := strings.Cut(text, "\n\n"); found {
+			text = body
+		}
+	}
+	lines := strings.Split(text, "\n")
+
+	var unformatted []string
+	for _, line := range lines {
+		if match := formattedLine.FindStringSubmatch(line); match != nil {
+			unformatted = append(unformatted, match[1])
+		} else if match := emptyLinesMarker.FindStringSubmatch(line); match != nil {
+			// formatOutput shows the first empty line of the run before the marker
+			count, _ := strconv.Atoi(match[1])
+			for i := 1; i < count; i++ {
+				unformatted = append(unformatted, "")
+			}
+		} else {
+			// Not formatOutput's format after all
+			return lines
+		}
+	}
+	return unformatted
+}
+
+type diffOp struct {
+	Kind byte // ' ' for unchanged, '-' for removed, '+' for added
+	Line string
+}
+
+// maxDiffCells bounds the size of the longest common subsequence table; past it,
+// the changed region is reported as entirely replaced.
+const maxDiffCells = 4_000_000
+
+// diffLines computes a line-level diff turning a into b.
+func diffLines(a, b []string) []diffOp {
+	// Only the part between the common prefix and suffix needs the quadratic search
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, diffChanged(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// diffChanged diffs a and b by their longest common subsequence.
+func diffChanged(a, b []string) []diffOp {
+	var ops []diffOp
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// unifiedDiff formats ops as unified diff hunks with up to context unchanged
+// lines around each change. It returns "" if nothing changed.
+func unifiedDiff(ops []diffOp, context int) string {
+	// oldLines[i] and newLines[i] count the lines of each side before ops[i]
+	oldLines := make([]int, len(ops)+1)
+	newLines := make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldLines[i+1], newLines[i+1] = oldLines[i], newLines[i]
+		if op.Kind != '+' {
+			oldLines[i+1]++
+		}
+		if op.Kind != '-' {
+			newLines[i+1]++
+		}
+	}
+
+	var diff strings.Builder
+	for next := 0; next < len(ops); {
+		first := next
+		for first < len(ops) && ops[first].Kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+
+		// Extend the hunk over changes whose context would overlap
+		last := first
+		for {
+			for last < len(ops) && ops[last].Kind != ' ' {
+				last++
+			}
+			following := last
+			for following < len(ops) && ops[following].Kind == ' ' {
+				following++
+			}
+			if following == len(ops) || following-last > 2*context {
+				break
+			}
+			last = following
+		}
+
+		start := max(next, first-context)
+		end := min(len(ops), last+context)
+		fmt.Fprintf(&diff, "@@ -%s +%s @@\n",
+			hunkRange(oldLines[start], oldLines[end]-oldLines[start]),
+			hunkRange(newLines[start], newLines[end]-newLines[start]))
+		for _, op := range ops[start:end] {
+			diff.WriteByte(op.Kind)
+			diff.WriteString(op.Line)
+			diff.WriteByte('\n')
+		}
+		next = end
+	}
+	return diff.String()
+}
+
+// hunkRange formats the range of a hunk header from the number of lines before
+// the hunk and the number in it.
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
@@ -0,0 +1,151 @@
+package tmuxmcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		context  int
+		expected string
+	}{
+		{
+			name:     "unchanged",
+			old:      "a\nb\nc",
+			new:      "a\nb\nc",
+			context:  3,
+			expected: "",
+		},
+		{
+			name:     "line appended",
+			old:      "a\nb\nc",
+			new:      "a\nb\nc\nd",
+			context:  1,
+			expected: "@@ -3,1 +3,2 @@\n c\n+d\n",
+		},
+		{
+			name:     "line changed in the middle",
+			old:      "1\n2\n3\n4\n5\n6\n7",
+			new:      "1\n2\n3\nfour\n5\n6\n7",
+			context:  2,
+			expected: "@@ -2,5 +2,5 @@\n 2\n 3\n-4\n+four\n 5\n 6\n",
+		},
+		{
+			name:     "screen scrolled by one line",
+			old:      "$ make\nstep 1\nstep 2",
+			new:      "step 1\nstep 2\nstep 3",
+			context:  0,
+			expected: "@@ -1,1 +0,0 @@\n-$ make\n@@ -3,0 +3,1 @@\n+step 3\n",
+		},
+		{
+			name:     "nearby changes share a hunk",
+			old:      "a\nb\nc\nd\ne",
+			new:      "A\nb\nc\nD\ne",
+			context:  1,
+			expected: "@@ -1,5 +1,5 @@\n-a\n+A\n b\n c\n-d\n+D\n e\n",
+		},
+		{
+			name:     "distant changes get separate hunks",
+			old:      "a\nb\nc\nd\ne\nf\ng",
+			new:      "A\nb\nc\nd\ne\nf\nG",
+			context:  1,
+			expected: "@@ -1,2 +1,2 @@\n-a\n+A\n b\n@@ -6,2 +6,2 @@\n f\n-g\n+G\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ops := diffLines(strings.Split(test.old, "\n"), strings.Split(test.new, "\n"))
+			assert.Equal(t, test.expected, unifiedDiff(ops, test.context))
+		})
+	}
+}
+
+func TestUnformatOutput(t *testing.T) {
+	raw := "$ ls\nfile\n\n\n\nend\n\n"
+	lines := strings.Split(raw, "\n")
+	assert.Equal(t, lines, unformatOutput(formatOutput(raw)))
+	assert.Equal(t, lines, unformatOutput("Session: test\nHash: 1234abcd\n\n"+formatOutput(raw)))
+	assert.Equal(t, []string{"plain", "text"}, unformatOutput("plain\ntext"))
+}
+
+func TestRememberCapture_EvictsLeastRecentlyUsed(t *testing.T) {
+	rememberCapture("lru-first", "first")
+	for i := 0; i < captureHistorySize-1; i++ {
+		rememberCapture(fmt.Sprintf("lru-filler-%d", i), "filler")
+		// Using the first capture keeps it from being evicted
+		_, ok := rememberedCapture("lru-first")
+		assert.True(t, ok)
+	}
+	rememberCapture("lru-last", "last")
+
+	output, ok := rememberedCapture("lru-first")
+	assert.True(t, ok)
+	assert.Equal(t, "first", output)
+	_, ok = rememberedCapture("lru-filler-0")
+	assert.False(t, ok, "least recently used capture should have been evicted")
+}
+
+func TestCaptureDiffTool_Handle(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+	assert.NoError(t, waitForShellReady(t.Context(), sessionName))
+
+	before, err := waitForStability(t.Context(), sessionName)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = runTmuxCommand(t.Context(), "send-keys", "-t", sessionName, "echo diff-marker", "Enter")
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, waitForCaptureContaining(ctx, sessionName, "]: diff-marker"))
+	after, err := waitForStability(t.Context(), sessionName)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	t.Run("by hash", func(t *testing.T) {
+		result, err := (&CaptureDiffTool{PaneTool: PaneTool{SessionTool: SessionTool{Prefix: sessionName}}, Hash: before.Hash, Context: 0}).Handle(t.Context())
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Contains(t, result, fmt.Sprintf("(changed from %s)", before.Hash))
+		assert.Contains(t, result, "\n+diff-marker\n")
+		assert.Contains(t, result, "echo diff-marker\n")
+		assert.NotContains(t, result, "[1]:", "diff should not repeat the whole capture")
+	})
+
+	t.Run("by previous output", func(t *testing.T) {
+		result, err := (&CaptureDiffTool{PaneTool: PaneTool{SessionTool: SessionTool{Prefix: sessionName}}, Previous: before.Output, Context: 0}).Handle(t.Context())
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Contains(t, result, "(changed from the previous output)")
+		assert.Contains(t, result, "\n+diff-marker\n")
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		result, err := (&CaptureDiffTool{PaneTool: PaneTool{SessionTool: SessionTool{Prefix: sessionName}}, Hash: after.Hash, Context: 3}).Handle(t.Context())
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, fmt.Sprintf("Session: %s\nHash: %s (unchanged from %s)", sessionName, after.Hash, after.Hash), result)
+	})
+
+	t.Run("forgotten hash", func(t *testing.T) {
+		_, err := (&CaptureDiffTool{PaneTool: PaneTool{SessionTool: SessionTool{Prefix: sessionName}}, Hash: "00000000", Context: 3}).Handle(t.Context())
+		assert.ErrorContains(t, err, "no longer remembered")
+	})
+}
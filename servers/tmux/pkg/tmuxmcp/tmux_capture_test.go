@@ -1,6 +1,9 @@
 package tmuxmcp
 
 import (
+	"context"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -24,9 +27,9 @@ func TestCaptureTool_Handle_BasicCapture(t *testing.T) {
 	}
 
 	tool := &CaptureTool{
-		SessionTool: SessionTool{
+		PaneTool: PaneTool{SessionTool: SessionTool{
 			Session: sessionName,
-		},
+		}},
 	}
 
 	result, err := tool.Handle(t.Context())
@@ -86,9 +89,9 @@ func TestCaptureTool_Handle_WaitForChange_ContentChanges(t *testing.T) {
 	}()
 
 	tool := &CaptureTool{
-		SessionTool: SessionTool{
+		PaneTool: PaneTool{SessionTool: SessionTool{
 			Session: sessionName,
-		},
+		}},
 		WaitForChange: initialHash,
 		Timeout:       2.0,
 	}
@@ -129,9 +132,9 @@ func TestCaptureTool_Handle_WaitForChange_Timeout(t *testing.T) {
 	initialHash := captureResult.Hash
 
 	tool := &CaptureTool{
-		SessionTool: SessionTool{
+		PaneTool: PaneTool{SessionTool: SessionTool{
 			Session: sessionName,
-		},
+		}},
 		WaitForChange: initialHash,
 		Timeout:       0.5, // Short timeout for test
 	}
@@ -168,9 +171,9 @@ func TestCaptureTool_Handle_WaitForChange_DefaultTimeout(t *testing.T) {
 	currentHash := captureResult.Hash
 
 	tool := &CaptureTool{
-		SessionTool: SessionTool{
+		PaneTool: PaneTool{SessionTool: SessionTool{
 			Session: sessionName,
-		},
+		}},
 		WaitForChange: currentHash, // Use actual current hash so it won't change
 		Timeout:       0.5,         // Short timeout for test
 	}
@@ -192,3 +195,176 @@ func TestCaptureTool_Handle_WaitForChange_DefaultTimeout(t *testing.T) {
 		t.Errorf("Expected timeout message, got: %s", resultStr)
 	}
 }
+
+func TestCaptureTool_Handle_HistoryLines(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 30*time.Second)
+	defer cancel()
+	err = sendKeysToSession(ctx, SendKeysOptions{SessionName: sessionName, Keys: "seq -f 'history-%g' 1 100", Enter: true, Literal: true})
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, waitForCaptureContaining(ctx, sessionName, "history-100")) {
+		return
+	}
+	if _, err := waitForStability(ctx, sessionName); !assert.NoError(t, err) {
+		return
+	}
+
+	visible, err := (&CaptureTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}}}).Handle(ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotContains(t, visible.(string), "history-1\n")
+
+	result, err := (&CaptureTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}}, HistoryLines: 200}).Handle(ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	resultStr := result.(string)
+	assert.Contains(t, resultStr, "history-1\n")
+	assert.Contains(t, resultStr, "history-100\n")
+
+	// Line numbers run continuously across history and the visible screen
+	first := regexp.MustCompile(`\[(\d+)\]: history-1\n`).FindStringSubmatch(resultStr)
+	last := regexp.MustCompile(`\[(\d+)\]: history-100\n`).FindStringSubmatch(resultStr)
+	if assert.Len(t, first, 2) && assert.Len(t, last, 2) {
+		firstLine, _ := strconv.Atoi(first[1])
+		lastLine, _ := strconv.Atoi(last[1])
+		assert.Equal(t, 99, lastLine-firstLine)
+	}
+
+	// The history hash is accepted by send keys verification
+	hash := regexp.MustCompile(`Hash: (\S+)`).FindStringSubmatch(resultStr)
+	if !assert.Len(t, hash, 2) {
+		return
+	}
+	assert.True(t, strings.HasSuffix(hash[1], "-h200"), hash[1])
+	assert.NoError(t, verifySessionHash(ctx, sessionName, hash[1]))
+}
+
+func TestCaptureTool_Handle_IncludeColors(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 30*time.Second)
+	defer cancel()
+	err = sendKeysToSession(ctx, SendKeysOptions{SessionName: sessionName, Keys: `printf '\033[31mFAIL\033[0m \033[32mPASS\033[0m\n'`, Enter: true, Literal: true})
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, waitForCaptureContaining(ctx, sessionName, "FAIL PASS")) {
+		return
+	}
+	if _, err := waitForStability(ctx, sessionName); !assert.NoError(t, err) {
+		return
+	}
+
+	plain, err := (&CaptureTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}}}).Handle(ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotContains(t, plain.(string), "\x1b[")
+
+	result, err := (&CaptureTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}}, IncludeColors: true}).Handle(ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	resultStr := result.(string)
+	assert.Regexp(t, "\x1b\\[[0-9;]*31m", resultStr)
+	assert.Regexp(t, "\x1b\\[[0-9;]*32m", resultStr)
+
+	// The colored hash is accepted by send keys verification
+	hash := regexp.MustCompile(`Hash: (\S+)`).FindStringSubmatch(resultStr)
+	if !assert.Len(t, hash, 2) {
+		return
+	}
+	assert.True(t, strings.HasSuffix(hash[1], "-c"), hash[1])
+	assert.NoError(t, verifySessionHash(ctx, sessionName, hash[1]))
+}
+
+func TestFormatOutput_IgnoresEscapesOnEmptyLines(t *testing.T) {
+	output := "\x1b[31mred\x1b[0m\n\x1b[0m\n\x1b[49m  \nlast"
+	assert.Equal(t, "[1]: \x1b[31mred\x1b[0m\n[2]: \n... 2 empty testLines ...\n[4]: last", formatOutput(output))
+}
+
+func TestHashRegion(t *testing.T) {
+	for _, region := range []captureRegion{{}, {HistoryLines: 500}, {Colors: true}, {HistoryLines: 20, Colors: true}} {
+		assert.Equal(t, region, hashRegion(regionHash(paneCapture{Output: "output", Hashed: "output"}, region)))
+	}
+}
+
+func TestCaptureTool_Format_LineRange(t *testing.T) {
+	output := "one\ntwo\n\n\n\nsix\nseven"
+
+	formatted, err := (&CaptureTool{StartLine: 2, EndLine: 6}).format(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "[2]: two\n[3]: \n... 3 empty testLines ...\n[6]: six", formatted)
+
+	formatted, err = (&CaptureTool{StartLine: 6}).format(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "[6]: six\n[7]: seven", formatted)
+
+	formatted, err = (&CaptureTool{EndLine: 1}).format(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "[1]: one", formatted)
+
+	_, err = (&CaptureTool{StartLine: 5, EndLine: 8}).format(output)
+	assert.ErrorContains(t, err, "only 7 lines were captured")
+}
+
+func TestCaptureTool_Handle_LineRange(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+	if !assert.NoError(t, waitForShellReady(t.Context(), sessionName)) {
+		return
+	}
+
+	full, err := (&CaptureTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}}}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	ranged, err := (&CaptureTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}}, StartLine: 2, EndLine: 3}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The hash covers the whole pane, so it matches the full capture and verifies
+	hash := regexp.MustCompile(`Hash: (\S+)`)
+	fullHash, rangedHash := hash.FindStringSubmatch(full.(string)), hash.FindStringSubmatch(ranged.(string))
+	if !assert.Len(t, fullHash, 2) || !assert.Len(t, rangedHash, 2) {
+		return
+	}
+	assert.Equal(t, fullHash[1], rangedHash[1])
+	assert.NoError(t, verifySessionHash(t.Context(), sessionName, rangedHash[1]))
+
+	assert.NotContains(t, ranged.(string), "[1]: ")
+	assert.Contains(t, ranged.(string), "[2]: ")
+	assert.NotContains(t, ranged.(string), "[4]: ")
+
+	for _, tool := range []*CaptureTool{{StartLine: 3, EndLine: 2}, {StartLine: -1}, {StartLine: 10000}} {
+		tool.Session = sessionName
+		_, err := tool.Handle(t.Context())
+		assert.Error(t, err, "start_line=%d end_line=%d", tool.StartLine, tool.EndLine)
+	}
+}
+
+func TestRegionHash_MatchesCalculateHash(t *testing.T) {
+	// Blank panes hash like any other content rather than getting a placeholder
+	for _, content := range []string{"", "   \n\n", "$ echo hi\nhi\n"} {
+		assert.Equal(t, calculateHash(content), regionHash(paneCapture{Output: content, Hashed: content}, captureRegion{}), "%q", content)
+		assert.Len(t, calculateHash(content), hashLength, "%q", content)
+	}
+	assert.NotEqual(t, calculateHash(""), calculateHash("   \n\n"))
+}
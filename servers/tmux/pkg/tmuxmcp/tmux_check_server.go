@@ -0,0 +1,73 @@
+package tmuxmcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"strings"
+	"time"
+)
+
+func init() {
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *CheckServerTool {
+		return &CheckServerTool{}
+	}))
+}
+
+type CheckServerTool struct {
+	_     mcpcommon.ToolInfo `name:"tmux_check_server" group:"tmux" title:"Check Tmux Server" description:"Check that the tmux server is running and responsive, starting a fresh one if none is running. Reports what was found and what was done" destructive:"false"`
+	Start bool               `json:"start" description:"Start a fresh tmux server if none is running" default:"true"`
+}
+
+func (t *CheckServerTool) Handle(ctx context.Context) (interface{}, error) {
+	return ensureServer(ctx, t.Start)
+}
+
+// serverPingTimeout bounds how long a healthy tmux server may take to list its sessions.
+const serverPingTimeout = 2 * time.Second
+
+// ensureServer pings the tmux server and, if none is running and start is set, starts
+// a fresh one on the configured socket. A server that does not answer in time is
+// reported but left alone, since restarting it would kill every session it holds.
+func ensureServer(ctx context.Context, start bool) (string, error) {
+	pingCtx, cancel := context.WithTimeout(ctx, serverPingTimeout)
+	_, err := runTmuxCommand(pingCtx, "list-sessions")
+	timedOut := errors.Is(pingCtx.Err(), context.DeadlineExceeded)
+	cancel()
+	if err == nil {
+		return fmt.Sprintf("tmux server on %s is running and responsive", socketDescription()), nil
+	}
+	if timedOut {
+		return "", mcpcommon.Errorf(mcpcommon.Timeout, "tmux server on %s did not respond within %s; it may be wedged, restart it with tmux kill-server", socketDescription(), serverPingTimeout)
+	}
+	if !isServerNotRunning(err) {
+		return "", fmt.Errorf("failed to check tmux server: %w", err)
+	}
+
+	if !start {
+		return fmt.Sprintf("no tmux server is running on %s", socketDescription()), nil
+	}
+	// A server started without sessions exits immediately unless exit-empty is off
+	if _, err := runTmuxCommand(ctx, "start-server", ";", "set-option", "-g", "exit-empty", "off"); err != nil {
+		return "", fmt.Errorf("no tmux server was running on %s and starting one failed: %w", socketDescription(), err)
+	}
+	if _, err := runTmuxCommand(ctx, "list-sessions"); err != nil {
+		return "", fmt.Errorf("started a tmux server on %s but it is not responding: %w", socketDescription(), err)
+	}
+	return fmt.Sprintf("no tmux server was running on %s; started a fresh one", socketDescription()), nil
+}
+
+// isServerNotRunning reports whether err from runTmuxCommand means there is no server
+// listening on the socket, as opposed to the server failing the command.
+func isServerNotRunning(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "no server running") || strings.Contains(message, "error connecting to")
+}
+
+func socketDescription() string {
+	if testSocketPath != "" {
+		return "socket " + testSocketPath
+	}
+	return "the default socket"
+}
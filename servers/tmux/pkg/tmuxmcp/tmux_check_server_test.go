@@ -0,0 +1,67 @@
+package tmuxmcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+// useFreshSocket points the tests at a socket with no server until the test ends.
+func useFreshSocket(t *testing.T) {
+	previous := testSocketPath
+	testSocketPath = fmt.Sprintf("%s-fresh", previous)
+	t.Cleanup(func() {
+		_, _ = runTmuxCommand(context.Background(), "kill-server")
+		_ = os.Remove(testSocketPath)
+		testSocketPath = previous
+	})
+}
+
+func TestEnsureServer_StartsMissingServer(t *testing.T) {
+	useFreshSocket(t)
+
+	_, err := runTmuxCommand(t.Context(), "list-sessions")
+	assert.Error(t, err)
+	assert.True(t, isServerNotRunning(err))
+
+	status, err := ensureServer(t.Context(), true)
+	assert.NoError(t, err)
+	assert.Contains(t, status, "started a fresh one")
+
+	status, err = ensureServer(t.Context(), true)
+	assert.NoError(t, err)
+	assert.Contains(t, status, "running and responsive")
+
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	assert.NoError(t, err)
+	defer killSession(t.Context(), sessionName)
+	assert.NoError(t, waitForShellReady(t.Context(), sessionName))
+}
+
+func TestEnsureServer_NoStart(t *testing.T) {
+	useFreshSocket(t)
+
+	status, err := (&CheckServerTool{Start: false}).Handle(t.Context())
+	assert.NoError(t, err)
+	assert.Contains(t, status, "no tmux server is running")
+
+	_, err = runTmuxCommand(t.Context(), "list-sessions")
+	assert.True(t, err != nil && isServerNotRunning(err), "server should not have been started")
+}
+
+func TestNewSessionTool_EnsureServer(t *testing.T) {
+	useFreshSocket(t)
+
+	tool := &NewSessionTool{
+		SessionTool:  SessionTool{Prefix: "test"},
+		Command:      []string{"bash"},
+		MaxWait:      5,
+		EnsureServer: true,
+	}
+	result, err := tool.Handle(t.Context())
+	assert.NoError(t, err)
+	assert.Contains(t, result, "started a fresh one")
+	assert.Contains(t, result, "Session created: test-bash-")
+}
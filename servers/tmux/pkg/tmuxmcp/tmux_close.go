@@ -0,0 +1,85 @@
+package tmuxmcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"time"
+)
+
+func init() {
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *CloseTool {
+		return &CloseTool{}
+	}))
+}
+
+type CloseTool struct {
+	_ mcpcommon.ToolInfo `name:"tmux_close" group:"tmux" title:"Close Tmux Session" description:"Gracefully close a tmux session by sending its exit command, force-killing it only if it does not exit in time" destructive:"true"`
+	SessionTool
+	Hash        string  `json:"hash" mcp:"required" description:"Content hash from previous capture (required for safety)"`
+	ExitCommand string  `json:"exit_command" description:"Command sent to the program to make it exit" default:"exit"`
+	Timeout     float64 `json:"timeout" description:"Seconds to wait for the session to exit before force-killing it" default:"5"`
+}
+
+func (t *CloseTool) Handle(ctx context.Context) (interface{}, error) {
+	if t.Hash == "" {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "hash is required for safety. Please capture the session first with tmux_capture to get the current hash, then use that hash in tmux_close")
+	}
+
+	sessionName, err := resolveSession(ctx, t.Prefix, t.Session)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifySessionHash(ctx, sessionName, t.Hash); err != nil {
+		return nil, err
+	}
+
+	exitCommand := t.ExitCommand
+	if exitCommand == "" {
+		exitCommand = "exit"
+	}
+	err = sendKeysToSession(ctx, SendKeysOptions{
+		SessionName: sessionName,
+		Keys:        exitCommand,
+		Enter:       true,
+		Literal:     true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = 5
+	}
+	if waitForSessionExit(ctx, sessionName, time.Duration(timeout*float64(time.Second))) {
+		return fmt.Sprintf("Session %s exited gracefully.", sessionName), nil
+	}
+
+	if err := killSession(ctx, sessionName); err != nil {
+		return nil, fmt.Errorf("session %s did not exit after %.1f seconds and force-kill failed: %w", sessionName, timeout, err)
+	}
+	return fmt.Sprintf("Session %s did not exit after %.1f seconds and was force-killed.", sessionName, timeout), nil
+}
+
+// waitForSessionExit polls until the session is gone, reporting false if it
+// is still alive when the timeout elapses.
+func waitForSessionExit(ctx context.Context, sessionName string, timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-deadline:
+			return false
+		case <-ticker.C:
+			if !sessionExists(ctx, sessionName) {
+				return true
+			}
+		}
+	}
+}
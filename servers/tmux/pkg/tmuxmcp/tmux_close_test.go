@@ -0,0 +1,77 @@
+package tmuxmcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloseTool_Handle_GracefulExit(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 30*time.Second)
+	defer cancel()
+	if !assert.NoError(t, waitForShellReady(ctx, sessionName)) {
+		return
+	}
+	result, err := waitForStability(ctx, sessionName)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tool := &CloseTool{
+		SessionTool: SessionTool{Session: sessionName},
+		Hash:        result.Hash,
+		ExitCommand: "exit",
+		Timeout:     10,
+	}
+	closeResult, err := tool.Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, closeResult.(string), "exited gracefully")
+	assert.False(t, sessionExists(t.Context(), sessionName))
+}
+
+func TestCloseTool_Handle_ForceKillsOnTimeout(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"sleep", "30"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	result, err := capture(t.Context(), captureOptions{Prefix: sessionName})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tool := &CloseTool{
+		SessionTool: SessionTool{Session: sessionName},
+		Hash:        result.Hash,
+		ExitCommand: "exit",
+		Timeout:     0.5,
+	}
+	closeResult, err := tool.Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, closeResult.(string), "was force-killed")
+	assert.False(t, sessionExists(t.Context(), sessionName))
+}
+
+func TestCloseTool_Handle_RequiresHash(t *testing.T) {
+	tool := &CloseTool{
+		SessionTool: SessionTool{Prefix: "test"},
+	}
+
+	_, err := tool.Handle(t.Context())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "hash is required for safety")
+	}
+}
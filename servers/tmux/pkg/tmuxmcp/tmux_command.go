@@ -38,7 +38,7 @@ func runTmuxCommand(ctx context.Context, args ...string) (string, error) {
 var createdSessions = make(map[string]struct{})
 var createdSessionsMu sync.Mutex
 
-func newSession(ctx context.Context, sessionName string, command []string, environment map[string]string) error {
+func newSession(ctx context.Context, sessionName string, command []string, environment map[string]string, workingDirectory string) error {
 	createdSessionsMu.Lock()
 	defer createdSessionsMu.Unlock()
 	if _, exists := createdSessions[sessionName]; exists {
@@ -52,6 +52,10 @@ func newSession(ctx context.Context, sessionName string, command []string, envir
 		args = append(args, "-e", fmt.Sprintf("%s=%s", k, v))
 	}
 
+	if workingDirectory != "" {
+		args = append(args, "-c", workingDirectory)
+	}
+
 	if len(command) > 0 {
 		args = append(args, command...)
 	}
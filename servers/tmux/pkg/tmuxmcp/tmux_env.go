@@ -0,0 +1,143 @@
+package tmuxmcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *EnvTool {
+		return &EnvTool{}
+	}))
+}
+
+type EnvTool struct {
+	_ mcpcommon.ToolInfo `name:"tmux_env" group:"tmux" title:"Tmux Session Environment" description:"Run printenv in a tmux session's shell with hash verification and return the environment it reports, e.g. to confirm a virtualenv or PATH change took effect. The session must be at a shell prompt." destructive:"false"`
+	SessionTool
+	Hash    string   `json:"hash" mcp:"required" description:"Content hash from previous capture (required for safety)"`
+	Keys    []string `json:"keys" description:"Only report these variables (all variables if empty)"`
+	Timeout float64  `json:"timeout" description:"Maximum seconds to wait for printenv to finish" default:"10"`
+}
+
+func (t *EnvTool) Handle(ctx context.Context) (interface{}, error) {
+	if t.Hash == "" {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "hash is required for safety. Please capture the session first with tmux_capture to get the current hash, then use that hash in tmux_env")
+	}
+
+	sessionName, err := resolveSession(ctx, t.Prefix, t.Session)
+	if err != nil {
+		return nil, fmt.Errorf("error reading session environment: %w", err)
+	}
+
+	if err := verifySessionHash(ctx, sessionName, t.Hash); err != nil {
+		return nil, err
+	}
+
+	// The temp file only reserves a unique name: printenv writes to a partial
+	// file that is renamed into place once complete, so the file appears only
+	// when it holds the whole environment.
+	tmpFile, err := os.CreateTemp("/tmp", "tmux-env-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	envFile := tmpFile.Name()
+	tmpFile.Close()
+	if err := os.Remove(envFile); err != nil {
+		return nil, fmt.Errorf("failed to prepare temporary file: %w", err)
+	}
+	partialFile := envFile + ".partial"
+	defer os.Remove(envFile)
+	defer os.Remove(partialFile)
+
+	// The leading space keeps the command out of the shell history.
+	err = sendKeysToSession(ctx, SendKeysOptions{
+		SessionName: sessionName,
+		Keys: fmt.Sprintf(" printenv -0 > %s && mv %s %s",
+			strconv.Quote(partialFile), strconv.Quote(partialFile), strconv.Quote(envFile)),
+		Enter:   true,
+		Literal: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	data, err := waitForFile(ctx, envFile, time.Duration(timeout*float64(time.Second)))
+	if err != nil {
+		return nil, mcpcommon.Errorf(mcpcommon.Timeout, "printenv did not finish in session %s after %.1f seconds, is the session at a shell prompt? %v", sessionName, timeout, err)
+	}
+	env := parseEnv(string(data))
+
+	stable, err := waitForStability(ctx, sessionName)
+	if err != nil {
+		return nil, fmt.Errorf("error waiting for stability: %v", err)
+	}
+
+	return fmt.Sprintf("Session: %s\nNew Hash: %s\n\n%s", sessionName, stable.Hash, formatEnv(env, t.Keys)), nil
+}
+
+// waitForFile polls until path exists and returns its contents.
+func waitForFile(ctx context.Context, path string, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+			data, err := os.ReadFile(path)
+			if err == nil {
+				return data, nil
+			}
+			if !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+	}
+}
+
+// parseEnv parses NUL-separated KEY=value pairs as written by printenv -0.
+func parseEnv(data string) map[string]string {
+	env := make(map[string]string)
+	for _, entry := range strings.Split(data, "\x00") {
+		key, value, found := strings.Cut(entry, "=")
+		if !found || key == "" {
+			continue
+		}
+		env[key] = value
+	}
+	return env
+}
+
+// formatEnv renders env as sorted KEY=value lines, restricted to keys if any are given.
+func formatEnv(env map[string]string, keys []string) string {
+	if len(keys) == 0 {
+		for key := range env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	}
+
+	var lines []string
+	for _, key := range keys {
+		if value, ok := env[key]; ok {
+			lines = append(lines, key+"="+value)
+		} else {
+			lines = append(lines, key+" is not set")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,56 @@
+package tmuxmcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvTool_Handle_ReportsSessionEnvironment(t *testing.T) {
+	sessionName, err := createUniqueSessionWithEnv(t.Context(), "test", []string{"bash"}, map[string]string{
+		"TMUX_ENV_TEST_VAR": "hello world",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 30*time.Second)
+	defer cancel()
+	if !assert.NoError(t, waitForShellReady(ctx, sessionName)) {
+		return
+	}
+	result, err := waitForStability(ctx, sessionName)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tool := &EnvTool{
+		SessionTool: SessionTool{Session: sessionName},
+		Hash:        result.Hash,
+		Keys:        []string{"TMUX_ENV_TEST_VAR", "TMUX_ENV_TEST_MISSING"},
+		Timeout:     10,
+	}
+	envResult, err := tool.Handle(ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, envResult.(string), "TMUX_ENV_TEST_VAR=hello world")
+	assert.Contains(t, envResult.(string), "TMUX_ENV_TEST_MISSING is not set")
+	assert.NotContains(t, envResult.(string), "PATH=")
+}
+
+func TestEnvTool_Handle_RequiresHash(t *testing.T) {
+	_, err := (&EnvTool{SessionTool: SessionTool{Session: "whatever"}}).Handle(t.Context())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "hash is required")
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	env := parseEnv("A=1\x00B=x=y\x00EMPTY=\x00")
+	assert.Equal(t, map[string]string{"A": "1", "B": "x=y", "EMPTY": ""}, env)
+	assert.Equal(t, "A=1\nB=x=y\nEMPTY=", formatEnv(env, nil))
+}
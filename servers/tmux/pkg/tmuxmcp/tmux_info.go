@@ -0,0 +1,125 @@
+package tmuxmcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+func init() {
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *InfoTool {
+		return &InfoTool{}
+	}))
+}
+
+type InfoTool struct {
+	_ mcpcommon.ToolInfo `name:"tmux_info" group:"tmux" title:"Tmux Version and Capabilities" description:"Report the installed tmux version and which optional command flags it supports, so callers can avoid flags this tmux would reject" destructive:"false" readonly:"true"`
+}
+
+func (t *InfoTool) Handle(ctx context.Context) (interface{}, error) {
+	return probeTmuxInfo(ctx)
+}
+
+// TmuxInfo describes the installed tmux binary.
+type TmuxInfo struct {
+	Version string `json:"version"`
+	// Capabilities maps a command or "command -flag" to whether this tmux supports it.
+	Capabilities map[string]bool `json:"capabilities"`
+}
+
+// probedCapabilities are the commands and flags reported by tmux_info, chosen
+// because they were added in tmux releases that are still commonly installed.
+var probedCapabilities = []string{
+	"new-session -e",
+	"new-session -X",
+	"split-window -e",
+	"split-window -Z",
+	"capture-pane -e",
+	"capture-pane -J",
+	"capture-pane -N",
+	"send-keys -H",
+	"send-keys -N",
+	"display-popup",
+	"display-menu",
+}
+
+var tmuxInfo *TmuxInfo
+var tmuxInfoMu sync.Mutex
+
+// probeTmuxInfo returns the tmux version and capabilities, probing them on first use.
+// A successful probe is kept for the life of the process; a failed one is retried.
+func probeTmuxInfo(ctx context.Context) (*TmuxInfo, error) {
+	tmuxInfoMu.Lock()
+	defer tmuxInfoMu.Unlock()
+	if tmuxInfo != nil {
+		return tmuxInfo, nil
+	}
+
+	version, err := tmuxVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := listCommands(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe tmux %s capabilities: %w", version, err)
+	}
+	commands := parseCommandFlags(usage)
+
+	info := &TmuxInfo{Version: version, Capabilities: make(map[string]bool)}
+	for _, capability := range probedCapabilities {
+		command, flag, hasFlag := strings.Cut(capability, " -")
+		flags, ok := commands[command]
+		info.Capabilities[capability] = ok && (!hasFlag || strings.Contains(flags, flag))
+	}
+	tmuxInfo = info
+	return info, nil
+}
+
+// listCommands returns the output of tmux list-commands. It needs a running server,
+// so it starts a throwaway one on a private socket with no config rather than
+// touching the server that sessions live on.
+func listCommands(ctx context.Context) (string, error) {
+	dir, err := os.MkdirTemp("", "tmux-probe-")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	socket := filepath.Join(dir, "probe")
+
+	ctx, cancel := context.WithTimeout(ctx, versionProbeTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "tmux", "-S", socket, "-f", "/dev/null",
+		"new-session", "-d", ";", "list-commands").CombinedOutput()
+	_ = exec.Command("tmux", "-S", socket, "kill-server").Run()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+var usageFlags = regexp.MustCompile(`\[-([A-Za-z0-9]+)`)
+
+// parseCommandFlags maps each command in list-commands output to the flag letters
+// its usage line accepts. Usage lines look like
+// "capture-pane (capturep) [-aCeJNpPq] [-b buffer-name] [-t target-pane]".
+func parseCommandFlags(usage string) map[string]string {
+	commands := make(map[string]string)
+	for _, line := range strings.Split(usage, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var flags strings.Builder
+		for _, match := range usageFlags.FindAllStringSubmatch(line, -1) {
+			flags.WriteString(match[1])
+		}
+		commands[fields[0]] = flags.String()
+	}
+	return commands
+}
@@ -0,0 +1,31 @@
+package tmuxmcp
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseCommandFlags(t *testing.T) {
+	commands := parseCommandFlags(`capture-pane (capturep) [-aCeJNpPq] [-b buffer-name] [-E end-line] [-S start-line] [-t target-pane]
+new-session (new) [-AdDEPX] [-c start-directory] [-e environment] [-F format] [shell-command]
+kill-server
+`)
+	assert.Equal(t, "aCeJNpPqbESt", commands["capture-pane"])
+	assert.Equal(t, "AdDEPXceF", commands["new-session"])
+	assert.Contains(t, commands, "kill-server")
+	assert.Equal(t, "", commands["kill-server"])
+}
+
+func TestInfoTool(t *testing.T) {
+	result, err := (&InfoTool{}).Handle(t.Context())
+	assert.NoError(t, err)
+	info := result.(*TmuxInfo)
+	assert.NotEmpty(t, info.Version)
+	assert.Len(t, info.Capabilities, len(probedCapabilities))
+	// Every tmux this server supports can capture escape sequences
+	assert.True(t, info.Capabilities["capture-pane -e"])
+
+	cached, err := probeTmuxInfo(t.Context())
+	assert.NoError(t, err)
+	assert.Same(t, info, cached)
+}
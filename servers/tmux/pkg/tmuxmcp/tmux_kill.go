@@ -13,14 +13,15 @@ func init() {
 }
 
 type KillTool struct {
-	_ mcpcommon.ToolInfo `name:"tmux_kill" title:"Kill Tmux Session" description:"Kill a tmux session" destructive:"true"`
+	_ mcpcommon.ToolInfo `name:"tmux_kill" group:"tmux" title:"Kill Tmux Session" description:"Kill a tmux session after verifying its content hash (skipped only with the unsafe force flag)" destructive:"true"`
 	SessionTool
-	Hash string `json:"hash" mcp:"required" description:"Content hash from previous capture (required for safety)"`
+	Hash  string `json:"hash" description:"Content hash from previous capture (required for safety unless force is set)"`
+	Force bool   `json:"force" description:"UNSAFE: skip hash verification and kill the session whatever it shows now, which may not be what you last saw. Strongly prefer capturing first and passing the hash"`
 }
 
 func (t *KillTool) Handle(ctx context.Context) (any, error) {
-	if t.Hash == "" {
-		return nil, fmt.Errorf("hash is required for safety. Please capture the session first with tmux_capture to get the current hash, then use that hash in tmux_kill")
+	if t.Hash == "" && !t.Force {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "hash is required for safety. Please capture the session first with tmux_capture to get the current hash, then use that hash in tmux_kill")
 	}
 
 	sessionName, err := resolveSession(ctx, t.Prefix, t.Session)
@@ -29,8 +30,10 @@ func (t *KillTool) Handle(ctx context.Context) (any, error) {
 	}
 
 	// Verify current hash by capturing current state
-	if err := verifySessionHash(ctx, sessionName, t.Hash); err != nil {
-		return nil, err
+	if !t.Force {
+		if err := verifySessionHash(ctx, sessionName, t.Hash); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := killSession(ctx, sessionName); err != nil {
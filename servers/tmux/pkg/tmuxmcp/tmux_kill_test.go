@@ -202,3 +202,19 @@ func TestKillTool_Handle_PrefixResolution(t *testing.T) {
 		t.Errorf("Expected success message, got: %s", resultStr)
 	}
 }
+
+func TestKillTool_Handle_Force(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	// A wrong hash is ignored as well as a missing one
+	result, err := (&KillTool{SessionTool: SessionTool{Session: sessionName}, Hash: "stale", Force: true}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, result, "killed successfully")
+	assert.False(t, sessionExists(t.Context(), sessionName))
+}
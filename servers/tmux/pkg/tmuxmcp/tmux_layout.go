@@ -0,0 +1,191 @@
+package tmuxmcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *SnapshotLayoutTool {
+		return &SnapshotLayoutTool{}
+	}))
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *RestoreLayoutTool {
+		return &RestoreLayoutTool{}
+	}))
+}
+
+// LayoutSpec describes the windows and panes of a session so it can be recreated.
+type LayoutSpec struct {
+	Windows []WindowSpec `json:"windows"`
+}
+
+type WindowSpec struct {
+	Name   string     `json:"name"`
+	Layout string     `json:"layout"`
+	Panes  []PaneSpec `json:"panes"`
+}
+
+type PaneSpec struct {
+	// Command is the command the pane was started with; empty means the default shell.
+	Command          string `json:"command"`
+	WorkingDirectory string `json:"working_directory"`
+}
+
+type SnapshotLayoutTool struct {
+	_ mcpcommon.ToolInfo `name:"tmux_snapshot_layout" group:"tmux" title:"Snapshot Tmux Layout" description:"Capture the window and pane layout of a tmux session, with each pane's start command and directory, as a JSON spec for tmux_restore_layout" destructive:"false" readonly:"true"`
+	SessionTool
+}
+
+func (t *SnapshotLayoutTool) Handle(ctx context.Context) (interface{}, error) {
+	sessionName, err := resolveSession(ctx, t.Prefix, t.Session)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := snapshotLayout(ctx, sessionName)
+	if err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+func snapshotLayout(ctx context.Context, sessionName string) (*LayoutSpec, error) {
+	windows, err := runTmuxCommand(ctx, "list-windows", "-t", sessionName, "-F", "#{window_id}\t#{window_name}\t#{window_layout}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list windows of session %s: %w", sessionName, err)
+	}
+
+	spec := &LayoutSpec{}
+	for _, line := range strings.Split(strings.TrimSpace(windows), "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected list-windows output: %q", line)
+		}
+		window := WindowSpec{Name: fields[1], Layout: fields[2]}
+
+		panes, err := runTmuxCommand(ctx, "list-panes", "-t", fields[0], "-F", "#{pane_start_command}\t#{pane_current_path}")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list panes of window %s: %w", fields[0], err)
+		}
+		for _, paneLine := range strings.Split(strings.TrimSpace(panes), "\n") {
+			command, path, _ := strings.Cut(paneLine, "\t")
+			window.Panes = append(window.Panes, PaneSpec{Command: unquoteStartCommand(command), WorkingDirectory: path})
+		}
+		spec.Windows = append(spec.Windows, window)
+	}
+	return spec, nil
+}
+
+// unquoteStartCommand undoes the quoting tmux applies to a pane_start_command
+// that was given as a single shell command string, e.g. "sleep 60".
+func unquoteStartCommand(command string) string {
+	if len(command) >= 2 && strings.HasPrefix(command, `"`) && strings.HasSuffix(command, `"`) {
+		if unquoted, err := strconv.Unquote(command); err == nil {
+			return unquoted
+		}
+	}
+	return command
+}
+
+type RestoreLayoutTool struct {
+	_      mcpcommon.ToolInfo `name:"tmux_restore_layout" group:"tmux" title:"Restore Tmux Layout" description:"Create a new tmux session from a layout spec produced by tmux_snapshot_layout, recreating its windows, panes and commands" destructive:"true"`
+	Prefix string             `json:"prefix" description:"Session name prefix (auto-detected from git repo if not provided)"`
+	Spec   string             `json:"spec" mcp:"required" description:"Layout spec JSON as returned by tmux_snapshot_layout"`
+}
+
+func (t *RestoreLayoutTool) Handle(ctx context.Context) (interface{}, error) {
+	var spec LayoutSpec
+	if err := json.Unmarshal([]byte(t.Spec), &spec); err != nil {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "invalid layout spec: %w", err)
+	}
+	if len(spec.Windows) == 0 {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "layout spec has no windows")
+	}
+	for i, window := range spec.Windows {
+		if len(window.Panes) == 0 {
+			return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "window %d (%s) has no panes", i, window.Name)
+		}
+	}
+
+	sessionName, err := createUniqueSession(ctx, t.Prefix, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := restoreLayout(ctx, sessionName, &spec); err != nil {
+		_ = killSession(ctx, sessionName)
+		return nil, err
+	}
+
+	return fmt.Sprintf("Session created: %s\nRestored %d windows", sessionName, len(spec.Windows)), nil
+}
+
+func restoreLayout(ctx context.Context, sessionName string, spec *LayoutSpec) error {
+	for i, window := range spec.Windows {
+		var windowID string
+		if i == 0 {
+			output, err := runTmuxCommand(ctx, "display-message", "-t", sessionName, "-p", "#{window_id}")
+			if err != nil {
+				return fmt.Errorf("failed to find first window of session %s: %w", sessionName, err)
+			}
+			windowID = strings.TrimSpace(output)
+		} else {
+			output, err := runTmuxCommand(ctx, "new-window", "-d", "-P", "-F", "#{window_id}", "-t", sessionName+":")
+			if err != nil {
+				return fmt.Errorf("failed to create window %s: %w", window.Name, err)
+			}
+			windowID = strings.TrimSpace(output)
+		}
+
+		if window.Name != "" {
+			if _, err := runTmuxCommand(ctx, "rename-window", "-t", windowID, window.Name); err != nil {
+				return fmt.Errorf("failed to rename window %s: %w", windowID, err)
+			}
+		}
+
+		for range window.Panes[1:] {
+			if _, err := runTmuxCommand(ctx, "split-window", "-d", "-t", windowID); err != nil {
+				return fmt.Errorf("failed to split window %s: %w", window.Name, err)
+			}
+			// Re-tile after each split so later splits always have room
+			if _, err := runTmuxCommand(ctx, "select-layout", "-t", windowID, "tiled"); err != nil {
+				return fmt.Errorf("failed to tile window %s: %w", window.Name, err)
+			}
+		}
+
+		if window.Layout != "" {
+			if _, err := runTmuxCommand(ctx, "select-layout", "-t", windowID, window.Layout); err != nil {
+				return fmt.Errorf("failed to apply layout to window %s: %w", window.Name, err)
+			}
+		}
+
+		paneIDs, err := runTmuxCommand(ctx, "list-panes", "-t", windowID, "-F", "#{pane_id}")
+		if err != nil {
+			return fmt.Errorf("failed to list panes of window %s: %w", window.Name, err)
+		}
+		for j, paneID := range strings.Fields(paneIDs) {
+			if j >= len(window.Panes) {
+				break
+			}
+			pane := window.Panes[j]
+			if pane.Command == "" && pane.WorkingDirectory == "" {
+				continue
+			}
+			args := []string{"respawn-pane", "-k", "-t", paneID}
+			if pane.WorkingDirectory != "" {
+				args = append(args, "-c", pane.WorkingDirectory)
+			}
+			if pane.Command != "" {
+				args = append(args, pane.Command)
+			}
+			if _, err := runTmuxCommand(ctx, args...); err != nil {
+				return fmt.Errorf("failed to start %q in window %s: %w", pane.Command, window.Name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package tmuxmcp
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayoutTools_SnapshotAndRestore(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test-layout", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	_, err = runTmuxCommand(t.Context(), "split-window", "-h", "-t", sessionName, "-c", "/tmp", "sleep 60")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	snapshot, err := (&SnapshotLayoutTool{SessionTool: SessionTool{Session: sessionName}}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	original := snapshot.(*LayoutSpec)
+	if !assert.Len(t, original.Windows, 1) || !assert.Len(t, original.Windows[0].Panes, 2) {
+		return
+	}
+	assert.Equal(t, "bash", original.Windows[0].Panes[0].Command)
+	assert.Equal(t, "sleep 60", original.Windows[0].Panes[1].Command)
+	assert.Equal(t, "/tmp", original.Windows[0].Panes[1].WorkingDirectory)
+
+	specJSON, err := json.Marshal(original)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	result, err := (&RestoreLayoutTool{Prefix: "test-restored", Spec: string(specJSON)}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	restoredName := strings.TrimPrefix(strings.SplitN(result.(string), "\n", 2)[0], "Session created: ")
+	defer func() { _ = killSession(context.Background(), restoredName) }()
+
+	restored, err := snapshotLayout(t.Context(), restoredName)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, restored.Windows, 1) || !assert.Len(t, restored.Windows[0].Panes, 2) {
+		return
+	}
+	assert.Equal(t, original.Windows[0].Name, restored.Windows[0].Name)
+	assert.Equal(t, layoutGeometry(original.Windows[0].Layout), layoutGeometry(restored.Windows[0].Layout))
+	assert.Equal(t, "sleep 60", restored.Windows[0].Panes[1].Command)
+	assert.Equal(t, "/tmp", restored.Windows[0].Panes[1].WorkingDirectory)
+}
+
+func TestRestoreLayoutTool_Handle_InvalidSpec(t *testing.T) {
+	_, err := (&RestoreLayoutTool{Prefix: "test", Spec: `{"windows": []}`}).Handle(t.Context())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no windows")
+	}
+}
+
+var layoutPaneIDs = regexp.MustCompile(`(\d+x\d+,\d+,\d+),\d+`)
+
+// layoutGeometry strips the checksum and pane ids from a tmux layout string,
+// leaving only the pane sizes and positions.
+func layoutGeometry(layout string) string {
+	_, geometry, _ := strings.Cut(layout, ",")
+	return layoutPaneIDs.ReplaceAllString(geometry, "$1")
+}
@@ -14,7 +14,7 @@ func init() {
 }
 
 type ListTool struct {
-	_ mcpcommon.ToolInfo `name:"tmux_list" title:"List Tmux Sessions" description:"List all tmux sessions" destructive:"false" readonly:"true"`
+	_ mcpcommon.ToolInfo `name:"tmux_list" group:"tmux" title:"List Tmux Sessions" description:"List all tmux sessions" destructive:"false" readonly:"true"`
 	SessionTool
 }
 
@@ -5,32 +5,66 @@ import (
 	"fmt"
 	"github.com/semistrict/mcpservers/pkg/mcpcommon"
 	"log/slog"
+	"os"
+	"path/filepath"
 	"time"
 )
 
 func init() {
 	Tools = append(Tools, mcpcommon.ReflectTool(func() *NewSessionTool {
-		return &NewSessionTool{
-			OpenInTerminal: true,
-		}
+		return &NewSessionTool{}
 	}))
 }
 
 type NewSessionTool struct {
-	_ mcpcommon.ToolInfo `name:"tmux_new_session" title:"Create Tmux Session" description:"Create a new tmux session with optional command execution" destructive:"true"`
+	_ mcpcommon.ToolInfo `name:"tmux_new_session" group:"tmux" title:"Create Tmux Session" description:"Create a new tmux session with optional command execution" destructive:"true"`
 	SessionTool
-	Command        []string `json:"command" description:"Command and arguments to run in the session"`
-	Expect         string   `json:"contains" description:"Wait for this string to appear in output before returning"`
-	KillOthers     bool     `json:"kill_others" description:"Kill existing sessions with same prefix before creating new one"`
-	AllowMultiple  bool     `json:"allow_multiple" description:"Allow multiple sessions with same prefix"`
-	MaxWait        float64  `json:"max_wait" description:"Maximum seconds to wait for output"`
-	OpenInTerminal bool     `json:"open_in_terminal" description:"Also open a view into the session (in read-only mode) in the user's terminal" default:"true"`
+	Command          []string `json:"command" description:"Command and arguments to run in the session"`
+	Environment      []string `json:"environment" description:"Environment variables to set in the session in NAME=VALUE format"`
+	WorkingDirectory string   `json:"working_directory" description:"Directory to start the session in (defaults to the tmux server's directory)"`
+	Expect           string   `json:"contains" description:"Wait for this string to appear in output before returning"`
+	ExpectRegex      bool     `json:"contains_regex" description:"Treat contains as a Go regular expression matched against the cursor line"`
+	KillOthers       bool     `json:"kill_others" description:"Kill existing sessions with same prefix before creating new one"`
+	AllowMultiple    bool     `json:"allow_multiple" description:"Allow multiple sessions with same prefix"`
+	MaxWait          float64  `json:"max_wait" description:"Maximum seconds to wait for output"`
+	OpenInTerminal   bool     `json:"open_in_terminal" description:"Also open a view into the session (in read-only mode) in the user's terminal" default:"true"`
+	EnsureServer     bool     `json:"ensure_server" description:"Check the tmux server first and start a fresh one if none is running"`
 }
 
 func (t *NewSessionTool) Handle(ctx context.Context) (interface{}, error) {
-	maxWait := time.Duration(t.MaxWait) * time.Second
-	if maxWait == 0 {
-		maxWait = 10
+	maxWait := time.Duration(t.MaxWait * float64(time.Second))
+	if maxWait <= 0 {
+		maxWait = defaultWaitTimeout
+	}
+
+	expected, err := newExpectation(t.Expect, t.ExpectRegex)
+	if err != nil {
+		return nil, err
+	}
+
+	environment, err := parseEnvironment(t.Environment)
+	if err != nil {
+		return nil, err
+	}
+
+	workingDirectory := t.WorkingDirectory
+	if workingDirectory != "" {
+		workingDirectory, err = filepath.Abs(workingDirectory)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve working_directory: %w", err)
+		}
+		if info, err := os.Stat(workingDirectory); err != nil || !info.IsDir() {
+			return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "working_directory does not exist or is not a directory: %s", t.WorkingDirectory)
+		}
+	}
+
+	var serverStatus string
+	if t.EnsureServer {
+		status, err := ensureServer(ctx, true)
+		if err != nil {
+			return nil, err
+		}
+		serverStatus = status + "\n"
 	}
 
 	prefix := t.Prefix
@@ -57,7 +91,7 @@ func (t *NewSessionTool) Handle(ctx context.Context) (interface{}, error) {
 		}
 	}
 
-	sessionName, err := createUniqueSession(ctx, prefix, t.Command)
+	sessionName, err := createUniqueSessionInDir(ctx, prefix, t.Command, environment, workingDirectory)
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +104,7 @@ func (t *NewSessionTool) Handle(ctx context.Context) (interface{}, error) {
 			ctxWithTimeout, cancel = context.WithDeadline(ctx, time.Now().Add(maxWait))
 			defer cancel()
 		}
-		result, err := waitForExpected(ctxWithTimeout, sessionName, t.Expect)
+		result, err := waitForExpected(ctxWithTimeout, sessionName, expected)
 		if err != nil {
 			return nil, fmt.Errorf("error creating session: %v", err)
 		}
@@ -94,10 +128,10 @@ func (t *NewSessionTool) Handle(ctx context.Context) (interface{}, error) {
 	if t.OpenInTerminal {
 		if err := openSessionInTerminal(sessionName); err != nil {
 			// Don't fail the entire operation if terminal opening fails
-			return fmt.Sprintf("Session created: %s\nOutput:\n%s\n\nNote: Could not open in terminal: %v", sessionName, output, err), nil
+			return fmt.Sprintf("%sSession created: %s\nOutput:\n%s\n\nNote: Could not open in terminal: %v", serverStatus, sessionName, output, err), nil
 		}
-		return fmt.Sprintf("Session created: %s\nOpened in terminal in read-only mode\nOutput:\n%s", sessionName, output), nil
+		return fmt.Sprintf("%sSession created: %s\nOpened in terminal in read-only mode\nOutput:\n%s", serverStatus, sessionName, output), nil
 	}
 
-	return fmt.Sprintf("Session created: %s\nOutput:\n%s", sessionName, output), nil
+	return fmt.Sprintf("%sSession created: %s\nOutput:\n%s", serverStatus, sessionName, output), nil
 }
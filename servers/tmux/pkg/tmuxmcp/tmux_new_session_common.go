@@ -3,11 +3,13 @@ package tmuxmcp
 import (
 	"context"
 	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
 	"math/rand"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"time"
 )
 
@@ -19,6 +21,12 @@ func createUniqueSession(ctx context.Context, prefix string, command []string) (
 
 // createUniqueSessionWithEnv creates a new tmux session with a unique name and environment variables
 func createUniqueSessionWithEnv(ctx context.Context, prefix string, command []string, environment map[string]string) (string, error) {
+	return createUniqueSessionInDir(ctx, prefix, command, environment, "")
+}
+
+// createUniqueSessionInDir creates a new tmux session with a unique name and environment
+// variables, starting in workingDirectory (the tmux server's directory if empty)
+func createUniqueSessionInDir(ctx context.Context, prefix string, command []string, environment map[string]string, workingDirectory string) (string, error) {
 	if prefix == "" {
 		prefix = detectPrefix()
 	}
@@ -52,7 +60,7 @@ func createUniqueSessionWithEnv(ctx context.Context, prefix string, command []st
 		sessionName := fmt.Sprintf("%s-%d", baseName, randomNum)
 
 		// Try to create the session
-		err := newSession(ctx, sessionName, command, environment)
+		err := newSession(ctx, sessionName, command, environment, workingDirectory)
 
 		if err == nil {
 			// Success! Return the session name
@@ -64,6 +72,23 @@ func createUniqueSessionWithEnv(ctx context.Context, prefix string, command []st
 	return "", fmt.Errorf("failed to create unique session after 100 attempts")
 }
 
+// parseEnvironment parses NAME=VALUE entries into the map createUniqueSessionWithEnv
+// takes, returning nil if there are none.
+func parseEnvironment(entries []string) (map[string]string, error) {
+	var environment map[string]string
+	for _, e := range entries {
+		key, value, found := strings.Cut(e, "=")
+		if !found || key == "" {
+			return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "invalid environment variable %q, expected NAME=VALUE", e)
+		}
+		if environment == nil {
+			environment = make(map[string]string)
+		}
+		environment[key] = value
+	}
+	return environment, nil
+}
+
 // openSessionInTerminal opens a tmux session in read-only mode in the user's terminal
 func openSessionInTerminal(sessionName string) error {
 	// Get the user's terminal program
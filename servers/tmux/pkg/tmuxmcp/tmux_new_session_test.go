@@ -0,0 +1,78 @@
+package tmuxmcp
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSessionTool_WorkingDirectory(t *testing.T) {
+	dir := t.TempDir()
+	tool := &NewSessionTool{
+		SessionTool:      SessionTool{Prefix: "test"},
+		Command:          []string{"bash"},
+		WorkingDirectory: dir,
+		AllowMultiple:    true,
+		MaxWait:          5,
+	}
+	result, err := tool.Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, after, _ := strings.Cut(result.(string), "Session created: ")
+	sessionName, _, _ := strings.Cut(after, "\n")
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	path, err := runTmuxCommand(t.Context(), "display-message", "-t", sessionName, "-p", "#{pane_current_path}")
+	assert.NoError(t, err)
+	assert.Equal(t, dir, strings.TrimSpace(path))
+}
+
+func TestNewSessionTool_WorkingDirectoryMissing(t *testing.T) {
+	tool := &NewSessionTool{
+		SessionTool:      SessionTool{Prefix: "test"},
+		Command:          []string{"bash"},
+		WorkingDirectory: filepath.Join(t.TempDir(), "missing"),
+		AllowMultiple:    true,
+		MaxWait:          5,
+	}
+	_, err := tool.Handle(t.Context())
+	assert.ErrorContains(t, err, "working_directory does not exist or is not a directory")
+}
+
+func TestNewSessionTool_Environment(t *testing.T) {
+	tool := &NewSessionTool{
+		SessionTool:   SessionTool{Prefix: "test"},
+		Command:       []string{"bash", "-c", `echo "greeting=$MCP_GREETING"; sleep 30`},
+		Environment:   []string{"MCP_GREETING=hello=world"},
+		AllowMultiple: true,
+		MaxWait:       5,
+	}
+	result, err := tool.Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, after, _ := strings.Cut(result.(string), "Session created: ")
+	sessionName, _, _ := strings.Cut(after, "\n")
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	assert.Contains(t, result, "greeting=hello=world")
+}
+
+func TestParseEnvironment(t *testing.T) {
+	environment, err := parseEnvironment([]string{"A=1", "B=", "C=x=y"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "1", "B": "", "C": "x=y"}, environment)
+
+	environment, err = parseEnvironment(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, environment)
+
+	for _, malformed := range []string{"NOVALUE", "=value"} {
+		_, err = parseEnvironment([]string{"A=1", malformed})
+		assert.ErrorContains(t, err, "expected NAME=VALUE", malformed)
+	}
+}
@@ -0,0 +1,99 @@
+package tmuxmcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"os"
+	"time"
+)
+
+func init() {
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *PasteTool {
+		return &PasteTool{}
+	}))
+}
+
+type PasteTool struct {
+	_ mcpcommon.ToolInfo `name:"tmux_paste" group:"tmux" title:"Paste Text into Tmux Session" description:"Paste text into a tmux session through a tmux buffer with hash verification, then wait for output to stabilize and return it. Much faster and more reliable than send keys for large inputs such as a whole config file pasted into an editor" destructive:"true"`
+	PaneTool
+	Hash      string  `json:"hash" mcp:"required" description:"Content hash from previous capture (required for safety)"`
+	Text      string  `json:"text" mcp:"required" description:"Text to paste. Newlines are pasted as carriage returns, as if typed"`
+	Bracketed bool    `json:"bracketed" description:"Use bracketed paste if the program in the pane requested it, so editors and shells treat the text as pasted rather than typed"`
+	Enter     bool    `json:"enter" description:"Append Enter key after pasting"`
+	MaxWait   float64 `json:"max_wait" description:"Maximum seconds to wait for output to stabilize after pasting"`
+}
+
+func (t *PasteTool) Handle(ctx context.Context) (interface{}, error) {
+	if t.Hash == "" {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "hash is required for safety. Please capture the session first with tmux_capture to get the current hash, then use that hash in tmux_paste")
+	}
+	if t.Text == "" {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "text parameter is required. Specify the text to paste into the session")
+	}
+
+	sessionName, err := t.resolveTarget(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error pasting: %w", err)
+	}
+
+	if err := verifySessionHash(ctx, sessionName, t.Hash); err != nil {
+		return nil, err
+	}
+
+	if err := pasteText(ctx, sessionName, t.Text, t.Bracketed); err != nil {
+		return nil, err
+	}
+
+	if t.Enter {
+		if _, err := runTmuxCommand(ctx, "send-keys", "-t", sessionName, "Enter"); err != nil {
+			return nil, fmt.Errorf("failed to send Enter key to session %s: %w", sessionName, err)
+		}
+	}
+
+	maxWait := t.MaxWait
+	if maxWait == 0 {
+		maxWait = defaultWaitTimeout.Seconds()
+	}
+	ctxWithTimeout, cancel := context.WithDeadline(ctx, time.Now().Add(time.Duration(maxWait*float64(time.Second))))
+	defer cancel()
+
+	stableResult, err := waitForStability(ctxWithTimeout, sessionName)
+	if err != nil {
+		return nil, fmt.Errorf("error waiting for stability: %v", err)
+	}
+
+	return fmt.Sprintf("Pasted %d bytes to session: %s\nNew Hash: %s\n\n%s", len(t.Text), sessionName, stableResult.Hash, stableResult.Output), nil
+}
+
+// pasteText loads text into a tmux buffer of its own and pastes it into the
+// target, deleting the buffer afterwards.
+func pasteText(ctx context.Context, target, text string, bracketed bool) error {
+	tmpFile, err := os.CreateTemp("/tmp", "tmux-paste-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	_, err = tmpFile.WriteString(text)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	bufferName := fmt.Sprintf("mcp-paste-%d", time.Now().UnixNano())
+	if _, err := runTmuxCommand(ctx, "load-buffer", "-b", bufferName, tmpFile.Name()); err != nil {
+		return fmt.Errorf("failed to load text into tmux buffer: %w", err)
+	}
+
+	args := []string{"paste-buffer", "-d", "-b", bufferName, "-t", target}
+	if bracketed {
+		args = append(args, "-p")
+	}
+	if _, err := runTmuxCommand(ctx, args...); err != nil {
+		_, _ = runTmuxCommand(ctx, "delete-buffer", "-b", bufferName)
+		return fmt.Errorf("failed to paste into session %s: %w", target, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package tmuxmcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasteTool_Handle(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+	if !assert.NoError(t, waitForShellReady(t.Context(), sessionName)) {
+		return
+	}
+	stable, err := waitForStability(t.Context(), sessionName)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Paste a multi-kilobyte file into cat through a heredoc
+	outputFile := filepath.Join(t.TempDir(), "pasted.txt")
+	var content strings.Builder
+	for i := 0; i < 200; i++ {
+		content.WriteString("line with 'quotes' and $dollars and \\backslashes\n")
+	}
+	text := "cat > " + outputFile + " <<'EOF'\n" + content.String() + "EOF\n"
+
+	result, err := (&PasteTool{
+		PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}},
+		Hash:     stable.Hash,
+		Text:     text,
+		MaxWait:  5,
+	}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, result, "New Hash: ")
+
+	if !assert.Eventually(t, func() bool {
+		data, err := os.ReadFile(outputFile)
+		return err == nil && string(data) == content.String()
+	}, 5*time.Second, 100*time.Millisecond) {
+		data, _ := os.ReadFile(outputFile)
+		t.Logf("pasted file has %d bytes, expected %d", len(data), content.Len())
+	}
+
+	buffers, _ := runTmuxCommand(t.Context(), "list-buffers")
+	assert.NotContains(t, buffers, "mcp-paste-", "paste buffer should be deleted")
+}
+
+func TestPasteTool_Handle_RequiresHash(t *testing.T) {
+	_, err := (&PasteTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: "whatever"}}, Text: "hello"}).Handle(t.Context())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "hash is required for safety")
+	}
+}
@@ -0,0 +1,59 @@
+package tmuxmcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"strings"
+)
+
+func init() {
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *RenameSessionTool {
+		return &RenameSessionTool{}
+	}))
+}
+
+type RenameSessionTool struct {
+	_ mcpcommon.ToolInfo `name:"tmux_rename_session" group:"tmux" title:"Rename Tmux Session" description:"Rename a tmux session, e.g. to give it a prefix that matches the task it is now used for" destructive:"false"`
+	SessionTool
+	NewName string `json:"new_name" mcp:"required" description:"New name for the session"`
+}
+
+func (t *RenameSessionTool) Handle(ctx context.Context) (interface{}, error) {
+	if t.NewName == "" {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "new_name is required")
+	}
+	if strings.ContainsAny(t.NewName, ":.") {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "new_name must not contain ':' or '.'")
+	}
+
+	sessionName, err := resolveSession(ctx, t.Prefix, t.Session)
+	if err != nil {
+		return nil, err
+	}
+	sessionName, _, _ = strings.Cut(sessionName, ":")
+
+	// "=" makes tmux match the name exactly instead of as a prefix
+	if sessionExists(ctx, "="+t.NewName) {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "session '%s' already exists", t.NewName)
+	}
+	if err := renameSession(ctx, sessionName, t.NewName); err != nil {
+		return nil, err
+	}
+	return fmt.Sprintf("Session %s renamed to %s", sessionName, t.NewName), nil
+}
+
+// renameSession renames a session, keeping track of it if this server created it.
+func renameSession(ctx context.Context, oldName, newName string) error {
+	if _, err := runTmuxCommand(ctx, "rename-session", "-t", "="+oldName, newName); err != nil {
+		return fmt.Errorf("failed to rename session %s to %s: %w", oldName, newName, err)
+	}
+
+	createdSessionsMu.Lock()
+	defer createdSessionsMu.Unlock()
+	if _, ok := createdSessions[oldName]; ok {
+		delete(createdSessions, oldName)
+		createdSessions[newName] = struct{}{}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package tmuxmcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenameSessionTool_Handle(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	newName := sessionName + "-renamed"
+	defer func() { _ = killSession(context.Background(), newName) }()
+
+	result, err := (&RenameSessionTool{SessionTool: SessionTool{Session: sessionName}, NewName: newName}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "Session "+sessionName+" renamed to "+newName, result)
+	assert.False(t, sessionExists(t.Context(), "="+sessionName))
+	assert.True(t, sessionExists(t.Context(), "="+newName))
+}
+
+func TestRenameSessionTool_Handle_ExistingName(t *testing.T) {
+	first, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), first) }()
+	second, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), second) }()
+
+	_, err = (&RenameSessionTool{SessionTool: SessionTool{Session: first}, NewName: second}).Handle(t.Context())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "already exists")
+	}
+	assert.True(t, sessionExists(t.Context(), "="+first))
+}
+
+func TestRenameSessionTool_Handle_SessionNotFound(t *testing.T) {
+	_, err := (&RenameSessionTool{SessionTool: SessionTool{Session: "nonexistent-session"}, NewName: "other"}).Handle(t.Context())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "not found")
+	}
+}
@@ -0,0 +1,145 @@
+package tmuxmcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *RenumberSessionsTool {
+		return &RenumberSessionsTool{}
+	}))
+}
+
+type RenumberSessionsTool struct {
+	_      mcpcommon.ToolInfo `name:"tmux_renumber_sessions" group:"tmux" title:"Renumber Tmux Sessions" description:"Rename all sessions starting with a prefix to a consistent numbered scheme (name-01, name-02, ...) in the order they were created. Returns the mapping from old to new names" destructive:"false"`
+	Prefix string             `json:"prefix" description:"Rename the sessions whose names start with this prefix (auto-detected from git repo if not provided)"`
+	Name   string             `json:"name" description:"Base of the new session names (the prefix if not provided)"`
+	Start  int                `json:"start" description:"Number given to the first session" default:"1"`
+}
+
+// sessionRename is one step of a batch rename.
+type sessionRename struct {
+	Old string
+	New string
+}
+
+func (t *RenumberSessionsTool) Handle(ctx context.Context) (interface{}, error) {
+	if t.Start < 0 {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "start must not be negative")
+	}
+	prefix := t.Prefix
+	if prefix == "" {
+		prefix = detectPrefix()
+	}
+	name := t.Name
+	if name == "" {
+		name = strings.TrimSuffix(prefix, "-")
+	}
+	if strings.ContainsAny(name, ":.") {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "name must not contain ':' or '.'")
+	}
+
+	sessions, err := sessionsByCreation(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, mcpcommon.Errorf(mcpcommon.NotFound, "no sessions found with prefix '%s'", prefix)
+	}
+
+	width := max(2, len(strconv.Itoa(t.Start+len(sessions)-1)))
+	renames := make([]sessionRename, len(sessions))
+	for i, session := range sessions {
+		renames[i] = sessionRename{Old: session, New: fmt.Sprintf("%s-%0*d", name, width, t.Start+i)}
+	}
+
+	if err := renameSessions(ctx, renames); err != nil {
+		return nil, err
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Renumbered %d sessions:", len(renames))
+	for _, rename := range renames {
+		fmt.Fprintf(&result, "\n%s -> %s", rename.Old, rename.New)
+	}
+	return result.String(), nil
+}
+
+// sessionsByCreation returns the sessions starting with prefix, oldest first.
+func sessionsByCreation(ctx context.Context, prefix string) ([]string, error) {
+	output, err := runTmuxCommand(ctx, "list-sessions", "-F", "#{session_created}\t#{session_name}")
+	if err != nil {
+		if isServerNotRunning(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	type createdSession struct {
+		created int64
+		name    string
+	}
+	var sessions []createdSession
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		created, name, ok := strings.Cut(line, "\t")
+		if !ok || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		createdAt, _ := strconv.ParseInt(created, 10, 64)
+		sessions = append(sessions, createdSession{created: createdAt, name: name})
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].created != sessions[j].created {
+			return sessions[i].created < sessions[j].created
+		}
+		return sessions[i].name < sessions[j].name
+	})
+
+	names := make([]string, len(sessions))
+	for i, session := range sessions {
+		names[i] = session.name
+	}
+	return names, nil
+}
+
+// renameSessions applies a batch of renames. Every session is first moved to a
+// temporary name so that a new name may be the old name of another session in
+// the batch; new names taken by sessions outside the batch are refused up front.
+func renameSessions(ctx context.Context, renames []sessionRename) error {
+	renamed := make(map[string]bool, len(renames))
+	for _, rename := range renames {
+		renamed[rename.Old] = true
+	}
+	for _, rename := range renames {
+		if !renamed[rename.New] && sessionExists(ctx, "="+rename.New) {
+			return mcpcommon.Errorf(mcpcommon.InvalidArgument, "cannot rename %s to %s: another session already has that name", rename.Old, rename.New)
+		}
+	}
+
+	nonce := time.Now().UnixNano()
+	temporary := make([]string, len(renames))
+	for i, rename := range renames {
+		if rename.Old == rename.New {
+			continue
+		}
+		temporary[i] = fmt.Sprintf("%s-renaming-%d-%d", rename.New, nonce, i)
+		if err := renameSession(ctx, rename.Old, temporary[i]); err != nil {
+			return err
+		}
+	}
+	for i, rename := range renames {
+		if temporary[i] == "" {
+			continue
+		}
+		if err := renameSession(ctx, temporary[i], rename.New); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package tmuxmcp
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenumberSessionsTool_Handle(t *testing.T) {
+	var sessions []string
+	for i := 0; i < 3; i++ {
+		sessionName, err := createUniqueSession(t.Context(), "renumber", []string{"bash"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		sessions = append(sessions, sessionName)
+	}
+	expected := []string{"renumber-task-01", "renumber-task-02", "renumber-task-03"}
+	defer func() {
+		for _, session := range append(sessions, expected...) {
+			_ = killSession(context.Background(), "="+session)
+		}
+	}()
+
+	result, err := (&RenumberSessionsTool{Prefix: "renumber-bash-", Name: "renumber-task", Start: 1}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	resultStr := result.(string)
+	assert.Contains(t, resultStr, "Renumbered 3 sessions:")
+
+	// Sessions are numbered in the order they were created
+	renumbered, err := sessionsByCreation(t.Context(), "renumber-")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, expected, renumbered)
+	for _, session := range sessions {
+		assert.Regexp(t, fmt.Sprintf(`\n%s -> renumber-task-0[123]`, session), resultStr)
+		assert.False(t, sessionExists(t.Context(), "="+session))
+	}
+
+	// Renumbering again from a different start moves names that overlap
+	result, err = (&RenumberSessionsTool{Prefix: "renumber-task-", Name: "renumber-task", Start: 2}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "Renumbered 3 sessions:\nrenumber-task-01 -> renumber-task-02\nrenumber-task-02 -> renumber-task-03\nrenumber-task-03 -> renumber-task-04", result)
+	expected = append(expected, "renumber-task-04")
+	assert.False(t, sessionExists(t.Context(), "=renumber-task-01"))
+	assert.True(t, sessionExists(t.Context(), "=renumber-task-04"))
+}
+
+func TestRenumberSessionsTool_Handle_NoSessions(t *testing.T) {
+	_, err := (&RenumberSessionsTool{Prefix: "nonexistent-prefix-", Start: 1}).Handle(t.Context())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no sessions found")
+	}
+}
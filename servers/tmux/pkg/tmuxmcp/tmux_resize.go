@@ -0,0 +1,59 @@
+package tmuxmcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *ResizeTool {
+		return &ResizeTool{}
+	}))
+}
+
+type ResizeTool struct {
+	_ mcpcommon.ToolInfo `name:"tmux_resize" group:"tmux" title:"Resize Tmux Window or Pane" description:"Set the width and height of a session's window, or of a single pane when pane is given, so programs render at a known size (e.g. widen to 200 columns before capturing a wide table). Returns the new window and pane dimensions" destructive:"false"`
+	PaneTool
+	Width  int `json:"width" description:"New width in columns (unchanged if not provided)"`
+	Height int `json:"height" description:"New height in lines (unchanged if not provided)"`
+}
+
+func (t *ResizeTool) Handle(ctx context.Context) (interface{}, error) {
+	if t.Width < 0 || t.Height < 0 {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "width and height must not be negative")
+	}
+	if t.Width == 0 && t.Height == 0 {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "width or height is required")
+	}
+
+	target, err := t.resolveTarget(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error resizing: %w", err)
+	}
+
+	// Resizing a pane is limited by its window, so only resize the pane when asked to
+	command := "resize-window"
+	if t.Pane != "" {
+		command = "resize-pane"
+	}
+	args := []string{command, "-t", target}
+	if t.Width > 0 {
+		args = append(args, "-x", strconv.Itoa(t.Width))
+	}
+	if t.Height > 0 {
+		args = append(args, "-y", strconv.Itoa(t.Height))
+	}
+	if _, err := runTmuxCommand(ctx, args...); err != nil {
+		return nil, fmt.Errorf("failed to resize %s: %w", target, err)
+	}
+
+	size, err := runTmuxCommand(ctx, "display-message", "-t", target, "-p", "#{window_width}x#{window_height} #{pane_width}x#{pane_height}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get size of %s: %w", target, err)
+	}
+	windowSize, paneSize, _ := strings.Cut(strings.TrimSpace(size), " ")
+	return fmt.Sprintf("Resized %s\nWindow: %s\nPane: %s", target, windowSize, paneSize), nil
+}
@@ -0,0 +1,54 @@
+package tmuxmcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResizeTool_Handle_Window(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	result, err := (&ResizeTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}}, Width: 200, Height: 50}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, result, "Window: 200x50")
+	assert.Contains(t, result, "Pane: 200x50")
+}
+
+func TestResizeTool_Handle_Pane(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	_, err = (&ResizeTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}}, Width: 120, Height: 40}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = runTmuxCommand(t.Context(), "split-window", "-h", "-t", sessionName)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	result, err := (&ResizeTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}, Window: "0", Pane: "0"}, Width: 30}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, result, "Window: 120x40")
+	assert.Contains(t, result, "Pane: 30x40")
+}
+
+func TestResizeTool_Handle_RequiresSize(t *testing.T) {
+	_, err := (&ResizeTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: "whatever"}}}).Handle(t.Context())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "width or height is required")
+	}
+}
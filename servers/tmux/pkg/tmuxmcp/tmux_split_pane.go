@@ -0,0 +1,74 @@
+package tmuxmcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"strings"
+	"time"
+)
+
+func init() {
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *SplitPaneTool {
+		return &SplitPaneTool{}
+	}))
+}
+
+type SplitPaneTool struct {
+	_ mcpcommon.ToolInfo `name:"tmux_split_pane" group:"tmux" title:"Split Tmux Pane" description:"Split a pane of an existing tmux session and optionally run a command in the new pane. Returns the new pane's id, its window and pane index for targeting other tools, and an initial capture with hash" destructive:"true"`
+	PaneTool
+	Horizontal bool     `json:"horizontal" description:"Split side by side instead of one above the other"`
+	Command    []string `json:"command" description:"Command and arguments to run in the new pane (the default shell if empty)"`
+	Percent    int      `json:"percent" description:"Size of the new pane as a percentage of the split pane (half if not provided)"`
+	MaxWait    float64  `json:"max_wait" description:"Maximum seconds to wait for the new pane's output to settle"`
+}
+
+func (t *SplitPaneTool) Handle(ctx context.Context) (interface{}, error) {
+	if t.Percent < 0 || t.Percent >= 100 {
+		return nil, mcpcommon.Errorf(mcpcommon.InvalidArgument, "percent must be between 1 and 99")
+	}
+
+	target, err := t.resolveTarget(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error splitting pane: %w", err)
+	}
+
+	args := []string{"split-window", "-t", target, "-P", "-F", "#{pane_id} #{window_index} #{pane_index}"}
+	if t.Horizontal {
+		args = append(args, "-h")
+	} else {
+		args = append(args, "-v")
+	}
+	if t.Percent > 0 {
+		args = append(args, "-l", fmt.Sprintf("%d%%", t.Percent))
+	}
+	args = append(args, t.Command...)
+
+	output, err := runTmuxCommand(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split pane %s: %w", target, err)
+	}
+	fields := strings.Fields(output)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("unexpected split-window output: %q", output)
+	}
+	paneID, window, pane := fields[0], fields[1], fields[2]
+
+	sessionName, _, _ := strings.Cut(target, ":")
+	newTarget := paneTarget(sessionName, window, pane)
+
+	maxWait := t.MaxWait
+	if maxWait == 0 {
+		maxWait = defaultWaitTimeout.Seconds()
+	}
+	ctxWithTimeout, cancel := context.WithDeadline(ctx, time.Now().Add(time.Duration(maxWait*float64(time.Second))))
+	defer cancel()
+
+	stable, err := waitForStability(ctxWithTimeout, newTarget)
+	if err != nil {
+		return nil, fmt.Errorf("error waiting for stability: %v", err)
+	}
+
+	return fmt.Sprintf("Session: %s\nPane: %s (window %s, pane %s)\nHash: %s\n\n%s",
+		sessionName, paneID, window, pane, stable.Hash, stable.Output), nil
+}
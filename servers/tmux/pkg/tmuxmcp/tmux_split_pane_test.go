@@ -0,0 +1,55 @@
+package tmuxmcp
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitPaneTool_Handle(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	result, err := (&SplitPaneTool{
+		PaneTool:   PaneTool{SessionTool: SessionTool{Session: sessionName}},
+		Horizontal: true,
+		Command:    []string{"bash", "-c", "echo split-ready; sleep 30"},
+		Percent:    30,
+	}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	resultStr := result.(string)
+	assert.Contains(t, resultStr, "split-ready")
+	assert.Contains(t, resultStr, "Hash: ")
+
+	match := regexp.MustCompile(`Pane: %\d+ \(window (\d+), pane (\d+)\)`).FindStringSubmatch(resultStr)
+	if !assert.Len(t, match, 3, resultStr) {
+		return
+	}
+
+	panes, err := runTmuxCommand(t.Context(), "list-panes", "-t", sessionName)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, strings.Split(strings.TrimSpace(panes), "\n"), 2)
+
+	capture, err := (&CaptureTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}, Window: match[1], Pane: match[2]}}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, capture.(string), "split-ready")
+}
+
+func TestSplitPaneTool_Handle_InvalidPercent(t *testing.T) {
+	_, err := (&SplitPaneTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: "whatever"}}, Percent: 150}).Handle(t.Context())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "percent must be between 1 and 99")
+	}
+}
@@ -0,0 +1,148 @@
+package tmuxmcp
+
+import (
+	"context"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *StatusTool {
+		return &StatusTool{}
+	}))
+}
+
+type StatusTool struct {
+	_      mcpcommon.ToolInfo `name:"tmux_status" group:"tmux" title:"Tmux Session Status" description:"Overview of the sessions created by this server, or of all sessions matching a prefix: whether each command is still running, for how long, its last line of output and the current content hash. Finished bash commands are listed for 10 minutes" destructive:"false" readonly:"true" json_format:"compact,omitempty"`
+	Prefix string             `json:"prefix" description:"Report every session whose name starts with this prefix instead of only the sessions created by this server"`
+}
+
+// SessionStatus is one row of the tmux_status table.
+type SessionStatus struct {
+	Session string `json:"session"`
+	// State is starting, running, exited or gone (ended without an exit code).
+	State    string `json:"state"`
+	ExitCode string `json:"exit_code"`
+	Elapsed  string `json:"elapsed"`
+	LastLine string `json:"last_line"`
+	Hash     string `json:"hash"`
+}
+
+func (t *StatusTool) Handle(ctx context.Context) (interface{}, error) {
+	names, err := t.sessionNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]SessionStatus, 0, len(names))
+	for _, name := range names {
+		statuses = append(statuses, sessionStatus(ctx, name))
+	}
+	return statuses, nil
+}
+
+// sessionNames returns the sessions to report on, including finished bash
+// commands whose sessions have already closed.
+func (t *StatusTool) sessionNames(ctx context.Context) ([]string, error) {
+	names := map[string]struct{}{}
+	if t.Prefix == "" {
+		createdSessionsMu.Lock()
+		for name := range createdSessions {
+			names[name] = struct{}{}
+		}
+		createdSessionsMu.Unlock()
+	} else {
+		sessions, err := list(ctx, t.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range sessions {
+			names[name] = struct{}{}
+		}
+	}
+
+	pruneBashRuns(ctx)
+	bashRunsMu.Lock()
+	for name := range bashRuns {
+		if strings.HasPrefix(name, t.Prefix) {
+			names[name] = struct{}{}
+		}
+	}
+	bashRunsMu.Unlock()
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted, nil
+}
+
+func sessionStatus(ctx context.Context, name string) SessionStatus {
+	status := SessionStatus{Session: name, State: "gone"}
+
+	// "=" makes tmux match the name exactly instead of as a prefix; pane
+	// targets also need the trailing ":" to select the active window
+	target := "=" + name + ":"
+	var screen string
+	if sessionExists(ctx, "="+name) {
+		if pane, err := capturePane(ctx, target, captureRegion{}); err == nil {
+			screen = pane.Output
+			status.Hash = regionHash(pane, captureRegion{})
+		}
+	}
+
+	if run, ok := findBashRun(name); ok {
+		state, err := inspectBashRun(ctx, run)
+		switch {
+		case err != nil:
+			status.State = "starting"
+		case state.Exited:
+			status.State = "exited"
+			status.ExitCode = state.ExitCode
+		case state.Running:
+			status.State = "running"
+		}
+		if err == nil {
+			status.Elapsed = state.Elapsed.String()
+		}
+		run.refreshOutput()
+		status.LastLine = run.outputLastLine()
+		return status
+	}
+
+	if status.Hash == "" {
+		return status
+	}
+	info, err := runTmuxCommand(ctx, "display-message", "-t", target, "-p", "#{session_created}\t#{pane_dead}\t#{pane_dead_status}")
+	if err != nil {
+		return status
+	}
+	fields := strings.Split(strings.TrimRight(info, "\n"), "\t")
+	if len(fields) != 3 {
+		return status
+	}
+	status.State = "running"
+	if fields[1] == "1" {
+		status.State = "exited"
+		status.ExitCode = fields[2]
+	}
+	if created, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+		status.Elapsed = time.Since(time.Unix(created, 0)).Round(time.Second).String()
+	}
+	status.LastLine = lastNonEmptyLine(screen)
+	return status
+}
+
+func lastNonEmptyLine(output string) string {
+	lines := strings.Split(output, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package tmuxmcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusTool_Handle_MixedSessions(t *testing.T) {
+	running := &BashTool{
+		Prefix:           "status",
+		Command:          "echo still-going; sleep 30",
+		WorkingDirectory: "/tmp",
+		Timeout:          1.5,
+		Partial:          true,
+	}
+	run(t, running)
+	defer func() { _ = killSession(context.Background(), running.sessionName) }()
+
+	finished := &BashTool{
+		Prefix:           "status",
+		Command:          "echo all-done; exit 3",
+		WorkingDirectory: "/tmp",
+		Timeout:          10,
+	}
+	runErr(t, finished)
+
+	plain, err := createUniqueSession(t.Context(), "status", []string{"bash", "-c", "echo plain-output; sleep 30"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), plain) }()
+	if !assert.NoError(t, waitForCaptureContaining(t.Context(), plain, "plain-output")) {
+		return
+	}
+
+	result, err := (&StatusTool{Prefix: "status-"}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	statuses := map[string]SessionStatus{}
+	for _, status := range result.([]SessionStatus) {
+		statuses[status.Session] = status
+	}
+	assert.Len(t, statuses, 3)
+
+	if status, ok := statuses[running.sessionName]; assert.True(t, ok) {
+		assert.Equal(t, "running", status.State)
+		assert.Equal(t, "still-going", status.LastLine)
+		assert.NotEmpty(t, status.Hash)
+		assert.NotEmpty(t, status.Elapsed)
+	}
+	if status, ok := statuses[finished.sessionName]; assert.True(t, ok) {
+		assert.Equal(t, "exited", status.State)
+		assert.Equal(t, "3", status.ExitCode)
+		assert.Equal(t, "all-done", status.LastLine)
+		assert.Empty(t, status.Hash, "the session of a finished command is closed")
+	}
+	if status, ok := statuses[plain]; assert.True(t, ok) {
+		assert.Equal(t, "running", status.State)
+		assert.Equal(t, "plain-output", status.LastLine)
+		assert.NoError(t, verifySessionHash(t.Context(), plain, status.Hash))
+	}
+}
+
+func TestStatusTool_Handle_PrunesOldRuns(t *testing.T) {
+	finished := &BashTool{
+		Prefix:           "prune",
+		Command:          "echo done",
+		WorkingDirectory: "/tmp",
+		Timeout:          10,
+	}
+	run(t, finished)
+	job, ok := findBashRun(finished.sessionName)
+	if !assert.True(t, ok) {
+		return
+	}
+
+	// Recently finished commands are still listed
+	result, err := (&StatusTool{Prefix: finished.sessionName}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, result.([]SessionStatus), 1)
+
+	job.mu.Lock()
+	job.finishedAt = time.Now().Add(-bashRunTTL - time.Minute)
+	job.mu.Unlock()
+	result, err = (&StatusTool{Prefix: finished.sessionName}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, result.([]SessionStatus))
+	_, ok = findBashRun(finished.sessionName)
+	assert.False(t, ok)
+}
+
+func TestBashRun_RefreshOutput(t *testing.T) {
+	job := &bashRun{TmpPath: filepath.Join(t.TempDir(), "run")}
+	job.refreshOutput()
+	assert.Empty(t, job.outputLastLine(), "nothing is written before the output file exists")
+
+	write := func(text string) {
+		f, err := os.OpenFile(job.outputFile(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if assert.NoError(t, err) {
+			_, _ = f.WriteString(text)
+			_ = f.Close()
+		}
+	}
+	write("first\nsec")
+	job.refreshOutput()
+	assert.Equal(t, "sec", job.outputLastLine())
+	write("ond\n\n")
+	job.refreshOutput()
+	assert.Equal(t, "second", job.outputLastLine())
+	job.refreshOutput()
+	assert.Equal(t, "second", job.outputLastLine())
+}
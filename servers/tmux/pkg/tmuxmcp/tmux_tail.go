@@ -0,0 +1,151 @@
+package tmuxmcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *TailTool {
+		return &TailTool{}
+	}))
+}
+
+type TailTool struct {
+	_ mcpcommon.ToolInfo `name:"tmux_tail" group:"tmux" title:"Tail Tmux Session" description:"Follow a tmux session like tail -f: new output lines are streamed as progress notifications until the duration elapses, the line limit is reached or the session ends, then all streamed lines are returned" destructive:"false" readonly:"true"`
+	SessionTool
+	Duration float64 `json:"duration" description:"Seconds to follow the session" default:"10" min:"1"`
+	MaxLines int     `json:"max_lines" description:"Stop after streaming this many lines" default:"200" min:"1"`
+}
+
+func (t *TailTool) Handle(ctx context.Context) (interface{}, error) {
+	sessionName, err := resolveSession(ctx, t.Prefix, t.Session)
+	if err != nil {
+		return nil, fmt.Errorf("error tailing session: %w", err)
+	}
+
+	duration := t.Duration
+	if duration <= 0 {
+		duration = 10
+	}
+	maxLines := t.MaxLines
+	if maxLines <= 0 {
+		maxLines = 200
+	}
+
+	// Only lines that appear after the call are streamed.
+	next, err := cursorLineNumber(ctx, sessionName)
+	if err != nil {
+		return nil, fmt.Errorf("error tailing session: %w", err)
+	}
+	lastHash := ""
+	if result, err := capture(ctx, captureOptions{Prefix: sessionName}); err == nil {
+		lastHash = result.Hash
+	}
+
+	deadline := time.After(time.Duration(duration * float64(time.Second)))
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	var streamed []string
+	var reason string
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("error tailing session: %w", ctx.Err())
+		case <-deadline:
+			reason = fmt.Sprintf("after %.1f seconds", duration)
+			break loop
+		case <-ticker.C:
+			result, err := capture(ctx, captureOptions{Prefix: sessionName})
+			if err != nil {
+				if !sessionExists(ctx, sessionName) {
+					reason = "session ended"
+					break loop
+				}
+				continue
+			}
+			if result.Hash == lastHash {
+				continue
+			}
+			lastHash = result.Hash
+
+			lines, cursor, err := linesSince(ctx, sessionName, next)
+			if err != nil {
+				continue
+			}
+			next = cursor
+			if len(lines) == 0 {
+				continue
+			}
+			if remaining := maxLines - len(streamed); len(lines) > remaining {
+				lines = lines[:remaining]
+			}
+			streamed = append(streamed, lines...)
+			mcpcommon.NotifyProgress(ctx, len(streamed), maxLines, strings.Join(lines, "\n"))
+
+			if len(streamed) >= maxLines {
+				reason = fmt.Sprintf("line limit of %d reached", maxLines)
+				break loop
+			}
+		}
+	}
+
+	return fmt.Sprintf("Session: %s\nStreamed %d new lines (%s)\n\n%s",
+		sessionName, len(streamed), reason, strings.Join(streamed, "\n")), nil
+}
+
+// cursorLineNumber returns the cursor row counted from the top of the pane's
+// scrollback history, which stays fixed for a line as the pane scrolls.
+func cursorLineNumber(ctx context.Context, sessionName string) (int, error) {
+	historySize, cursorY, err := paneScrollPosition(ctx, sessionName)
+	if err != nil {
+		return 0, err
+	}
+	return historySize + cursorY, nil
+}
+
+func paneScrollPosition(ctx context.Context, sessionName string) (historySize int, cursorY int, err error) {
+	output, err := runTmuxCommand(ctx, "display-message", "-t", sessionName, "-p", "#{history_size} #{cursor_y}")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get scroll position for session %s: %w", sessionName, err)
+	}
+	history, cursor, _ := strings.Cut(strings.TrimSpace(output), " ")
+	if historySize, err = strconv.Atoi(history); err != nil {
+		return 0, 0, fmt.Errorf("invalid history size: %s", history)
+	}
+	if cursorY, err = strconv.Atoi(cursor); err != nil {
+		return 0, 0, fmt.Errorf("invalid cursor Y position: %s", cursor)
+	}
+	return historySize, cursorY, nil
+}
+
+// linesSince returns the complete lines from line number from up to (but not
+// including) the cursor line, along with the cursor's line number.
+func linesSince(ctx context.Context, sessionName string, from int) ([]string, int, error) {
+	historySize, cursorY, err := paneScrollPosition(ctx, sessionName)
+	if err != nil {
+		return nil, 0, err
+	}
+	cursor := historySize + cursorY
+	if cursor <= from {
+		// Nothing new, or the pane was cleared and the cursor moved back up
+		return nil, cursor, nil
+	}
+
+	// capture-pane addresses rows relative to the top of the visible pane,
+	// with negative numbers reaching into the history.
+	start := max(from-historySize, -historySize)
+	end := cursorY - 1
+	output, err := runTmuxCommand(ctx, "capture-pane", "-t", sessionName, "-p",
+		"-S", strconv.Itoa(start), "-E", strconv.Itoa(end))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to capture session %s: %w", sessionName, err)
+	}
+	return strings.Split(strings.TrimSuffix(output, "\n"), "\n"), cursor, nil
+}
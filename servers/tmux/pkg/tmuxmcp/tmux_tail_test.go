@@ -0,0 +1,129 @@
+package tmuxmcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"github.com/stretchr/testify/assert"
+)
+
+// testClientSession collects the notifications a tool sends to its client.
+type testClientSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testClientSession) Initialize()       {}
+func (s *testClientSession) Initialized() bool { return true }
+func (s *testClientSession) SessionID() string { return "test-client-session" }
+func (s *testClientSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func TestTailTool_StreamsNewLines(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash", "-c",
+		"sleep 1; for i in 1 2 3 4 5; do echo tail-line-$i; sleep 0.3; done; sleep 30"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	s := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true))
+	s.AddTools(mcpcommon.ReflectTool(func() *TailTool { return &TailTool{MaxLines: 200} }))
+	session := &testClientSession{notifications: make(chan mcp.JSONRPCNotification, 100)}
+
+	request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"tmux_tail","arguments":{"session":%q,"duration":4},"_meta":{"progressToken":"tail"}}}`, sessionName)
+	ctx, cancel := context.WithTimeout(s.WithContext(t.Context(), session), 20*time.Second)
+	defer cancel()
+	response := s.HandleMessage(ctx, json.RawMessage(request))
+
+	data, err := json.Marshal(response)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for i := 1; i <= 5; i++ {
+		assert.Contains(t, string(data), fmt.Sprintf("tail-line-%d", i))
+	}
+
+	var streamed strings.Builder
+	close(session.notifications)
+	for notification := range session.notifications {
+		message, _ := notification.Params.AdditionalFields["message"].(string)
+		streamed.WriteString(message + "\n")
+	}
+	for i := 1; i <= 5; i++ {
+		assert.Contains(t, streamed.String(), fmt.Sprintf("tail-line-%d", i))
+	}
+}
+
+func TestTailTool_StopsAtLineLimit(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash", "-c",
+		"sleep 1; seq 1 50; sleep 30"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	s := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true))
+	s.AddTools(mcpcommon.ReflectTool(func() *TailTool { return &TailTool{} }))
+	session := &testClientSession{notifications: make(chan mcp.JSONRPCNotification, 100)}
+
+	request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"tmux_tail","arguments":{"session":%q,"duration":10,"max_lines":5},"_meta":{"progressToken":"tail"}}}`, sessionName)
+	ctx, cancel := context.WithTimeout(s.WithContext(t.Context(), session), 20*time.Second)
+	defer cancel()
+	response, ok := s.HandleMessage(ctx, json.RawMessage(request)).(mcp.JSONRPCResponse)
+	if !assert.True(t, ok) {
+		return
+	}
+	result := response.Result.(mcp.CallToolResult).Content[0].(mcp.TextContent).Text
+	assert.Contains(t, result, "Streamed 5 new lines (line limit of 5 reached)")
+	assert.NotContains(t, result, "\n6\n")
+}
+
+func TestTailTool_RejectsNonPositiveLimits(t *testing.T) {
+	s := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true))
+	s.AddTools(mcpcommon.ReflectTool(func() *TailTool { return &TailTool{} }))
+	ctx := s.WithContext(t.Context(), &testClientSession{})
+
+	tests := []struct {
+		arguments string
+		errMsg    string
+	}{
+		{`{"session":"test","max_lines":-1}`, "parameter max_lines must be at least 1, got -1"},
+		{`{"session":"test","max_lines":0}`, "parameter max_lines must be at least 1, got 0"},
+		{`{"session":"test","duration":-5}`, "parameter duration must be at least 1, got -5"},
+		{`{"session":"test","duration":0}`, "parameter duration must be at least 1, got 0"},
+	}
+	for _, tt := range tests {
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"tmux_tail","arguments":%s}}`, tt.arguments)
+		response, ok := s.HandleMessage(ctx, json.RawMessage(request)).(mcp.JSONRPCResponse)
+		if !assert.True(t, ok, tt.arguments) {
+			continue
+		}
+		result := response.Result.(mcp.CallToolResult)
+		assert.True(t, result.IsError, tt.arguments)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, tt.errMsg)
+	}
+}
+
+func TestTailTool_NegativeLineLimitFallsBack(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash", "-c",
+		"sleep 1; seq 1 5; sleep 30"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	// Callers bypassing argument validation get the default limit, not a panic
+	result, err := (&TailTool{SessionTool: SessionTool{Session: sessionName}, Duration: 3, MaxLines: -1}).Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, result, "\n5")
+}
@@ -1,5 +1,7 @@
 package tmuxmcp
 
+import "context"
+
 type TmuxTool struct {
 }
 
@@ -8,3 +10,25 @@ type SessionTool struct {
 	Prefix  string `json:"prefix" description:"Session name prefix (auto-detected from git repo if not provided)"`
 	Session string `json:"session" description:"Specific session name (overrides prefix)"`
 }
+
+// PaneTool is a SessionTool that can also target a specific window and pane.
+// Only tools that act on a single pane embed it, so the rest never advertise
+// window or pane arguments they would ignore.
+type PaneTool struct {
+	SessionTool
+	Window string `json:"window" description:"Window index or name within the session (the session's current window if not provided)"`
+	Pane   string `json:"pane" description:"Pane index within the window (the window's current pane if not provided)"`
+}
+
+// resolveTarget resolves the session and returns the tmux target for the
+// requested window and pane, or just the session name if neither is given.
+func (t *PaneTool) resolveTarget(ctx context.Context) (string, error) {
+	sessionName, err := resolveSession(ctx, t.Prefix, t.Session)
+	if err != nil {
+		return "", err
+	}
+	if t.Window == "" && t.Pane == "" {
+		return sessionName, nil
+	}
+	return resolvePaneTarget(ctx, paneTarget(sessionName, t.Window, t.Pane))
+}
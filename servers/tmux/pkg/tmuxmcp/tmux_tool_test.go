@@ -0,0 +1,78 @@
+package tmuxmcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionTool_PaneTargeting(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	_, err = runTmuxCommand(t.Context(), "split-window", "-t", sessionName, "bash")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 30*time.Second)
+	defer cancel()
+	target := paneTarget(sessionName, "0", "1")
+	if !assert.NoError(t, waitForShellReady(ctx, target)) {
+		return
+	}
+	stable, err := waitForStability(ctx, target)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	sendResult, err := (&SendKeysTool{
+		PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}, Window: "0", Pane: "1"},
+		Hash:     stable.Hash,
+		Keys:     "echo pane-$((1+1))",
+		Enter:    true,
+		MaxWait:  10,
+	}).Handle(ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, sendResult.(string), "pane-2")
+
+	paneResult, err := (&CaptureTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}, Pane: "1"}}).Handle(ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, paneResult.(string), "pane-2")
+
+	otherResult, err := (&CaptureTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}, Window: "0", Pane: "0"}}).Handle(ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, strings.Contains(otherResult.(string), "pane-2"), "pane 0 should not show pane 1's output")
+}
+
+func TestSessionTool_MissingPane(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	_, err = (&CaptureTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}, Window: "0", Pane: "7"}}).Handle(t.Context())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "can't find pane")
+		assert.Equal(t, mcpcommon.NotFound, mcpcommon.ErrorCodeOf(err))
+	}
+
+	_, err = (&CaptureTool{PaneTool: PaneTool{SessionTool: SessionTool{Session: sessionName}, Window: "9"}}).Handle(t.Context())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "can't find window")
+	}
+}
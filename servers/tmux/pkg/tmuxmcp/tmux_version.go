@@ -0,0 +1,45 @@
+package tmuxmcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// versionProbeTimeout bounds how long tmux -V may take at startup.
+const versionProbeTimeout = 5 * time.Second
+
+// tmuxVersion runs tmux -V and returns the reported version, such as "3.4".
+// A missing binary produces an error that says how to fix it, since every tool
+// would otherwise fail the same way on first use.
+func tmuxVersion(ctx context.Context) (string, error) {
+	path, err := exec.LookPath("tmux")
+	if err != nil {
+		return "", fmt.Errorf("tmux was not found on PATH; install it (for example with apt install tmux or brew install tmux) and restart the server: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, versionProbeTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, path, "-V").CombinedOutput()
+	if err != nil {
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			return "", fmt.Errorf("%s -V failed (%d): %s", path, exitError.ExitCode(), strings.TrimSpace(string(output)))
+		}
+		return "", fmt.Errorf("%s -V failed: %w", path, err)
+	}
+	return parseTmuxVersion(string(output))
+}
+
+// parseTmuxVersion extracts the version from tmux -V output, which looks like
+// "tmux 3.4" for releases and "tmux next-3.5" for builds from source.
+func parseTmuxVersion(output string) (string, error) {
+	version, ok := strings.CutPrefix(strings.TrimSpace(output), "tmux ")
+	if !ok || version == "" || strings.ContainsAny(version, " \n") {
+		return "", fmt.Errorf("unexpected tmux -V output: %q", output)
+	}
+	return version, nil
+}
@@ -0,0 +1,37 @@
+package tmuxmcp
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseTmuxVersion(t *testing.T) {
+	for output, expected := range map[string]string{
+		"tmux 3.4\n":      "3.4",
+		"tmux 3.3a":       "3.3a",
+		"tmux next-3.5\n": "next-3.5",
+	} {
+		version, err := parseTmuxVersion(output)
+		assert.NoError(t, err, output)
+		assert.Equal(t, expected, version)
+	}
+
+	for _, output := range []string{"", "tmux", "tmux \n", "screen 4.9", "tmux 3.4\nextra"} {
+		_, err := parseTmuxVersion(output)
+		assert.Error(t, err, output)
+	}
+}
+
+func TestTmuxVersion(t *testing.T) {
+	version, err := tmuxVersion(t.Context())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, version)
+}
+
+func TestTmuxVersion_Missing(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	_, err := tmuxVersion(t.Context())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "install it")
+}
@@ -0,0 +1,106 @@
+package tmuxmcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/semistrict/mcpservers/pkg/mcpcommon"
+	"strings"
+	"time"
+)
+
+func init() {
+	Tools = append(Tools, mcpcommon.ReflectTool(func() *WaitCommandTool {
+		return &WaitCommandTool{}
+	}))
+}
+
+type WaitCommandTool struct {
+	_ mcpcommon.ToolInfo `name:"tmux_wait_command" group:"tmux" title:"Wait For Tmux Command" description:"Wait until the foreground command in a tmux session finishes or is replaced, then return the final output" destructive:"false" readonly:"true"`
+	SessionTool
+	Timeout float64 `json:"timeout" description:"Maximum seconds to wait for the foreground command to change" default:"60"`
+}
+
+// paneProcess identifies what is currently running in a pane.
+type paneProcess struct {
+	PID     string
+	Command string
+}
+
+func (t *WaitCommandTool) Handle(ctx context.Context) (interface{}, error) {
+	sessionName, err := resolveSession(ctx, t.Prefix, t.Session)
+	if err != nil {
+		return nil, fmt.Errorf("error waiting for command: %w", err)
+	}
+
+	initial, err := currentPaneProcess(ctx, sessionName)
+	if err != nil {
+		return nil, fmt.Errorf("error waiting for command: %w", err)
+	}
+
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = 60
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout*float64(time.Second)))
+	defer cancel()
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	// Keep the last capture around so we have something to return if the
+	// session exits between polls.
+	var lastOutput string
+	if result, err := capture(ctx, captureOptions{Prefix: sessionName}); err == nil {
+		lastOutput = result.Output
+	}
+	exited := func() (interface{}, error) {
+		return fmt.Sprintf("Session: %s\nSession exited while running '%s'\n\n%s",
+			sessionName, initial.Command, formatOutput(lastOutput)), nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, mcpcommon.Errorf(mcpcommon.Timeout, "'%s' still running in session %s after %.1f seconds", initial.Command, sessionName, timeout)
+
+		case <-ticker.C:
+			current, err := currentPaneProcess(ctx, sessionName)
+			if err != nil {
+				if !sessionExists(ctx, sessionName) {
+					return exited()
+				}
+				continue
+			}
+
+			result, err := capture(ctx, captureOptions{Prefix: sessionName})
+			if err == nil {
+				lastOutput = result.Output
+			}
+
+			if current == initial {
+				continue
+			}
+
+			// Give the shell a moment to finish printing before capturing.
+			final, err := waitForStability(ctx, sessionName)
+			if err != nil {
+				if !sessionExists(context.Background(), sessionName) {
+					return exited()
+				}
+				return nil, fmt.Errorf("error capturing final output: %w", err)
+			}
+			return fmt.Sprintf("Session: %s\nCommand '%s' finished (foreground is now '%s')\nHash: %s\n\n%s",
+				sessionName, initial.Command, current.Command, final.Hash, formatOutput(final.Output)), nil
+		}
+	}
+}
+
+// currentPaneProcess reports the pane's process id and its foreground command.
+func currentPaneProcess(ctx context.Context, sessionName string) (paneProcess, error) {
+	output, err := runTmuxCommand(ctx, "display-message", "-t", sessionName, "-p", "#{pane_pid} #{pane_current_command}")
+	if err != nil {
+		return paneProcess{}, fmt.Errorf("failed to get pane process for session %s: %w", sessionName, err)
+	}
+	pid, command, _ := strings.Cut(strings.TrimSpace(output), " ")
+	return paneProcess{PID: pid, Command: command}, nil
+}
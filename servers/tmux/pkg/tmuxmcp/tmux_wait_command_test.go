@@ -0,0 +1,93 @@
+package tmuxmcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitCommandTool_Handle_CommandFinishes(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 30*time.Second)
+	defer cancel()
+
+	if !assert.NoError(t, waitForShellReady(ctx, sessionName)) {
+		return
+	}
+
+	_, err = runTmuxCommand(ctx, "send-keys", "-t", sessionName, "-l", "sleep 2; echo wait-marker")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = runTmuxCommand(ctx, "send-keys", "-t", sessionName, "Enter")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Wait until sleep is in the foreground so the tool records it as the initial command.
+	for {
+		proc, err := currentPaneProcess(ctx, sessionName)
+		if err == nil && proc.Command == "sleep" {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("sleep never became the foreground command")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	tool := &WaitCommandTool{
+		SessionTool: SessionTool{Session: sessionName},
+		Timeout:     20,
+	}
+	result, err := tool.Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	resultStr := result.(string)
+	assert.Contains(t, resultStr, "Command 'sleep' finished")
+	assert.Contains(t, resultStr, "foreground is now 'bash'")
+	assert.Contains(t, resultStr, "wait-marker")
+}
+
+func TestWaitCommandTool_Handle_SessionExits(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"sleep", "1"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tool := &WaitCommandTool{
+		SessionTool: SessionTool{Session: sessionName},
+		Timeout:     10,
+	}
+	result, err := tool.Handle(t.Context())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, result.(string), "Session exited while running 'sleep'")
+}
+
+func TestWaitCommandTool_Handle_Timeout(t *testing.T) {
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"sleep", "30"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+
+	tool := &WaitCommandTool{
+		SessionTool: SessionTool{Session: sessionName},
+		Timeout:     0.5,
+	}
+	_, err = tool.Handle(t.Context())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "still running")
+	}
+}
@@ -0,0 +1,47 @@
+package tmuxmcp
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maskVolatileLines empties the lines matching volatileLines, if it is set.
+func maskVolatileLines(content string) string {
+	if volatileLines == nil {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if volatileLines.MatchString(stripANSI(line)) {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// blankCell replaces the character at column x of row y of the visible screen, the
+// last height lines of the capture, with a space. Escape sequences take no columns.
+func blankCell(capture string, y, x, height int) string {
+	lines := strings.Split(capture, "\n")
+	// The capture ends with a newline, so the last element is not a line
+	row := len(lines) - 1 - height + y
+	if row < 0 || row >= len(lines)-1 {
+		return capture
+	}
+	line := lines[row]
+	column := 0
+	for i := 0; i < len(line); {
+		if escape := ansiEscape.FindStringIndex(line[i:]); escape != nil && escape[0] == 0 {
+			i += escape[1]
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(line[i:])
+		if column == x {
+			lines[row] = line[:i] + " " + line[i+size:]
+			return strings.Join(lines, "\n")
+		}
+		column++
+		i += size
+	}
+	return capture
+}
@@ -0,0 +1,94 @@
+package tmuxmcp
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// useStabilityMasks sets the stability masks until the test ends. The masks are
+// package globals every capture reads, so tests using it must not run in parallel.
+func useStabilityMasks(t *testing.T, cursor bool, lines string) {
+	previousCursor, previousLines := ignoreCursor, volatileLines
+	ignoreCursor, volatileLines = cursor, nil
+	if lines != "" {
+		volatileLines = regexp.MustCompile(lines)
+	}
+	t.Cleanup(func() {
+		ignoreCursor, volatileLines = previousCursor, previousLines
+	})
+}
+
+func TestBlankCell(t *testing.T) {
+	capture := "history\nab█\n\x1b[31mred\x1b[0m\n\n"
+	// The visible screen is the last three lines; the first is history
+	assert.Equal(t, "history\nab \n\x1b[31mred\x1b[0m\n\n", blankCell(capture, 0, 2, 3))
+	assert.Equal(t, "history\nab█\n\x1b[31mr d\x1b[0m\n\n", blankCell(capture, 1, 1, 3))
+	// Past the end of the line or outside the screen nothing changes
+	assert.Equal(t, capture, blankCell(capture, 2, 5, 3))
+	assert.Equal(t, capture, blankCell(capture, 3, 0, 3))
+}
+
+func TestCapturePane_IgnoreCursor_Integration(t *testing.T) {
+	useStabilityMasks(t, true, "")
+
+	// Park the cursor on the "d" of cursor-abcdef
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash", "-c", "clear; printf 'cursor-abcdef\\033[3D'; sleep 30"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+	if !assert.NoError(t, waitForCaptureContaining(t.Context(), sessionName, "cursor-abcdef")) {
+		return
+	}
+
+	// Only the hash ignores the cursor cell; callers see the character under it
+	pane, err := capturePane(t.Context(), sessionName, captureRegion{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, pane.Output, "cursor-abcdef")
+	assert.Contains(t, pane.Hashed, "cursor-abc ef")
+
+	result, err := capture(t.Context(), captureOptions{Prefix: sessionName})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, result.Output, "cursor-abcdef")
+	assert.Equal(t, calculateHash(pane.Hashed), result.Hash)
+}
+
+func TestCalculateHash_VolatileLines(t *testing.T) {
+	before, after := "output\n12:00:01 status\n", "output\n12:00:02 status\n"
+	assert.NotEqual(t, calculateHash(before), calculateHash(after))
+
+	useStabilityMasks(t, false, `^\d\d:\d\d:\d\d status$`)
+	assert.Equal(t, calculateHash(before), calculateHash(after))
+	assert.NotEqual(t, calculateHash(before), calculateHash("changed\n12:00:01 status\n"))
+}
+
+func TestWaitForStability_VolatileLines_Integration(t *testing.T) {
+	useStabilityMasks(t, true, `^tick \d+$`)
+
+	sessionName, err := createUniqueSession(t.Context(), "test", []string{"bash", "-c", "clear; echo steady; while true; do printf '\\rtick %s' $RANDOM; sleep 0.1; done"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = killSession(context.Background(), sessionName) }()
+	if !assert.NoError(t, waitForCaptureContaining(t.Context(), sessionName, "tick")) {
+		return
+	}
+
+	// Without the mask the pane never settles and the wait gives up
+	ctx, cancel := context.WithTimeout(t.Context(), 15*time.Second)
+	defer cancel()
+	result, err := waitForStability(ctx, sessionName)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, result.StillChanging, "the ticking line should not keep the pane from settling")
+	assert.Contains(t, result.Output, "steady")
+	assert.NoError(t, verifySessionHash(t.Context(), sessionName, result.Hash))
+}